@@ -0,0 +1,143 @@
+// Package apperr 定义应用错误类型，处理函数返回的错误统一由 middleware.ErrorHandler 渲染
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code 错误码，客户端按错误码而不是错误信息判断错误类型
+type Code string
+
+const (
+	CodeValidation           Code = "VALIDATION"
+	CodeUnauthorized         Code = "UNAUTHORIZED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeConflict             Code = "CONFLICT"
+	CodeMethod               Code = "METHOD_NOT_ALLOWED"
+	CodePrecondition         Code = "PRECONDITION_FAILED"
+	CodePreconditionRequired Code = "PRECONDITION_REQUIRED"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeMediaType            Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeInternal             Code = "INTERNAL"
+)
+
+// statusByCode 错误码对应的HTTP状态码
+var statusByCode = map[Code]int{
+	CodeValidation:           http.StatusBadRequest,
+	CodeUnauthorized:         http.StatusUnauthorized,
+	CodeForbidden:            http.StatusForbidden,
+	CodeNotFound:             http.StatusNotFound,
+	CodeConflict:             http.StatusConflict,
+	CodeMethod:               http.StatusMethodNotAllowed,
+	CodePrecondition:         http.StatusPreconditionFailed,
+	CodePreconditionRequired: http.StatusPreconditionRequired,
+	CodeRateLimited:          http.StatusTooManyRequests,
+	CodeMediaType:            http.StatusUnsupportedMediaType,
+	CodeInternal:             http.StatusInternalServerError,
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error 应用错误，Message 会返回给客户端，Err 为底层原因，只记录日志
+// Meta 为随错误返回给客户端的附加信息，如冲突时资源的当前版本
+type Error struct {
+	Code    Code
+	Message string
+	Details []FieldError
+	Meta    map[string]any
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithMeta 附加一项返回给客户端的信息
+func (e *Error) WithMeta(key string, value any) *Error {
+	if e.Meta == nil {
+		e.Meta = make(map[string]any)
+	}
+	e.Meta[key] = value
+	return e
+}
+
+// Status 错误对应的HTTP状态码
+func (e *Error) Status() int {
+	if s, ok := statusByCode[e.Code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// New 创建指定错误码的错误
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Validation 请求参数不合法，details 为各字段的错误
+func Validation(message string, details ...FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: message, Details: details}
+}
+
+// Unauthorized 未认证或认证失败
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+// Forbidden 已认证但无权执行操作
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+// NotFound 资源不存在
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+// Conflict 与现有数据冲突，如唯一键重复
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+// PreconditionFailed If-Match 等条件请求头与资源当前状态不符
+func PreconditionFailed(message string) *Error {
+	return New(CodePrecondition, message)
+}
+
+// PreconditionRequired 修改资源时缺少 If-Match 等前置条件
+func PreconditionRequired(message string) *Error {
+	return New(CodePreconditionRequired, message)
+}
+
+// TooManyRequests 请求过于频繁，调用方应同时设置 Retry-After
+func TooManyRequests(message string) *Error {
+	return New(CodeRateLimited, message)
+}
+
+// Internal 服务器内部错误，err 只记录日志不返回给客户端
+func Internal(message string, err error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
+
+// From 把任意错误转换为应用错误，已是 *Error 时原样返回，数据库错误按 FromDB 转换
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return FromDB(err, "Record")
+}
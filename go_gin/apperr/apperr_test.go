@@ -0,0 +1,147 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want int
+	}{
+		{Validation("bad"), http.StatusBadRequest},
+		{Unauthorized("no"), http.StatusUnauthorized},
+		{Forbidden("no"), http.StatusForbidden},
+		{NotFound("no"), http.StatusNotFound},
+		{Conflict("no"), http.StatusConflict},
+		{New(CodeMethod, "no"), http.StatusMethodNotAllowed},
+		{PreconditionFailed("no"), http.StatusPreconditionFailed},
+		{PreconditionRequired("no"), http.StatusPreconditionRequired},
+		{TooManyRequests("no"), http.StatusTooManyRequests},
+		{New(CodeMediaType, "no"), http.StatusUnsupportedMediaType},
+		{Internal("no", nil), http.StatusInternalServerError},
+		{New("UNKNOWN", "no"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Status(); got != tt.want {
+			t.Errorf("%s status = %d, want %d", tt.err.Code, got, tt.want)
+		}
+	}
+}
+
+func TestFrom(t *testing.T) {
+	orig := Conflict("Post version mismatch").WithMeta("version", 3)
+	if got := From(fmt.Errorf("update: %w", orig)); got != orig {
+		t.Errorf("From wrapped *Error = %v, want the original error", got)
+	}
+
+	cause := errors.New("connection refused")
+	got := From(cause)
+	if got.Code != CodeInternal || got.Message != "Internal server error" || !errors.Is(got, cause) {
+		t.Errorf("From plain error = %+v, want INTERNAL wrapping the cause", got)
+	}
+}
+
+func TestFromDB(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code Code
+		msg  string
+	}{
+		{"not found", gorm.ErrRecordNotFound, CodeNotFound, "Post not found"},
+		{"duplicated key", gorm.ErrDuplicatedKey, CodeConflict, "Post already exists"},
+		{"foreign key", gorm.ErrForeignKeyViolated, CodeValidation, "Referenced record does not exist"},
+		{"wrapped not found", fmt.Errorf("load: %w", gorm.ErrRecordNotFound), CodeNotFound, "Post not found"},
+		{"mysql duplicate entry", &mysql.MySQLError{Number: mysqlDuplicateEntry}, CodeConflict, "Post already exists"},
+		{"mysql no referenced row", &mysql.MySQLError{Number: mysqlNoReferencedRow}, CodeValidation, "Referenced record does not exist"},
+		{"mysql row is referenced", &mysql.MySQLError{Number: mysqlRowIsReferenced}, CodeConflict, "Post is still referenced"},
+		{"mysql data too long", &mysql.MySQLError{Number: mysqlDataTooLong}, CodeValidation, "Value too long"},
+		{"mysql deadlock", &mysql.MySQLError{Number: mysqlDeadlockDetected}, CodeConflict, "Concurrent update, please retry"},
+		{"other mysql error", &mysql.MySQLError{Number: 1045}, CodeInternal, "Internal server error"},
+		{"unknown error", errors.New("boom"), CodeInternal, "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromDB(tt.err, "Post")
+			if got.Code != tt.code || got.Message != tt.msg {
+				t.Errorf("FromDB = %s %q, want %s %q", got.Code, got.Message, tt.code, tt.msg)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("FromDB lost the cause: %v", got)
+			}
+		})
+	}
+}
+
+type bindRequest struct {
+	Title string   `json:"title" binding:"required,max=5"`
+	Email string   `json:"email" binding:"omitempty,email"`
+	Age   int      `json:"age" binding:"omitempty,gte=18"`
+	Tags  []string `json:"tags" binding:"max=2"`
+	Kind  string   `json:"kind" binding:"omitempty,oneof=post page"`
+}
+
+func TestFromBinding(t *testing.T) {
+	UseJSONFieldNames()
+
+	tests := []struct {
+		name    string
+		body    string
+		msg     string
+		details []FieldError
+	}{
+		{
+			name: "validation errors use json field names",
+			body: `{"title":"","email":"nope","age":3,"tags":["a","b","c"],"kind":"note"}`,
+			msg:  "Validation failed",
+			details: []FieldError{
+				{Field: "title", Rule: "required", Message: "is required"},
+				{Field: "email", Rule: "email", Message: "must be a valid email address"},
+				{Field: "age", Rule: "gte", Message: "must be gte 18"},
+				{Field: "tags", Rule: "max", Message: "must have at most 2 items"},
+				{Field: "kind", Rule: "oneof", Message: "must be one of post, page"},
+			},
+		},
+		{
+			name:    "string too long",
+			body:    `{"title":"too long"}`,
+			msg:     "Validation failed",
+			details: []FieldError{{Field: "title", Rule: "max", Message: "must be at most 5 characters"}},
+		},
+		{
+			name:    "wrong json type",
+			body:    `{"title":"ok","age":"old"}`,
+			msg:     "Validation failed",
+			details: []FieldError{{Field: "age", Rule: "type", Message: "must be int"}},
+		},
+		{name: "empty body", body: ``, msg: "Request body is required"},
+		{name: "malformed json", body: `{"title":`, msg: "Invalid request body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req bindRequest
+			err := binding.JSON.BindBody([]byte(tt.body), &req)
+			if err == nil {
+				t.Fatal("BindBody succeeded, want error")
+			}
+			got := FromBinding(err)
+			if got.Code != CodeValidation || got.Status() != http.StatusBadRequest {
+				t.Errorf("code = %s status = %d, want VALIDATION 400", got.Code, got.Status())
+			}
+			if got.Message != tt.msg {
+				t.Errorf("message = %q, want %q", got.Message, tt.msg)
+			}
+			if fmt.Sprint(got.Details) != fmt.Sprint(tt.details) {
+				t.Errorf("details = %+v, want %+v", got.Details, tt.details)
+			}
+		})
+	}
+}
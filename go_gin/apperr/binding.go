@@ -0,0 +1,98 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// UseJSONFieldNames 让校验错误中的字段名使用 json 标签，与请求体中的字段一致
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+}
+
+// FromBinding 把 ShouldBindJSON/ShouldBindQuery 返回的错误转换为带字段详情的 VALIDATION 错误
+func FromBinding(err error) *Error {
+	var ves validator.ValidationErrors
+	if errors.As(err, &ves) {
+		details := make([]FieldError, len(ves))
+		for i, fe := range ves {
+			details[i] = FieldError{Field: fe.Field(), Rule: fe.Tag(), Message: ruleMessage(fe)}
+		}
+		return &Error{Code: CodeValidation, Message: "Validation failed", Details: details, Err: err}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{
+			Code:    CodeValidation,
+			Message: "Validation failed",
+			Details: []FieldError{{Field: typeErr.Field, Rule: "type", Message: "must be " + typeErr.Type.String()}},
+			Err:     err,
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		return &Error{Code: CodeValidation, Message: "Request body is required", Err: err}
+	}
+	return &Error{Code: CodeValidation, Message: "Invalid request body", Err: err}
+}
+
+// ruleMessage 常见校验规则的错误描述
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		switch fe.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		case reflect.Slice, reflect.Map:
+			return fmt.Sprintf("must have at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "len":
+		return "must be exactly " + fe.Param() + " long"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "notblank":
+		return "must not be blank"
+	case "username":
+		return "may only contain letters, digits and underscores"
+	case "password":
+		return "must contain both letters and digits and be at most 72 bytes"
+	case "slug":
+		return "must contain at least one letter or digit"
+	case "nefield":
+		return "must differ from " + fe.Param()
+	case "gt", "gte", "lt", "lte":
+		return fmt.Sprintf("must be %s %s", fe.Tag(), fe.Param())
+	}
+	return "failed on " + fe.Tag() + " validation"
+}
@@ -0,0 +1,54 @@
+package apperr
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// MySQL 错误号
+const (
+	mysqlDuplicateEntry   = 1062
+	mysqlNoReferencedRow  = 1452
+	mysqlRowIsReferenced  = 1451
+	mysqlDataTooLong      = 1406
+	mysqlDeadlockDetected = 1213
+)
+
+// FromDB 把数据库错误转换为应用错误，resource 为资源名称，如 "Post"
+// 记录不存在 → NOT_FOUND，唯一键冲突 → CONFLICT，外键约束 → VALIDATION/CONFLICT，其他 → INTERNAL
+func FromDB(err error, resource string) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &Error{Code: CodeNotFound, Message: resource + " not found", Err: err}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return &Error{Code: CodeConflict, Message: resource + " already exists", Err: err}
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return &Error{Code: CodeValidation, Message: "Referenced record does not exist", Err: err}
+	}
+
+	// 未开启 TranslateError 的连接直接检查 MySQL 错误号
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case mysqlDuplicateEntry:
+			return &Error{Code: CodeConflict, Message: resource + " already exists", Err: err}
+		case mysqlNoReferencedRow:
+			return &Error{Code: CodeValidation, Message: "Referenced record does not exist", Err: err}
+		case mysqlRowIsReferenced:
+			return &Error{Code: CodeConflict, Message: resource + " is still referenced", Err: err}
+		case mysqlDataTooLong:
+			return &Error{Code: CodeValidation, Message: "Value too long", Err: err}
+		case mysqlDeadlockDetected:
+			return &Error{Code: CodeConflict, Message: "Concurrent update, please retry", Err: err}
+		}
+	}
+
+	return Internal("Internal server error", err)
+}
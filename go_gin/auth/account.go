@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/mail"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidUserToken 邮箱验证或重置密码的令牌签名错误、不存在、已过期或已使用
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+// AccountOptions 邮箱验证和找回密码的配置
+type AccountOptions struct {
+	Secret      string        // 一次性令牌的HMAC签名密钥
+	VerifyTTL   time.Duration // 邮箱验证链接有效期
+	ResetTTL    time.Duration // 重置密码链接有效期
+	LinkBaseURL string        // 邮件中链接的前缀
+	// RequireVerify 为 true 时邮箱未验证的用户不能登录
+	RequireVerify bool
+}
+
+// Accounts 管理邮箱验证和找回密码
+// 令牌由随机数和对随机数、用途的HMAC签名组成，数据库只保存哈希，核销时先校验签名再查库，
+// 每个令牌只能使用一次，同一用途发出新令牌后旧令牌失效；
+// 令牌记录了发往的邮箱，用户改邮箱后发往旧邮箱的令牌不能再用来验证新邮箱
+type Accounts struct {
+	users    repository.UserRepository
+	tokens   repository.TokenRepository
+	sessions *Service
+	mailer   mail.Mailer
+	opts     AccountOptions
+}
+
+// NewAccounts 创建账号服务，重置密码后通过 sessions 吊销用户的全部会话
+func NewAccounts(users repository.UserRepository, tokens repository.TokenRepository, sessions *Service,
+	mailer mail.Mailer, opts AccountOptions) *Accounts {
+	opts.LinkBaseURL = strings.TrimRight(opts.LinkBaseURL, "/")
+	return &Accounts{users: users, tokens: tokens, sessions: sessions, mailer: mailer, opts: opts}
+}
+
+// SendVerification 给用户当前的邮箱发送验证链接
+func (a *Accounts) SendVerification(ctx context.Context, user *model.User) error {
+	token, err := a.issue(ctx, user, model.TokenPurposeVerifyEmail, a.opts.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	return a.mailer.Send(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: "验证你的邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %s 内打开下面的链接完成邮箱验证：\n\n%s/verify-email?token=%s\n\n如果这不是你的操作，请忽略本邮件。\n",
+			user.Username, formatTTL(a.opts.VerifyTTL), a.opts.LinkBaseURL, token),
+	})
+}
+
+// EmailChanged 用户修改邮箱后作废未使用的验证令牌，并给新邮箱发送验证链接
+func (a *Accounts) EmailChanged(ctx context.Context, user *model.User) error {
+	if err := a.tokens.DeleteUserTokens(ctx, user.ID, model.TokenPurposeVerifyEmail); err != nil {
+		return err
+	}
+	return a.SendVerification(ctx, user)
+}
+
+// CanLogin 用户是否满足登录的邮箱验证要求
+func (a *Accounts) CanLogin(user *model.User) bool {
+	return !a.opts.RequireVerify || user.EmailVerifiedAt != nil
+}
+
+// ResendVerification 按邮箱重新发送验证链接，邮箱未注册或已验证时什么也不做，避免泄露邮箱是否注册
+func (a *Accounts) ResendVerification(ctx context.Context, email string) error {
+	user, err := a.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return a.SendVerification(ctx, user)
+}
+
+// VerifyEmail 核销验证令牌并记录邮箱验证时间
+func (a *Accounts) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	user, err := a.consume(ctx, token, model.TokenPurposeVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := a.users.Update(ctx, user, "EmailVerifiedAt"); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// SendPasswordReset 给邮箱发送重置密码链接，邮箱未注册时什么也不做，避免泄露邮箱是否注册
+func (a *Accounts) SendPasswordReset(ctx context.Context, email string) error {
+	user, err := a.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := a.issue(ctx, user, model.TokenPurposeResetPassword, a.opts.ResetTTL)
+	if err != nil {
+		return err
+	}
+	return a.mailer.Send(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: "重置你的密码",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %s 内打开下面的链接设置新密码：\n\n%s/reset-password?token=%s\n\n如果这不是你的操作，请忽略本邮件，你的密码不会改变。\n",
+			user.Username, formatTTL(a.opts.ResetTTL), a.opts.LinkBaseURL, token),
+	})
+}
+
+// ResetPassword 核销重置令牌并设置新密码，用户的全部会话随即失效
+// 能收到重置邮件说明邮箱属于该用户，邮箱未验证时一并标记为已验证
+func (a *Accounts) ResetPassword(ctx context.Context, token, newPassword string) (*model.User, error) {
+	user, err := a.consume(ctx, token, model.TokenPurposeResetPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = string(hashedPassword)
+	fields := []string{"Password"}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		fields = append(fields, "EmailVerifiedAt")
+	}
+	if err := a.users.Update(ctx, user, fields...); err != nil {
+		return nil, err
+	}
+	if err := a.sessions.RevokeUser(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// issue 签发一次性令牌并保存其哈希和收件邮箱
+func (a *Accounts) issue(ctx context.Context, user *model.User, purpose string, ttl time.Duration) (string, error) {
+	nonce, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	token := nonce + "." + a.sign(purpose, nonce)
+	err = a.tokens.CreateUserToken(ctx, &model.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consume 校验并核销令牌，返回令牌所属的用户
+// 已注销的用户，或用户邮箱已不是令牌发往的邮箱时，视为令牌无效
+func (a *Accounts) consume(ctx context.Context, token, purpose string) (*model.User, error) {
+	nonce, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(a.sign(purpose, nonce))) {
+		return nil, ErrInvalidUserToken
+	}
+
+	stored, err := a.tokens.FindUserTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if stored.Purpose != purpose || stored.UsedAt != nil || now.After(stored.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+
+	used, err := a.tokens.MarkUserTokenUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		// 并发请求抢先使用了同一个令牌
+		return nil, ErrInvalidUserToken
+	}
+
+	user, err := a.users.FindByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+	if user.Email != stored.Email {
+		return nil, ErrInvalidUserToken
+	}
+	return user, nil
+}
+
+// sign 对用途和随机数签名，不同用途的令牌不能互相替代
+func (a *Accounts) sign(purpose, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(a.opts.Secret))
+	mac.Write([]byte(purpose + "." + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// formatTTL 邮件中展示的有效期，如 "1小时"、"48小时"、"30分钟"
+func formatTTL(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d小时", d/time.Hour)
+	}
+	return fmt.Sprintf("%d分钟", (d+time.Minute-1)/time.Minute)
+}
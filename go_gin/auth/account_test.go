@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/mail"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// outbox 记录发出的邮件
+type outbox struct {
+	sent []*mail.Message
+}
+
+func (o *outbox) Send(_ context.Context, msg *mail.Message) error {
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+// lastToken 取出最后一封发往 to 的邮件中链接的令牌
+func (o *outbox) lastToken(t *testing.T, to string) string {
+	t.Helper()
+	for i := len(o.sent) - 1; i >= 0; i-- {
+		msg := o.sent[i]
+		if msg.To != to {
+			continue
+		}
+		_, rest, ok := strings.Cut(msg.Body, "?token=")
+		if !ok {
+			t.Fatalf("mail to %s has no token link", to)
+		}
+		token, _, _ := strings.Cut(rest, "\n")
+		token, err := url.QueryUnescape(token)
+		if err != nil {
+			t.Fatalf("unescape token: %v", err)
+		}
+		return token
+	}
+	t.Fatalf("no mail sent to %s", to)
+	return ""
+}
+
+func newTestAccounts(t *testing.T) (*Accounts, *repository.Store, *outbox) {
+	t.Helper()
+	store := newTestStore(t)
+	box := &outbox{}
+	sessions := NewService(store.Users, store.Tokens, time.Hour)
+	accounts := NewAccounts(store.Users, store.Tokens, sessions, box, AccountOptions{
+		Secret:      "account-secret",
+		VerifyTTL:   time.Hour,
+		ResetTTL:    time.Hour,
+		LinkBaseURL: "http://localhost/",
+	})
+	return accounts, store, box
+}
+
+func TestVerifyEmail(t *testing.T) {
+	accounts, store, box := newTestAccounts(t)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice")
+
+	if err := accounts.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	token := box.lastToken(t, user.Email)
+	verified, err := accounts.VerifyEmail(ctx, token)
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if verified.EmailVerifiedAt == nil {
+		t.Error("email not marked verified")
+	}
+	if _, err := accounts.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("reusing token: error = %v, want ErrInvalidUserToken", err)
+	}
+}
+
+// TestVerifyEmailAfterEmailChange 发往旧邮箱的令牌不能用来验证新邮箱
+func TestVerifyEmailAfterEmailChange(t *testing.T) {
+	accounts, store, box := newTestAccounts(t)
+	ctx := context.Background()
+	user := createTestUser(t, store, "mallory")
+
+	if err := accounts.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	oldToken := box.lastToken(t, "mallory@example.com")
+
+	user.Email = "ceo@victim.example"
+	if err := store.Users.Update(ctx, user, "Email"); err != nil {
+		t.Fatalf("update email: %v", err)
+	}
+	if _, err := accounts.VerifyEmail(ctx, oldToken); !errors.Is(err, ErrInvalidUserToken) {
+		t.Fatalf("token sent to old address: error = %v, want ErrInvalidUserToken", err)
+	}
+	if u, _ := store.Users.FindByID(ctx, user.ID); u.EmailVerifiedAt != nil {
+		t.Fatal("new address marked verified with a token sent to the old address")
+	}
+
+	// 重新发送的验证邮件发往新邮箱，可以正常验证
+	if err := accounts.EmailChanged(ctx, user); err != nil {
+		t.Fatalf("EmailChanged: %v", err)
+	}
+	if _, err := accounts.VerifyEmail(ctx, box.lastToken(t, "ceo@victim.example")); err != nil {
+		t.Errorf("token sent to new address: %v", err)
+	}
+}
+
+// TestEmailChangedDiscardsOutstandingTokens 改邮箱后未使用的验证令牌被删除，改回原邮箱也不能再用
+func TestEmailChangedDiscardsOutstandingTokens(t *testing.T) {
+	accounts, store, box := newTestAccounts(t)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice")
+
+	if err := accounts.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	oldToken := box.lastToken(t, user.Email)
+	if err := accounts.EmailChanged(ctx, user); err != nil {
+		t.Fatalf("EmailChanged: %v", err)
+	}
+	if _, err := accounts.VerifyEmail(ctx, oldToken); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("outstanding token after email change: error = %v, want ErrInvalidUserToken", err)
+	}
+}
+
+func TestUserTokenPurposes(t *testing.T) {
+	accounts, store, box := newTestAccounts(t)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice")
+
+	if err := accounts.SendPasswordReset(ctx, user.Email); err != nil {
+		t.Fatalf("SendPasswordReset: %v", err)
+	}
+	resetToken := box.lastToken(t, user.Email)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"reset token used for verification", resetToken},
+		{"tampered signature", resetToken + "x"},
+		{"no signature", strings.Split(resetToken, ".")[0]},
+		{"garbage", "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := accounts.VerifyEmail(ctx, tt.token); !errors.Is(err, ErrInvalidUserToken) {
+				t.Errorf("VerifyEmail error = %v, want ErrInvalidUserToken", err)
+			}
+		})
+	}
+
+	reset, err := accounts.ResetPassword(ctx, resetToken, "new-password-1")
+	if err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if reset.EmailVerifiedAt == nil {
+		t.Error("reset password did not mark the email verified")
+	}
+}
+
+// TestAccountMails 验证和重置邮件发往用户绑定的邮箱，链接中的令牌带有对应用途的签名，库中只保存哈希
+func TestAccountMails(t *testing.T) {
+	accounts, store, box := newTestAccounts(t)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice")
+
+	tests := []struct {
+		name    string
+		send    func() error
+		purpose string
+		link    string
+		subject string
+	}{
+		{"verify", func() error { return accounts.SendVerification(ctx, user) },
+			model.TokenPurposeVerifyEmail, "http://localhost/verify-email?token=", "验证你的邮箱"},
+		{"reset", func() error { return accounts.SendPasswordReset(ctx, user.Email) },
+			model.TokenPurposeResetPassword, "http://localhost/reset-password?token=", "重置你的密码"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(box.sent)
+			if err := tt.send(); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			if len(box.sent) != before+1 {
+				t.Fatalf("sent %d mails, want 1", len(box.sent)-before)
+			}
+			msg := box.sent[before]
+			if msg.To != "alice@example.com" || msg.Subject != tt.subject {
+				t.Errorf("mail to %q subject %q, want alice@example.com %q", msg.To, msg.Subject, tt.subject)
+			}
+			if !strings.Contains(msg.Body, tt.link) || !strings.Contains(msg.Body, "1小时") {
+				t.Errorf("body = %q, want link %s and ttl", msg.Body, tt.link)
+			}
+
+			token := box.lastToken(t, user.Email)
+			nonce, sig, ok := strings.Cut(token, ".")
+			if !ok || sig != accounts.sign(tt.purpose, nonce) {
+				t.Errorf("token %q is not signed for %s", token, tt.purpose)
+			}
+			stored, err := store.Tokens.FindUserTokenByHash(ctx, hashToken(token))
+			if err != nil {
+				t.Fatalf("find token: %v", err)
+			}
+			if stored.UserID != user.ID || stored.Purpose != tt.purpose || stored.Email != user.Email {
+				t.Errorf("stored token = %+v", stored)
+			}
+		})
+	}
+
+	// 未注册的邮箱不发送重置邮件，已验证的邮箱不再发送验证邮件
+	before := len(box.sent)
+	if err := accounts.SendPasswordReset(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("SendPasswordReset unknown: %v", err)
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := store.Users.Update(ctx, user, "EmailVerifiedAt"); err != nil {
+		t.Fatalf("verify user: %v", err)
+	}
+	if err := accounts.ResendVerification(ctx, user.Email); err != nil {
+		t.Fatalf("ResendVerification verified: %v", err)
+	}
+	if len(box.sent) != before {
+		t.Errorf("sent %d mails to unknown or verified addresses", len(box.sent)-before)
+	}
+
+	// 改邮箱后重置邮件发往新邮箱
+	user.Email = "alice@new.example"
+	if err := store.Users.Update(ctx, user, "Email"); err != nil {
+		t.Fatalf("update email: %v", err)
+	}
+	if err := accounts.SendPasswordReset(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("SendPasswordReset old address: %v", err)
+	}
+	if err := accounts.SendPasswordReset(ctx, user.Email); err != nil {
+		t.Fatalf("SendPasswordReset: %v", err)
+	}
+	if len(box.sent) != before+1 || box.sent[before].To != "alice@new.example" {
+		t.Fatalf("mails after email change = %+v, want one to alice@new.example", box.sent[before:])
+	}
+	if _, err := accounts.ResetPassword(ctx, box.lastToken(t, user.Email), "new-password-1"); err != nil {
+		t.Errorf("ResetPassword with token sent to new address: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"github.com/zhanglegen/go_task/go_gin/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或已被吊销
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused 已轮换过的刷新令牌被再次使用，用户全部会话已被强制下线
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// TokenPair 一次签发的访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // 访问令牌有效期
+}
+
+// Service 管理登录会话：签发、轮换和吊销令牌
+type Service struct {
+	users      repository.UserRepository
+	tokens     repository.TokenRepository
+	refreshTTL time.Duration
+}
+
+// NewService 创建会话服务，refreshTTL 为刷新令牌有效期
+func NewService(users repository.UserRepository, tokens repository.TokenRepository, refreshTTL time.Duration) *Service {
+	return &Service{users: users, tokens: tokens, refreshTTL: refreshTTL}
+}
+
+// IssueTokens 登录成功后开启新会话并签发令牌
+func (s *Service) IssueTokens(ctx context.Context, user *model.User) (*TokenPair, error) {
+	familyID, err := middleware.RandomID()
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, familyID)
+}
+
+// Refresh 用刷新令牌换取新的令牌对，旧刷新令牌立即失效
+// 已使用过的刷新令牌再次出现说明可能被盗用，此时吊销该用户的全部会话
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	stored, err := s.tokens.FindRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if stored.UsedAt != nil {
+		return nil, s.handleReuse(ctx, stored.UserID)
+	}
+	if stored.RevokedAt != nil || now.After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	ok, err := s.tokens.MarkRefreshTokenUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 并发请求抢先轮换了同一个令牌
+		return nil, s.handleReuse(ctx, stored.UserID)
+	}
+
+	user, err := s.users.FindByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return s.issue(ctx, user, stored.FamilyID)
+}
+
+// Logout 注销当前会话：吊销会话下的刷新令牌以及当前访问令牌
+func (s *Service) Logout(ctx context.Context, claims *middleware.Claims) error {
+	if err := s.tokens.RevokeFamily(ctx, claims.SessionID, time.Now()); err != nil {
+		return err
+	}
+	return s.tokens.AddRevoked(ctx, []string{claims.ID, claims.SessionID}, s.accessExpiry())
+}
+
+// RevokeUser 强制用户下线，吊销其全部会话
+func (s *Service) RevokeUser(ctx context.Context, userID uint) error {
+	families, err := s.tokens.RevokeUserFamilies(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.tokens.AddRevoked(ctx, families, s.accessExpiry())
+}
+
+// IsRevoked 实现 middleware.RevocationChecker
+func (s *Service) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	return s.tokens.IsRevoked(ctx, ids...)
+}
+
+func (s *Service) handleReuse(ctx context.Context, userID uint) error {
+	utils.Logger(ctx).Warn("Refresh token reuse detected, revoking all sessions", "user_id", userID)
+	if err := s.RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *Service) issue(ctx context.Context, user *model.User, familyID string) (*TokenPair, error) {
+	accessToken, err := middleware.GenerateToken(user.ID, user.Username, user.Role, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.tokens.CreateRefreshToken(ctx, &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    middleware.TokenTTL(),
+	}, nil
+}
+
+// accessExpiry 吊销记录的保留期限，超过后被吊销的访问令牌已自然过期
+func (s *Service) accessExpiry() time.Time {
+	return time.Now().Add(middleware.TokenTTL())
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
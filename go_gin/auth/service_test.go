@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/migrations"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+func init() {
+	key, err := middleware.NewHMACKey("test", []byte("test-secret"))
+	if err != nil {
+		panic(err)
+	}
+	keys, err := middleware.NewKeySet("test", key)
+	if err != nil {
+		panic(err)
+	}
+	middleware.InitJWT(keys, 15*time.Minute)
+}
+
+// newTestStore 基于内存 SQLite 创建仓储并执行全部迁移
+func newTestStore(t *testing.T) *repository.Store {
+	t.Helper()
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { model.Close(db) })
+
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	return repository.NewGormStore(db)
+}
+
+func createTestUser(t *testing.T, store *repository.Store, username string) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Email: username + "@example.com", Password: "x", Role: "author"}
+	if err := store.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// sessionRevoked 访问令牌的 jti 或 sid 是否已被吊销
+func sessionRevoked(t *testing.T, s *Service, accessToken string) bool {
+	t.Helper()
+	claims, err := middleware.ParseToken(accessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	revoked, err := s.IsRevoked(context.Background(), claims.ID, claims.SessionID)
+	if err != nil {
+		t.Fatalf("IsRevoked: %v", err)
+	}
+	return revoked
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	store := newTestStore(t)
+	s := NewService(store.Users, store.Tokens, time.Hour)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice")
+
+	first, err := s.IssueTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Error("Refresh returned the same tokens")
+	}
+
+	// 轮换后仍属于同一个会话
+	c1, _ := middleware.ParseToken(first.AccessToken)
+	c2, _ := middleware.ParseToken(second.AccessToken)
+	if c1.SessionID != c2.SessionID {
+		t.Errorf("session changed on refresh: %s -> %s", c1.SessionID, c2.SessionID)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Errorf("Refresh with rotated token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesAllSessions(t *testing.T) {
+	store := newTestStore(t)
+	s := NewService(store.Users, store.Tokens, time.Hour)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice")
+	other := createTestUser(t, store, "bob")
+
+	phone, err := s.IssueTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	laptop, err := s.IssueTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	bob, err := s.IssueTokens(ctx, other)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	rotated, err := s.Refresh(ctx, phone.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// 旧令牌被再次使用，视为泄露
+	if _, err := s.Refresh(ctx, phone.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reusing refresh token: error = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// 该用户的全部会话下线，包括轮换出的新令牌和其他设备
+	for name, token := range map[string]string{"rotated": rotated.RefreshToken, "laptop": laptop.RefreshToken} {
+		if _, err := s.Refresh(ctx, token); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("%s refresh token after reuse: error = %v, want ErrInvalidRefreshToken", name, err)
+		}
+	}
+	for name, token := range map[string]string{"rotated": rotated.AccessToken, "laptop": laptop.AccessToken} {
+		if !sessionRevoked(t, s, token) {
+			t.Errorf("%s access token not revoked after reuse", name)
+		}
+	}
+
+	// 其他用户不受影响
+	if sessionRevoked(t, s, bob.AccessToken) {
+		t.Error("other user's session was revoked")
+	}
+	if _, err := s.Refresh(ctx, bob.RefreshToken); err != nil {
+		t.Errorf("other user's refresh: %v", err)
+	}
+}
+
+func TestRefreshInvalidTokens(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice")
+
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		token func(s *Service) string
+	}{
+		{"unknown token", time.Hour, func(*Service) string { return "not-a-token" }},
+		{"expired token", -time.Minute, func(s *Service) string {
+			pair, err := s.IssueTokens(ctx, user)
+			if err != nil {
+				t.Fatalf("IssueTokens: %v", err)
+			}
+			return pair.RefreshToken
+		}},
+		{"logged out session", time.Hour, func(s *Service) string {
+			pair, err := s.IssueTokens(ctx, user)
+			if err != nil {
+				t.Fatalf("IssueTokens: %v", err)
+			}
+			claims, _ := middleware.ParseToken(pair.AccessToken)
+			if err := s.Logout(ctx, claims); err != nil {
+				t.Fatalf("Logout: %v", err)
+			}
+			if !sessionRevoked(t, s, pair.AccessToken) {
+				t.Error("access token not revoked after logout")
+			}
+			return pair.RefreshToken
+		}},
+		{"revoked user", time.Hour, func(s *Service) string {
+			pair, err := s.IssueTokens(ctx, user)
+			if err != nil {
+				t.Fatalf("IssueTokens: %v", err)
+			}
+			if err := s.RevokeUser(ctx, user.ID); err != nil {
+				t.Fatalf("RevokeUser: %v", err)
+			}
+			return pair.RefreshToken
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(store.Users, store.Tokens, tt.ttl)
+			if _, err := s.Refresh(ctx, tt.token(s)); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("Refresh error = %v, want ErrInvalidRefreshToken", err)
+			}
+		})
+	}
+}
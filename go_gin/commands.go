@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+const commandUsage = `用法: blog [参数] <命令>
+
+命令:
+  migrate up          执行全部未执行的迁移
+  migrate down [n]    回滚最近执行的 n 个迁移，默认 1
+  migrate to <版本>   迁移到指定版本，0 表示回滚全部
+  migrate status      查看迁移状态
+  counters check      检查评论数、文章数、点赞数和收藏数是否与明细一致
+  counters reconcile  按明细重新统计不一致的计数`
+
+// runCommand 执行子命令并返回进程退出码
+func runCommand(ctx context.Context, migrator *migrate.Migrator, store *repository.Store, args []string) int {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+	switch args[0] {
+	case "migrate":
+		return runMigrate(ctx, migrator, args)
+	case "counters":
+		return runCounters(ctx, store.Counters, args)
+	}
+	fmt.Fprintln(os.Stderr, commandUsage)
+	return 2
+}
+
+// runMigrate 执行 migrate 子命令
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string) int {
+
+	var (
+		done []migrate.Migration
+		err  error
+	)
+	switch args[1] {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 2 {
+			if steps, err = strconv.Atoi(args[2]); err != nil || steps < 1 {
+				fmt.Fprintf(os.Stderr, "invalid step count: %s\n", args[2])
+				return 2
+			}
+		}
+		done, err = migrator.Down(ctx, steps)
+	case "to":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, commandUsage)
+			return 2
+		}
+		version, perr := strconv.ParseInt(args[2], 10, 64)
+		if perr != nil {
+			fmt.Fprintf(os.Stderr, "invalid version: %s\n", args[2])
+			return 2
+		}
+		done, err = migrator.To(ctx, version)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	for _, m := range done {
+		fmt.Printf("%d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate %s: %v\n", args[1], err)
+		return 1
+	}
+	if len(done) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+	return 0
+}
+
+// runCounters 执行 counters 子命令，check 发现不一致时退出码为 1，便于在定时任务中告警
+func runCounters(ctx context.Context, counters repository.CounterRepository, args []string) int {
+	var dryRun bool
+	switch args[1] {
+	case "check":
+		dryRun = true
+	case "reconcile":
+	default:
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	drifts, err := counters.Reconcile(ctx, dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "counters %s: %v\n", args[1], err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "FIXED"
+	if dryRun {
+		header = "DRIFTED"
+	}
+	fmt.Fprintf(w, "COUNTER\t%s\n", header)
+	var total int64
+	for _, d := range drifts {
+		fmt.Fprintf(w, "%s\t%d\n", d.Counter, d.Rows)
+		total += d.Rows
+	}
+	w.Flush()
+	if dryRun && total > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printMigrationStatus 以表格输出每个迁移的版本、名称和执行时间
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) int {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate status: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	w.Flush()
+	return 0
+}
@@ -0,0 +1,670 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultJWTSecret 历史遗留的默认密钥，配置中出现该值时拒绝启动
+const DefaultJWTSecret = "your_secret_key"
+
+// accountTokenKeyLabel 由 jwt.secret 派生一次性令牌密钥时使用的标签
+const accountTokenKeyLabel = "blog/account-token/v1"
+
+// envPrefix 环境变量前缀
+const envPrefix = "BLOG_"
+
+// Duration 支持 "24h"、"30m" 这类字符串写法的时长
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText 解析TOML和环境变量中的时长
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// MarshalText 输出时长字符串
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalYAML 解析YAML中的时长
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// Config 博客服务的全部配置
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt"`
+	Account   AccountConfig   `yaml:"account" toml:"account"`
+	Mail      MailConfig      `yaml:"mail" toml:"mail"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Search    SearchConfig    `yaml:"search" toml:"search"`
+	Comments  CommentsConfig  `yaml:"comments" toml:"comments"`
+	Posts     PostsConfig     `yaml:"posts" toml:"posts"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Metrics   MetricsConfig   `yaml:"metrics" toml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Addr string `yaml:"addr" toml:"addr"` // 监听地址，如 ":8080"
+	// TrustedProxies 可信的反向代理地址或网段，只有来自这些地址的 X-Forwarded-For 才会被采用，
+	// 为空时直接使用连接的对端IP，避免客户端伪造IP绕过按IP限流
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"` // 读取请求头的超时
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout"`               // 读取整个请求的超时
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout"`             // 写响应的超时
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout"`               // keep-alive 连接的空闲超时
+	ShutdownTimeout   Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`       // 收到退出信号后等待请求处理完的最长时间
+	DrainDelay        Duration `yaml:"drain_delay" toml:"drain_delay"`                 // 就绪检查失败后等待多久再停止监听，留给负载均衡摘除实例
+}
+
+// DatabaseConfig 数据库连接配置
+type DatabaseConfig struct {
+	Driver          string   `yaml:"driver" toml:"driver"` // mysql 或 sqlite
+	DSN             string   `yaml:"dsn" toml:"dsn"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	// AutoMigrate 启动时执行未执行的迁移，多实例部署时建议关闭并在发布前单独执行 migrate up
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+// JWTConfig JWT签名配置
+// 只配置 Secret 时使用一把HS256密钥；配置 Keys 时可以同时存在多把密钥，
+// 新令牌由 SigningKeyID 指向的密钥签名，其余密钥只用于验证，便于无感知轮换
+type JWTConfig struct {
+	Secret          string         `yaml:"secret" toml:"secret"`
+	SigningKeyID    string         `yaml:"signing_key_id" toml:"signing_key_id"`
+	Keys            []JWTKeyConfig `yaml:"keys" toml:"keys"`
+	TokenTTL        Duration       `yaml:"token_ttl" toml:"token_ttl"`                 // 访问令牌有效期
+	RefreshTokenTTL Duration       `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"` // 刷新令牌有效期
+}
+
+// JWTKeyConfig 一把JWT密钥，ID 写入令牌头的 kid
+type JWTKeyConfig struct {
+	ID             string `yaml:"id" toml:"id"`
+	Algorithm      string `yaml:"algorithm" toml:"algorithm"`               // HS256、RS256 或 EdDSA
+	Secret         string `yaml:"secret" toml:"secret"`                     // HS256 使用
+	PrivateKeyFile string `yaml:"private_key_file" toml:"private_key_file"` // RS256/EdDSA 的PEM私钥，签名密钥必填
+	PublicKeyFile  string `yaml:"public_key_file" toml:"public_key_file"`   // 只有公钥的密钥仅用于验证
+}
+
+// AccountConfig 邮箱验证和找回密码配置
+type AccountConfig struct {
+	TokenSecret   string   `yaml:"token_secret" toml:"token_secret"`     // 一次性令牌的HMAC签名密钥，为空时由 jwt.secret 派生
+	VerifyTTL     Duration `yaml:"verify_ttl" toml:"verify_ttl"`         // 邮箱验证链接有效期
+	ResetTTL      Duration `yaml:"reset_ttl" toml:"reset_ttl"`           // 重置密码链接有效期
+	LinkBaseURL   string   `yaml:"link_base_url" toml:"link_base_url"`   // 邮件中链接的前缀，指向前端页面
+	RequireVerify bool     `yaml:"require_verify" toml:"require_verify"` // 邮箱未验证的用户不能登录
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Driver    string     `yaml:"driver" toml:"driver"`         // smtp 或 file
+	From      string     `yaml:"from" toml:"from"`             // 发件人地址
+	OutboxDir string     `yaml:"outbox_dir" toml:"outbox_dir"` // file 方式保存邮件的目录
+	SMTP      SMTPConfig `yaml:"smtp" toml:"smtp"`
+}
+
+// SMTPConfig SMTP服务器配置
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"` // 465 使用隐式TLS，其他端口在服务器支持时使用 STARTTLS
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Dir       string   `yaml:"dir" toml:"dir"`
+	Level     string   `yaml:"level" toml:"level"`             // debug、info、warn、error
+	Format    string   `yaml:"format" toml:"format"`           // json 或 logfmt
+	Console   bool     `yaml:"console" toml:"console"`         // 是否同时输出到标准输出
+	MaxSizeMB int      `yaml:"max_size_mb" toml:"max_size_mb"` // 单个文件大小上限，0表示只按天切分
+	MaxAge    Duration `yaml:"max_age" toml:"max_age"`         // 旧日志保留时长，0表示不清理
+	Compress  bool     `yaml:"compress" toml:"compress"`       // 是否gzip压缩切分后的旧日志
+}
+
+// SearchConfig 全文搜索配置
+type SearchConfig struct {
+	Engine string `yaml:"engine" toml:"engine"` // auto、mysql 或 memory
+}
+
+// CommentsConfig 评论配置
+type CommentsConfig struct {
+	MaxDepth int `yaml:"max_depth" toml:"max_depth"` // 树形展示的默认最大层级
+}
+
+// PostsConfig 文章配置
+type PostsConfig struct {
+	SchedulerInterval Duration `yaml:"scheduler_interval" toml:"scheduler_interval"`   // 检查到期定时文章的间隔
+	ViewFlushInterval Duration `yaml:"view_flush_interval" toml:"view_flush_interval"` // 浏览数写入数据库的间隔
+}
+
+// RateLimitConfig 限流配置，按路由分组分别设置，requests 为0的分组不限流
+type RateLimitConfig struct {
+	Enabled bool          `yaml:"enabled" toml:"enabled"`
+	Auth    RateLimitRule `yaml:"auth" toml:"auth"`   // 注册、登录、刷新令牌，按IP
+	Read    RateLimitRule `yaml:"read" toml:"read"`   // 查询接口，公开接口按IP，需要认证的接口按用户
+	Write   RateLimitRule `yaml:"write" toml:"write"` // 需要认证的写操作（POST、PUT、PATCH、DELETE），按用户
+	Lockout LockoutConfig `yaml:"lockout" toml:"lockout"`
+}
+
+// RateLimitRule 每 per 时间允许 requests 次请求，burst 为允许的突发请求数，0表示等于 requests
+type RateLimitRule struct {
+	Requests int      `yaml:"requests" toml:"requests"`
+	Per      Duration `yaml:"per" toml:"per"`
+	Burst    int      `yaml:"burst" toml:"burst"`
+}
+
+// LockoutConfig 登录失败锁定配置，max_failures 为0时不锁定
+type LockoutConfig struct {
+	MaxFailures int      `yaml:"max_failures" toml:"max_failures"` // 连续失败多少次后锁定
+	Window      Duration `yaml:"window" toml:"window"`             // 失败计数在最后一次失败多久后清零
+	BaseDelay   Duration `yaml:"base_delay" toml:"base_delay"`     // 首次锁定时长，之后每次失败翻倍
+	MaxDelay    Duration `yaml:"max_delay" toml:"max_delay"`       // 最长锁定时长
+}
+
+// MetricsConfig Prometheus 指标配置
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"`
+	Path    string `yaml:"path" toml:"path"` // 指标接口路径，不限流也不需要认证，应在反向代理处限制访问
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter"`         // none、stdout 或 otlp
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`         // OTLP 接收地址，如 localhost:4317，为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT
+	Protocol    string  `yaml:"protocol" toml:"protocol"`         // OTLP 协议：grpc 或 http
+	Insecure    bool    `yaml:"insecure" toml:"insecure"`         // OTLP 不使用TLS
+	ServiceName string  `yaml:"service_name" toml:"service_name"` // 上报的 service.name
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"` // 根span的采样比例，0到1，上游已采样的请求始终采样
+}
+
+// Default 返回默认配置，JWT密钥必须由使用者提供
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: Duration{5 * time.Second},
+			ReadTimeout:       Duration{15 * time.Second},
+			WriteTimeout:      Duration{30 * time.Second},
+			IdleTimeout:       Duration{60 * time.Second},
+			ShutdownTimeout:   Duration{20 * time.Second},
+		},
+		Database: DatabaseConfig{
+			Driver:          "mysql",
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: Duration{time.Hour},
+			AutoMigrate:     true,
+		},
+		JWT: JWTConfig{
+			TokenTTL:        Duration{15 * time.Minute},
+			RefreshTokenTTL: Duration{7 * 24 * time.Hour},
+		},
+		Account: AccountConfig{
+			VerifyTTL:   Duration{48 * time.Hour},
+			ResetTTL:    Duration{time.Hour},
+			LinkBaseURL: "http://localhost:8080",
+		},
+		Mail: MailConfig{
+			Driver:    "file",
+			From:      "noreply@localhost",
+			OutboxDir: "outbox",
+			SMTP:      SMTPConfig{Port: 587},
+		},
+		Log: LogConfig{
+			Dir:       "logs",
+			Level:     "info",
+			Format:    "json",
+			Console:   true,
+			MaxSizeMB: 100,
+			MaxAge:    Duration{30 * 24 * time.Hour},
+			Compress:  true,
+		},
+		Search:   SearchConfig{Engine: "auto"},
+		Comments: CommentsConfig{MaxDepth: 5},
+		Posts:    PostsConfig{SchedulerInterval: Duration{30 * time.Second}, ViewFlushInterval: Duration{10 * time.Second}},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Auth:    RateLimitRule{Requests: 10, Per: Duration{time.Minute}, Burst: 5},
+			Read:    RateLimitRule{Requests: 300, Per: Duration{time.Minute}, Burst: 60},
+			Write:   RateLimitRule{Requests: 30, Per: Duration{time.Minute}, Burst: 10},
+			Lockout: LockoutConfig{
+				MaxFailures: 5,
+				Window:      Duration{time.Hour},
+				BaseDelay:   Duration{time.Minute},
+				MaxDelay:    Duration{30 * time.Minute},
+			},
+		},
+		Metrics: MetricsConfig{Enabled: true, Path: "/metrics"},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Protocol:    "grpc",
+			ServiceName: "blog",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load 按 默认值 < 配置文件 < 环境变量 < 命令行参数 的优先级加载配置
+// 配置文件通过 -config 参数或 BLOG_CONFIG 环境变量指定，支持 .yaml/.yml/.toml
+// 返回参数之后的子命令，如 "migrate up"
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("blog", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv(envPrefix+"CONFIG"), "配置文件路径 (.yaml/.yml/.toml)")
+	addr := fs.String("addr", "", "监听地址，如 :8080")
+	dbDriver := fs.String("db-driver", "", "数据库驱动: mysql 或 sqlite")
+	dbDSN := fs.String("db-dsn", "", "数据库连接串")
+	jwtSecret := fs.String("jwt-secret", "", "JWT签名密钥")
+	tokenTTL := fs.Duration("token-ttl", 0, "访问令牌有效期，如 15m")
+	logDir := fs.String("log-dir", "", "日志目录")
+	logLevel := fs.String("log-level", "", "日志级别: debug、info、warn、error")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(cfg, *configPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	// 命令行参数只覆盖显式传入的值
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "db-driver":
+			cfg.Database.Driver = *dbDriver
+		case "db-dsn":
+			cfg.Database.DSN = *dbDSN
+		case "jwt-secret":
+			cfg.JWT.Secret = *jwtSecret
+		case "token-ttl":
+			cfg.JWT.TokenTTL = Duration{*tokenTTL}
+		case "log-dir":
+			cfg.Log.Dir = *logDir
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		}
+	})
+
+	// 未单独配置时由 jwt.secret 派生一次性令牌专用的密钥，访问令牌和重置密码令牌不共用同一把密钥
+	if cfg.Account.TokenSecret == "" && cfg.JWT.Secret != "" && cfg.JWT.Secret != DefaultJWTSecret {
+		cfg.Account.TokenSecret = deriveKey(cfg.JWT.Secret, accountTokenKeyLabel)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// loadFile 根据扩展名解析配置文件
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv 使用 BLOG_ 前缀的环境变量覆盖配置
+func applyEnv(cfg *Config) error {
+	strVars := map[string]*string{
+		"SERVER_ADDR":      &cfg.Server.Addr,
+		"DB_DRIVER":        &cfg.Database.Driver,
+		"DB_DSN":           &cfg.Database.DSN,
+		"JWT_SECRET":       &cfg.JWT.Secret,
+		"LOG_DIR":          &cfg.Log.Dir,
+		"LOG_LEVEL":        &cfg.Log.Level,
+		"LOG_FORMAT":       &cfg.Log.Format,
+		"SEARCH_ENGINE":    &cfg.Search.Engine,
+		"TRACING_EXPORTER": &cfg.Tracing.Exporter,
+		"TRACING_ENDPOINT": &cfg.Tracing.Endpoint,
+		"TRACING_PROTOCOL": &cfg.Tracing.Protocol,
+
+		"ACCOUNT_TOKEN_SECRET":  &cfg.Account.TokenSecret,
+		"ACCOUNT_LINK_BASE_URL": &cfg.Account.LinkBaseURL,
+		"MAIL_DRIVER":           &cfg.Mail.Driver,
+		"MAIL_FROM":             &cfg.Mail.From,
+		"MAIL_OUTBOX_DIR":       &cfg.Mail.OutboxDir,
+		"MAIL_SMTP_HOST":        &cfg.Mail.SMTP.Host,
+		"MAIL_SMTP_USERNAME":    &cfg.Mail.SMTP.Username,
+		"MAIL_SMTP_PASSWORD":    &cfg.Mail.SMTP.Password,
+	}
+	for name, dst := range strVars {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			*dst = v
+		}
+	}
+
+	intVars := map[string]*int{
+		"DB_MAX_OPEN_CONNS":  &cfg.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS":  &cfg.Database.MaxIdleConns,
+		"COMMENTS_MAX_DEPTH": &cfg.Comments.MaxDepth,
+		"LOG_MAX_SIZE_MB":    &cfg.Log.MaxSizeMB,
+		"MAIL_SMTP_PORT":     &cfg.Mail.SMTP.Port,
+	}
+	for name, dst := range intVars {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s: %w", envPrefix, name, err)
+			}
+			*dst = n
+		}
+	}
+
+	boolVars := map[string]*bool{
+		"DB_AUTO_MIGRATE":        &cfg.Database.AutoMigrate,
+		"LOG_CONSOLE":            &cfg.Log.Console,
+		"LOG_COMPRESS":           &cfg.Log.Compress,
+		"RATE_LIMIT_ENABLED":     &cfg.RateLimit.Enabled,
+		"METRICS_ENABLED":        &cfg.Metrics.Enabled,
+		"TRACING_INSECURE":       &cfg.Tracing.Insecure,
+		"ACCOUNT_REQUIRE_VERIFY": &cfg.Account.RequireVerify,
+	}
+	for name, dst := range boolVars {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s: %w", envPrefix, name, err)
+			}
+			*dst = b
+		}
+	}
+
+	if v, ok := os.LookupEnv(envPrefix + "TRACING_SAMPLE_RATIO"); ok {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %sTRACING_SAMPLE_RATIO: %w", envPrefix, err)
+		}
+		cfg.Tracing.SampleRatio = ratio
+	}
+
+	durationVars := map[string]*Duration{
+		"DB_CONN_MAX_LIFETIME":      &cfg.Database.ConnMaxLifetime,
+		"SERVER_SHUTDOWN_TIMEOUT":   &cfg.Server.ShutdownTimeout,
+		"JWT_TOKEN_TTL":             &cfg.JWT.TokenTTL,
+		"JWT_REFRESH_TOKEN_TTL":     &cfg.JWT.RefreshTokenTTL,
+		"LOG_MAX_AGE":               &cfg.Log.MaxAge,
+		"POSTS_SCHEDULER_INTERVAL":  &cfg.Posts.SchedulerInterval,
+		"POSTS_VIEW_FLUSH_INTERVAL": &cfg.Posts.ViewFlushInterval,
+		"ACCOUNT_VERIFY_TTL":        &cfg.Account.VerifyTTL,
+		"ACCOUNT_RESET_TTL":         &cfg.Account.ResetTTL,
+	}
+	for name, dst := range durationVars {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			if err := dst.UnmarshalText([]byte(v)); err != nil {
+				return fmt.Errorf("invalid %s%s: %w", envPrefix, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate 校验必填项，任何一项不合法都拒绝启动
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if c.Server.ReadHeaderTimeout.Duration < 0 || c.Server.ReadTimeout.Duration < 0 ||
+		c.Server.WriteTimeout.Duration < 0 || c.Server.IdleTimeout.Duration < 0 {
+		errs = append(errs, errors.New("server timeouts must not be negative"))
+	}
+	if c.Server.DrainDelay.Duration < 0 {
+		errs = append(errs, errors.New("server.drain_delay must not be negative"))
+	}
+	if c.Server.ShutdownTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	switch c.Database.Driver {
+	case "mysql", "sqlite":
+	default:
+		errs = append(errs, fmt.Errorf("database.driver must be mysql or sqlite, got %q", c.Database.Driver))
+	}
+	// 连接串包含数据库密码，不提供默认值；sqlite 留空时使用内存数据库
+	if c.Database.Driver == "mysql" && c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required for mysql"))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database pool sizes must not be negative"))
+	}
+	errs = append(errs, c.JWT.validateKeys()...)
+	if c.JWT.TokenTTL.Duration <= 0 {
+		errs = append(errs, errors.New("jwt.token_ttl must be positive"))
+	}
+	if c.JWT.RefreshTokenTTL.Duration <= c.JWT.TokenTTL.Duration {
+		errs = append(errs, errors.New("jwt.refresh_token_ttl must be longer than jwt.token_ttl"))
+	}
+	errs = append(errs, c.Account.validate()...)
+	if c.Account.TokenSecret != "" && c.JWT.usesSecret(c.Account.TokenSecret) {
+		errs = append(errs, errors.New("account.token_secret must differ from the jwt secrets"))
+	}
+	errs = append(errs, c.Mail.validate()...)
+	if c.Log.Dir == "" {
+		errs = append(errs, errors.New("log.dir is required"))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "logfmt" {
+		errs = append(errs, fmt.Errorf("log.format must be json or logfmt, got %q", c.Log.Format))
+	}
+	if c.Log.MaxSizeMB < 0 || c.Log.MaxAge.Duration < 0 {
+		errs = append(errs, errors.New("log.max_size_mb and log.max_age must not be negative"))
+	}
+	errs = append(errs, c.RateLimit.validate()...)
+	if c.Comments.MaxDepth < 1 || c.Comments.MaxDepth > 20 {
+		errs = append(errs, errors.New("comments.max_depth must be between 1 and 20"))
+	}
+	if c.Posts.SchedulerInterval.Duration <= 0 || c.Posts.ViewFlushInterval.Duration <= 0 {
+		errs = append(errs, errors.New("posts.scheduler_interval and posts.view_flush_interval must be positive"))
+	}
+	switch c.Search.Engine {
+	case "auto", "mysql", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("search.engine must be auto, mysql or memory, got %q", c.Search.Engine))
+	}
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		errs = append(errs, errors.New("metrics.path must start with /"))
+	}
+	errs = append(errs, c.Tracing.validate()...)
+	return errors.Join(errs...)
+}
+
+// validateKeys 校验密钥配置，具体的密钥文件在加载时再检查
+func (j *JWTConfig) validateKeys() []error {
+	if len(j.Keys) == 0 {
+		switch j.Secret {
+		case "":
+			return []error{errors.New("jwt.secret or jwt.keys is required")}
+		case DefaultJWTSecret:
+			return []error{errors.New("jwt.secret must not be the default value")}
+		}
+		return nil
+	}
+
+	var errs []error
+	ids := make(map[string]bool)
+	for i, k := range j.Keys {
+		if k.ID == "" {
+			errs = append(errs, fmt.Errorf("jwt.keys[%d].id is required", i))
+		} else if ids[k.ID] {
+			errs = append(errs, fmt.Errorf("jwt.keys[%d].id %q is duplicated", i, k.ID))
+		}
+		ids[k.ID] = true
+
+		switch k.Algorithm {
+		case "HS256":
+			if k.Secret == "" || k.Secret == DefaultJWTSecret {
+				errs = append(errs, fmt.Errorf("jwt.keys[%d].secret must be set and not the default value", i))
+			}
+		case "RS256", "EdDSA":
+			if k.PrivateKeyFile == "" && k.PublicKeyFile == "" {
+				errs = append(errs, fmt.Errorf("jwt.keys[%d] requires private_key_file or public_key_file", i))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("jwt.keys[%d].algorithm must be HS256, RS256 or EdDSA", i))
+		}
+	}
+	if !ids[j.SigningKeyID] {
+		errs = append(errs, errors.New("jwt.signing_key_id must refer to one of jwt.keys"))
+	}
+	return errs
+}
+
+// usesSecret secret 是否被用作 JWT 的 HS256 密钥
+func (j *JWTConfig) usesSecret(secret string) bool {
+	if j.Secret == secret {
+		return true
+	}
+	for _, k := range j.Keys {
+		if k.Algorithm == "HS256" && k.Secret == secret {
+			return true
+		}
+	}
+	return false
+}
+
+// deriveKey 用 HMAC-SHA256 从主密钥派生指定用途的子密钥，不同用途的子密钥互相独立
+func deriveKey(secret, label string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(label))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (a *AccountConfig) validate() []error {
+	var errs []error
+	switch a.TokenSecret {
+	case "":
+		errs = append(errs, errors.New("account.token_secret is required when jwt.secret is not set"))
+	case DefaultJWTSecret:
+		errs = append(errs, errors.New("account.token_secret must not be the default value"))
+	}
+	if a.VerifyTTL.Duration <= 0 || a.ResetTTL.Duration <= 0 {
+		errs = append(errs, errors.New("account.verify_ttl and account.reset_ttl must be positive"))
+	}
+	if !strings.HasPrefix(a.LinkBaseURL, "http://") && !strings.HasPrefix(a.LinkBaseURL, "https://") {
+		errs = append(errs, errors.New("account.link_base_url must be an http or https URL"))
+	}
+	return errs
+}
+
+func (m *MailConfig) validate() []error {
+	var errs []error
+	if m.From == "" {
+		errs = append(errs, errors.New("mail.from is required"))
+	}
+	switch m.Driver {
+	case "smtp":
+		if m.SMTP.Host == "" || m.SMTP.Port <= 0 {
+			errs = append(errs, errors.New("mail.smtp.host and mail.smtp.port are required for smtp"))
+		}
+	case "file":
+		if m.OutboxDir == "" {
+			errs = append(errs, errors.New("mail.outbox_dir is required for file"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver must be smtp or file, got %q", m.Driver))
+	}
+	return errs
+}
+
+func (c *RateLimitConfig) validate() []error {
+	var errs []error
+	rules := map[string]RateLimitRule{"auth": c.Auth, "read": c.Read, "write": c.Write}
+	for name, r := range rules {
+		if r.Requests < 0 || r.Burst < 0 {
+			errs = append(errs, fmt.Errorf("rate_limit.%s.requests and burst must not be negative", name))
+		}
+		if r.Requests > 0 && r.Per.Duration <= 0 {
+			errs = append(errs, fmt.Errorf("rate_limit.%s.per must be positive", name))
+		}
+	}
+	l := c.Lockout
+	if l.MaxFailures < 0 {
+		errs = append(errs, errors.New("rate_limit.lockout.max_failures must not be negative"))
+	}
+	if l.MaxFailures > 0 {
+		if l.BaseDelay.Duration <= 0 || l.MaxDelay.Duration < l.BaseDelay.Duration {
+			errs = append(errs, errors.New("rate_limit.lockout requires 0 < base_delay <= max_delay"))
+		}
+		// 计数窗口短于锁定时长时，锁定结束后计数已清零，退避不会继续翻倍
+		if l.Window.Duration < l.MaxDelay.Duration {
+			errs = append(errs, errors.New("rate_limit.lockout.window must not be shorter than max_delay"))
+		}
+	}
+	return errs
+}
+
+func (t *TracingConfig) validate() []error {
+	var errs []error
+	switch t.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be none, stdout or otlp, got %q", t.Exporter))
+	}
+	if t.Exporter == "otlp" && t.Protocol != "grpc" && t.Protocol != "http" {
+		errs = append(errs, fmt.Errorf("tracing.protocol must be grpc or http, got %q", t.Protocol))
+	}
+	if t.ServiceName == "" {
+		errs = append(errs, errors.New("tracing.service_name is required"))
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+	return errs
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile 在临时目录中写入配置文件，返回文件路径
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("BLOG_JWT_SECRET", "test-secret")
+	cfg, rest, err := Load([]string{"-db-driver", "sqlite"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("rest = %v, want none", rest)
+	}
+
+	want := Default()
+	if cfg.Server.Addr != want.Server.Addr || cfg.JWT.TokenTTL != want.JWT.TokenTTL ||
+		cfg.Log.Level != want.Log.Level || cfg.RateLimit.Write != want.RateLimit.Write {
+		t.Errorf("Load did not keep defaults: %+v", cfg)
+	}
+	if cfg.Database.DSN != "" {
+		t.Errorf("sqlite DSN = %q, want empty for in-memory database", cfg.Database.DSN)
+	}
+	if cfg.Account.TokenSecret == "" || cfg.Account.TokenSecret == cfg.JWT.Secret {
+		t.Errorf("account token secret = %q, want a key derived from jwt.secret", cfg.Account.TokenSecret)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  addr: ":7000"
+database:
+  driver: sqlite
+  dsn: file.db
+jwt:
+  secret: file-secret
+  token_ttl: 5m
+log:
+  level: warn
+  dir: file-logs
+`)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		addr    string
+		dsn     string
+		ttl     time.Duration
+		level   string
+		logDir  string
+		command []string
+	}{
+		{
+			name: "file overrides defaults",
+			args: []string{"-config", path},
+			addr: ":7000", dsn: "file.db", ttl: 5 * time.Minute, level: "warn", logDir: "file-logs",
+		},
+		{
+			name: "config path from env",
+			env:  map[string]string{"BLOG_CONFIG": path},
+			addr: ":7000", dsn: "file.db", ttl: 5 * time.Minute, level: "warn", logDir: "file-logs",
+		},
+		{
+			name: "env overrides file",
+			env:  map[string]string{"BLOG_SERVER_ADDR": ":7100", "BLOG_DB_DSN": "env.db", "BLOG_JWT_TOKEN_TTL": "10m"},
+			args: []string{"-config", path},
+			addr: ":7100", dsn: "env.db", ttl: 10 * time.Minute, level: "warn", logDir: "file-logs",
+		},
+		{
+			name: "flags override env",
+			env:  map[string]string{"BLOG_SERVER_ADDR": ":7100", "BLOG_DB_DSN": "env.db", "BLOG_LOG_LEVEL": "error"},
+			args: []string{"-config", path, "-addr", ":7200", "-db-dsn", "flag.db", "-token-ttl", "20m", "-log-level", "debug"},
+			addr: ":7200", dsn: "flag.db", ttl: 20 * time.Minute, level: "debug", logDir: "file-logs",
+		},
+		{
+			name: "unset flags keep env",
+			env:  map[string]string{"BLOG_LOG_DIR": "env-logs"},
+			args: []string{"-config", path, "-addr", ":7200"},
+			addr: ":7200", dsn: "file.db", ttl: 5 * time.Minute, level: "warn", logDir: "env-logs",
+		},
+		{
+			name: "subcommand after flags",
+			args: []string{"-config", path, "migrate", "down", "1"},
+			addr: ":7000", dsn: "file.db", ttl: 5 * time.Minute, level: "warn", logDir: "file-logs",
+			command: []string{"migrate", "down", "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, rest, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Addr != tt.addr {
+				t.Errorf("addr = %q, want %q", cfg.Server.Addr, tt.addr)
+			}
+			if cfg.Database.DSN != tt.dsn {
+				t.Errorf("dsn = %q, want %q", cfg.Database.DSN, tt.dsn)
+			}
+			if cfg.JWT.TokenTTL.Duration != tt.ttl {
+				t.Errorf("token ttl = %v, want %v", cfg.JWT.TokenTTL, tt.ttl)
+			}
+			if cfg.Log.Level != tt.level {
+				t.Errorf("log level = %q, want %q", cfg.Log.Level, tt.level)
+			}
+			if cfg.Log.Dir != tt.logDir {
+				t.Errorf("log dir = %q, want %q", cfg.Log.Dir, tt.logDir)
+			}
+			if strings.Join(rest, " ") != strings.Join(tt.command, " ") {
+				t.Errorf("rest = %v, want %v", rest, tt.command)
+			}
+		})
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", `
+[database]
+driver = "sqlite"
+
+[jwt]
+secret = "toml-secret"
+refresh_token_ttl = "48h"
+`)
+	cfg, _, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.JWT.Secret != "toml-secret" || cfg.JWT.RefreshTokenTTL.Duration != 48*time.Hour {
+		t.Errorf("jwt = %+v", cfg.JWT)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		file string
+		want string
+	}{
+		{
+			name: "mysql without dsn",
+			env:  map[string]string{"BLOG_JWT_SECRET": "s"},
+			want: "database.dsn is required for mysql",
+		},
+		{
+			name: "missing jwt secret",
+			args: []string{"-db-driver", "sqlite"},
+			want: "jwt.secret or jwt.keys is required",
+		},
+		{
+			name: "default jwt secret",
+			env:  map[string]string{"BLOG_JWT_SECRET": DefaultJWTSecret},
+			args: []string{"-db-driver", "sqlite"},
+			want: "jwt.secret must not be the default value",
+		},
+		{
+			name: "token secret reuses jwt secret",
+			env:  map[string]string{"BLOG_JWT_SECRET": "s", "BLOG_ACCOUNT_TOKEN_SECRET": "s"},
+			args: []string{"-db-driver", "sqlite"},
+			want: "account.token_secret must differ from the jwt secrets",
+		},
+		{
+			name: "unknown driver",
+			env:  map[string]string{"BLOG_JWT_SECRET": "s"},
+			args: []string{"-db-driver", "postgres"},
+			want: `database.driver must be mysql or sqlite, got "postgres"`,
+		},
+		{
+			name: "invalid log level",
+			env:  map[string]string{"BLOG_JWT_SECRET": "s", "BLOG_LOG_LEVEL": "trace"},
+			args: []string{"-db-driver", "sqlite"},
+			want: `log.level must be debug, info, warn or error, got "trace"`,
+		},
+		{
+			name: "refresh ttl not longer than access ttl",
+			env:  map[string]string{"BLOG_JWT_SECRET": "s", "BLOG_JWT_REFRESH_TOKEN_TTL": "10m"},
+			args: []string{"-db-driver", "sqlite", "-token-ttl", "10m"},
+			want: "jwt.refresh_token_ttl must be longer than jwt.token_ttl",
+		},
+		{
+			name: "invalid env duration",
+			env:  map[string]string{"BLOG_JWT_SECRET": "s", "BLOG_JWT_TOKEN_TTL": "soon"},
+			want: "invalid BLOG_JWT_TOKEN_TTL",
+		},
+		{
+			name: "invalid env bool",
+			env:  map[string]string{"BLOG_JWT_SECRET": "s", "BLOG_LOG_CONSOLE": "maybe"},
+			want: "invalid BLOG_LOG_CONSOLE",
+		},
+		{
+			name: "unknown yaml field",
+			file: "config.yaml",
+			want: "field unknown not found",
+		},
+		{
+			name: "unsupported file format",
+			file: "config.ini",
+			want: "unsupported config file format",
+		},
+		{
+			name: "unknown flag",
+			args: []string{"-no-such-flag"},
+			want: "flag provided but not defined",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append(args, "-config", writeConfigFile(t, tt.file, "unknown: 1\n"))
+			}
+			_, _, err := Load(args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *RateLimitConfig)
+		want   string
+	}{
+		{"negative requests", func(c *RateLimitConfig) { c.Write.Requests = -1 }, "rate_limit.write.requests and burst must not be negative"},
+		{"zero period", func(c *RateLimitConfig) { c.Read.Per = Duration{} }, "rate_limit.read.per must be positive"},
+		{"base delay above max delay", func(c *RateLimitConfig) { c.Lockout.BaseDelay = Duration{time.Hour} }, "0 < base_delay <= max_delay"},
+		{"window shorter than max delay", func(c *RateLimitConfig) { c.Lockout.Window = Duration{time.Minute} }, "window must not be shorter than max_delay"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Database.Driver = "sqlite"
+			cfg.JWT.Secret = "s"
+			cfg.Account.TokenSecret = "t"
+			tt.modify(&cfg.RateLimit)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
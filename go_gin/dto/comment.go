@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// CreateCommentRequest 创建评论的请求，长度与 model.Comment 的 size:500 一致
+type CreateCommentRequest struct {
+	Content  string `json:"content" binding:"required,notblank,max=500"`
+	ParentID *uint  `json:"parent_id" binding:"omitempty,gt=0"`
+}
+
+// Model 转换为评论模型，Depth 和 Path 由调用方根据父评论计算
+func (r *CreateCommentRequest) Model(postID, userID uint) *model.Comment {
+	return &model.Comment{
+		Content:     r.Content,
+		ContentHTML: markup.Render(r.Content),
+		PostID:      postID,
+		UserID:      &userID,
+		ParentID:    r.ParentID,
+	}
+}
+
+// UpdateCommentRequest 修改评论的请求
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required,notblank,max=500"`
+}
+
+// Apply 把修改后的内容和渲染出的 HTML 写入评论
+func (r *UpdateCommentRequest) Apply(comment *model.Comment) {
+	comment.Content = r.Content
+	comment.ContentHTML = markup.Render(r.Content)
+}
+
+// CommentResponse 评论信息，Content 为 ContentFormat 指定格式的内容
+// 已删除的评论只在树形展示中作为占位出现，Deleted 为 true，不返回内容和作者
+// 作者已注销的评论 UserID 为空，User 为名称是 DeletedUsername 的占位作者
+type CommentResponse struct {
+	ID            uint          `json:"id"`
+	Content       string        `json:"content"`
+	ContentFormat markup.Format `json:"content_format"`
+	UserID        *uint         `json:"user_id"`
+	PostID        uint          `json:"post_id"`
+	ParentID      *uint         `json:"parent_id"`
+	Depth         int           `json:"depth"`
+	User          *UserSummary  `json:"user,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Deleted       bool          `json:"deleted,omitempty"`
+}
+
+// NewCommentResponse 从评论模型生成响应，内容按 format 输出
+func NewCommentResponse(c *model.Comment, format markup.Format) CommentResponse {
+	if c.DeletedAt.Valid {
+		return CommentResponse{
+			ID:            c.ID,
+			ContentFormat: format,
+			PostID:        c.PostID,
+			ParentID:      c.ParentID,
+			Depth:         c.Depth,
+			CreatedAt:     c.CreatedAt,
+			UpdatedAt:     c.UpdatedAt,
+			Deleted:       true,
+		}
+	}
+	return CommentResponse{
+		ID:            c.ID,
+		Content:       format.Select(c.Content, c.ContentHTML),
+		ContentFormat: format,
+		UserID:        c.UserID,
+		PostID:        c.PostID,
+		ParentID:      c.ParentID,
+		Depth:         c.Depth,
+		User:          newCommentAuthor(c),
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+	}
+}
+
+// newCommentAuthor 作者已注销的评论返回占位作者
+func newCommentAuthor(c *model.Comment) *UserSummary {
+	if c.UserID == nil {
+		return &UserSummary{Username: DeletedUsername}
+	}
+	return newUserSummary(&c.User)
+}
+
+// NewCommentResponses 批量转换评论列表
+func NewCommentResponses(comments []model.Comment, format markup.Format) []CommentResponse {
+	resp := make([]CommentResponse, len(comments))
+	for i := range comments {
+		resp[i] = NewCommentResponse(&comments[i], format)
+	}
+	return resp
+}
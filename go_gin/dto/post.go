@@ -0,0 +1,209 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// MaxPostContentLength 文章内容的字符数上限，MySQL TEXT 最多 65535 字节，按每个字符3字节计算
+const MaxPostContentLength = 20000
+
+// 每篇文章的标签和分类数量上限
+const (
+	MaxPostTags       = 10
+	MaxPostCategories = 5
+)
+
+// PostRequest 创建和更新文章的请求，标题长度与 model.Post 的 size:200 一致
+// Tags 为标签名称，不存在的标签会自动创建；Tags、CategoryIDs 省略时更新文章不修改对应关联，传空数组表示清空
+// Status 省略时新文章直接发布，更新文章保持原状态；scheduled 需要在 PublishedAt 指定将来的发布时间
+// Version 为客户端修改的文章版本，没有 If-Match 头时用于更新文章的冲突检测
+type PostRequest struct {
+	Title       string     `json:"title" binding:"required,notblank,max=200"`
+	Content     string     `json:"content" binding:"required,notblank,max=20000"`
+	Tags        []string   `json:"tags" binding:"omitempty,max=10,dive,required,max=50,slug"`
+	CategoryIDs []uint     `json:"category_ids" binding:"omitempty,max=5,dive,gt=0"`
+	Status      string     `json:"status" binding:"omitempty,oneof=draft scheduled published archived"`
+	PublishedAt *time.Time `json:"published_at"`
+	Version     *int       `json:"version" binding:"omitempty,gt=0"`
+}
+
+// ErrScheduleInPast 定时发布的时间缺失或不在将来
+var ErrScheduleInPast = errors.New("published_at must be a future time for scheduled posts")
+
+// PatchablePostFields PATCH 文章时补丁可以包含的字段
+var PatchablePostFields = []string{"title", "content", "tags", "category_ids", "status", "published_at", "version"}
+
+// NewPostRequest 由文章当前的值生成请求，作为 PATCH 合并补丁的基础
+func NewPostRequest(post *model.Post) PostRequest {
+	req := PostRequest{
+		Title:       post.Title,
+		Content:     post.Content,
+		Tags:        make([]string, len(post.Tags)),
+		CategoryIDs: make([]uint, len(post.Categories)),
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+		Version:     &post.Version,
+	}
+	for i, t := range post.Tags {
+		req.Tags[i] = t.Name
+	}
+	for i, c := range post.Categories {
+		req.CategoryIDs[i] = c.ID
+	}
+	return req
+}
+
+// Model 转换为文章模型
+func (r *PostRequest) Model(userID uint) *model.Post {
+	post := &model.Post{UserID: userID}
+	r.Apply(post)
+	return post
+}
+
+// Apply 把请求内容写入已有的文章，内容按 Markdown 渲染出 HTML 一并保存
+func (r *PostRequest) Apply(post *model.Post) {
+	post.Title = strings.TrimSpace(r.Title)
+	post.Content = r.Content
+	post.ContentHTML = markup.Render(r.Content)
+}
+
+// ApplyStatus 按请求设置文章状态和发布时间，now 为当前时间
+// 转为草稿时清空发布时间，归档保留原发布时间，重新发布时沿用原发布时间
+func (r *PostRequest) ApplyStatus(post *model.Post, now time.Time) error {
+	status := r.Status
+	if status == "" {
+		status = post.Status
+	}
+	if status == "" {
+		status = model.PostStatusPublished
+	}
+
+	switch status {
+	case model.PostStatusDraft:
+		post.PublishedAt = nil
+	case model.PostStatusScheduled:
+		// 已经是定时状态且未指定新时间时保持原计划
+		if r.PublishedAt != nil || post.Status != model.PostStatusScheduled {
+			if r.PublishedAt == nil || !r.PublishedAt.After(now) {
+				return ErrScheduleInPast
+			}
+			at := r.PublishedAt.UTC()
+			post.PublishedAt = &at
+		}
+	case model.PostStatusPublished:
+		if !post.Published() && (post.PublishedAt == nil || post.PublishedAt.After(now)) {
+			post.PublishedAt = &now
+		}
+	}
+	post.Status = status
+	return nil
+}
+
+// TagModels 把标签名称转换为标签模型，Slug 相同的名称只保留第一个
+func (r *PostRequest) TagModels() []model.Tag {
+	if r.Tags == nil {
+		return nil
+	}
+	tags := make([]model.Tag, 0, len(r.Tags))
+	seen := make(map[string]bool, len(r.Tags))
+	for _, name := range r.Tags {
+		tag := NewTag(name)
+		if seen[tag.Slug] {
+			continue
+		}
+		seen[tag.Slug] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// UniqueCategoryIDs 去重后的分类ID
+func (r *PostRequest) UniqueCategoryIDs() []uint {
+	if r.CategoryIDs == nil {
+		return nil
+	}
+	ids := make([]uint, 0, len(r.CategoryIDs))
+	seen := make(map[uint]bool, len(r.CategoryIDs))
+	for _, id := range r.CategoryIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// PostResponse 文章信息，Content 为 ContentFormat 指定格式的内容
+type PostResponse struct {
+	ID            uint               `json:"id"`
+	Title         string             `json:"title"`
+	Content       string             `json:"content"`
+	ContentFormat markup.Format      `json:"content_format"`
+	UserID        uint               `json:"user_id"`
+	User          *UserSummary       `json:"user,omitempty"`
+	Tags          []TagResponse      `json:"tags"`
+	Categories    []CategoryResponse `json:"categories"`
+	Status        string             `json:"status"`
+	PublishedAt   *time.Time         `json:"published_at"`
+	Version       int                `json:"version"`
+	CommentCount  int64              `json:"comment_count"`
+	LikeCount     int64              `json:"like_count"`
+	BookmarkCount int64              `json:"bookmark_count"`
+	ViewCount     int64              `json:"view_count"` // 浏览数定期批量写入，比实际值略有延迟
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	Comments      []CommentResponse  `json:"comments,omitempty"`
+}
+
+// NewPostResponse 从文章模型生成响应，内容按 format 输出，已预加载的作者和评论一并转换
+func NewPostResponse(p *model.Post, format markup.Format) PostResponse {
+	return PostResponse{
+		ID:            p.ID,
+		Title:         p.Title,
+		Content:       format.Select(p.Content, p.ContentHTML),
+		ContentFormat: format,
+		UserID:        p.UserID,
+		User:          newUserSummary(&p.User),
+		Tags:          NewTagResponses(p.Tags),
+		Categories:    NewCategoryResponses(p.Categories),
+		Status:        p.Status,
+		PublishedAt:   p.PublishedAt,
+		Version:       p.Version,
+		CommentCount:  p.CommentCount,
+		LikeCount:     p.LikeCount,
+		BookmarkCount: p.BookmarkCount,
+		ViewCount:     p.ViewCount,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+		Comments:      NewCommentResponses(p.Comments, format),
+	}
+}
+
+// NewPostResponses 批量转换文章列表
+func NewPostResponses(posts []model.Post, format markup.Format) []PostResponse {
+	resp := make([]PostResponse, len(posts))
+	for i := range posts {
+		resp[i] = NewPostResponse(&posts[i], format)
+	}
+	return resp
+}
+
+// BookmarkResponse 收藏列表中的一项
+type BookmarkResponse struct {
+	BookmarkedAt time.Time    `json:"bookmarked_at"`
+	Post         PostResponse `json:"post"`
+}
+
+// NewBookmarkResponses 批量转换收藏列表，收藏记录需要预加载文章
+func NewBookmarkResponses(bookmarks []model.Bookmark, format markup.Format) []BookmarkResponse {
+	resp := make([]BookmarkResponse, len(bookmarks))
+	for i := range bookmarks {
+		resp[i] = BookmarkResponse{BookmarkedAt: bookmarks[i].CreatedAt, Post: NewPostResponse(&bookmarks[i].Post, format)}
+	}
+	return resp
+}
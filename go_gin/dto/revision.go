@@ -0,0 +1,170 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// diffContextLines 差异中每处修改前后保留的上下文行数
+const diffContextLines = 3
+
+// RevisionSummary 修订列表中的修订信息，不包含内容
+type RevisionSummary struct {
+	Number       int          `json:"number"`
+	Title        string       `json:"title"`
+	EditorID     uint         `json:"editor_id"`
+	Editor       *UserSummary `json:"editor,omitempty"`
+	RestoredFrom *int         `json:"restored_from"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// NewRevisionSummary 从修订模型生成摘要
+func NewRevisionSummary(r *model.PostRevision) RevisionSummary {
+	return RevisionSummary{
+		Number:       r.Number,
+		Title:        r.Title,
+		EditorID:     r.EditorID,
+		Editor:       newUserSummary(&r.Editor),
+		RestoredFrom: r.RestoredFrom,
+		CreatedAt:    r.CreatedAt,
+	}
+}
+
+// NewRevisionSummaries 批量转换修订列表
+func NewRevisionSummaries(revisions []model.PostRevision) []RevisionSummary {
+	resp := make([]RevisionSummary, len(revisions))
+	for i := range revisions {
+		resp[i] = NewRevisionSummary(&revisions[i])
+	}
+	return resp
+}
+
+// RevisionResponse 单个修订的完整信息
+type RevisionResponse struct {
+	RevisionSummary
+	Content string `json:"content"`
+}
+
+// NewRevisionResponse 从修订模型生成响应
+func NewRevisionResponse(r *model.PostRevision) RevisionResponse {
+	return RevisionResponse{RevisionSummary: NewRevisionSummary(r), Content: r.Content}
+}
+
+// RestoreRevisionRequest 恢复修订的请求，请求体可以省略
+// Version 为客户端看到的文章版本，没有 If-Match 头时用于冲突检测
+type RestoreRevisionRequest struct {
+	Version *int `json:"version" binding:"omitempty,gt=0"`
+}
+
+// RevisionDiff 两个修订之间的差异，内容按行比较
+type RevisionDiff struct {
+	From    int          `json:"from"`
+	To      int          `json:"to"`
+	Title   *TitleChange `json:"title,omitempty"` // 标题未变化时省略
+	Added   int          `json:"added"`           // 新增的行数
+	Removed int          `json:"removed"`         // 删除的行数
+	Hunks   []DiffHunk   `json:"hunks"`
+	Unified string       `json:"unified"` // unified diff 格式的文本，内容相同时为空
+}
+
+// TitleChange 标题的变化
+type TitleChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DiffHunk 一段连续的修改及其上下文，行号从1开始
+type DiffHunk struct {
+	FromStart int        `json:"from_start"`
+	FromLines int        `json:"from_lines"`
+	ToStart   int        `json:"to_start"`
+	ToLines   int        `json:"to_lines"`
+	Lines     []DiffLine `json:"lines"`
+}
+
+// DiffLine 差异中的一行，Op 为 " "（未变化）、"-"（删除）或 "+"（新增）
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// NewRevisionDiff 比较两个修订的标题和内容，换行符统一按 \n 处理
+func NewRevisionDiff(from, to *model.PostRevision) (*RevisionDiff, error) {
+	a := splitLines(normalizeNewlines(from.Content))
+	b := splitLines(normalizeNewlines(to.Content))
+
+	diff := &RevisionDiff{From: from.Number, To: to.Number, Hunks: []DiffHunk{}}
+	if from.Title != to.Title {
+		diff.Title = &TitleChange{From: from.Title, To: to.Title}
+	}
+
+	matcher := difflib.NewMatcher(a, b)
+	for _, group := range matcher.GetGroupedOpCodes(diffContextLines) {
+		first, last := group[0], group[len(group)-1]
+		hunk := DiffHunk{
+			FromStart: first.I1 + 1,
+			FromLines: last.I2 - first.I1,
+			ToStart:   first.J1 + 1,
+			ToLines:   last.J2 - first.J1,
+		}
+		for _, op := range group {
+			if op.Tag == 'e' {
+				hunk.Lines = appendDiffLines(hunk.Lines, " ", a[op.I1:op.I2])
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				hunk.Lines = appendDiffLines(hunk.Lines, "-", a[op.I1:op.I2])
+				diff.Removed += op.I2 - op.I1
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				hunk.Lines = appendDiffLines(hunk.Lines, "+", b[op.J1:op.J2])
+				diff.Added += op.J2 - op.J1
+			}
+		}
+		diff.Hunks = append(diff.Hunks, hunk)
+	}
+
+	unified, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        a,
+		B:        b,
+		FromFile: fmt.Sprintf("revision %d", from.Number),
+		ToFile:   fmt.Sprintf("revision %d", to.Number),
+		Context:  diffContextLines,
+	})
+	if err != nil {
+		return nil, err
+	}
+	diff.Unified = unified
+	return diff, nil
+}
+
+// splitLines 按行切分，每行保留行尾换行符，最后一行没有换行符时补上
+// difflib.SplitLines 会在以换行结尾的内容后多出一个空行，这里不使用
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// appendDiffLines 追加差异行，去掉 splitLines 保留的行尾换行符
+func appendDiffLines(dst []DiffLine, op string, lines []string) []DiffLine {
+	for _, line := range lines {
+		dst = append(dst, DiffLine{Op: op, Text: strings.TrimSuffix(line, "\n")})
+	}
+	return dst
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+func TestNewRevisionDiff(t *testing.T) {
+	from := &model.PostRevision{Number: 1, Title: "Hello", Content: "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"}
+	to := &model.PostRevision{Number: 3, Title: "Hello, world", Content: "one\nTWO\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"}
+
+	diff, err := NewRevisionDiff(from, to)
+	if err != nil {
+		t.Fatalf("NewRevisionDiff: %v", err)
+	}
+
+	wantUnified := `--- revision 1
++++ revision 3
+@@ -1,5 +1,5 @@
+ one
+-two
++TWO
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`
+	if diff.Unified != wantUnified {
+		t.Errorf("unified =\n%s\nwant\n%s", diff.Unified, wantUnified)
+	}
+	if diff.From != 1 || diff.To != 3 || diff.Added != 2 || diff.Removed != 1 {
+		t.Errorf("diff = from %d to %d +%d -%d, want 1 3 +2 -1", diff.From, diff.To, diff.Added, diff.Removed)
+	}
+	if diff.Title == nil || *diff.Title != (TitleChange{From: "Hello", To: "Hello, world"}) {
+		t.Errorf("title = %+v, want the change", diff.Title)
+	}
+
+	wantHunks := []DiffHunk{
+		{FromStart: 1, FromLines: 5, ToStart: 1, ToLines: 5, Lines: []DiffLine{
+			{" ", "one"}, {"-", "two"}, {"+", "TWO"}, {" ", "three"}, {" ", "four"}, {" ", "five"},
+		}},
+		{FromStart: 8, FromLines: 3, ToStart: 8, ToLines: 4, Lines: []DiffLine{
+			{" ", "eight"}, {" ", "nine"}, {" ", "ten"}, {"+", "eleven"},
+		}},
+	}
+	if !reflect.DeepEqual(diff.Hunks, wantHunks) {
+		t.Errorf("hunks = %+v\nwant %+v", diff.Hunks, wantHunks)
+	}
+}
+
+func TestNewRevisionDiffUnchanged(t *testing.T) {
+	// 只有换行符不同时视为内容相同
+	from := &model.PostRevision{Number: 2, Title: "Same", Content: "line one\r\nline two\r\n"}
+	to := &model.PostRevision{Number: 1, Title: "Same", Content: "line one\nline two\n"}
+
+	diff, err := NewRevisionDiff(from, to)
+	if err != nil {
+		t.Fatalf("NewRevisionDiff: %v", err)
+	}
+	if diff.Unified != "" || len(diff.Hunks) != 0 || diff.Added != 0 || diff.Removed != 0 || diff.Title != nil {
+		t.Errorf("diff = %+v, want no changes", diff)
+	}
+	if diff.Hunks == nil {
+		t.Error("hunks = nil, want an empty array in JSON")
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"one", []string{"one\n"}},
+		{"one\n", []string{"one\n"}},
+		{"one\ntwo", []string{"one\n", "two\n"}},
+		{"one\n\ntwo\n", []string{"one\n", "\n", "two\n"}},
+	}
+	for _, tt := range tests {
+		if got := splitLines(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitLines(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
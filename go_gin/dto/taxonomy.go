@@ -0,0 +1,124 @@
+package dto
+
+import (
+	"math"
+	"strings"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// TagRequest 创建和重命名标签的请求，重命名会同时更新 Slug
+type TagRequest struct {
+	Name string `json:"name" binding:"required,notblank,max=50,slug"`
+}
+
+// NewTag 由名称生成标签，名称去掉首尾空白并合并连续空白
+func NewTag(name string) model.Tag {
+	name = strings.Join(strings.Fields(name), " ")
+	return model.Tag{Name: name, Slug: model.Slugify(name)}
+}
+
+// Apply 把新名称写入已有的标签
+func (r *TagRequest) Apply(tag *model.Tag) {
+	t := NewTag(r.Name)
+	tag.Name, tag.Slug = t.Name, t.Slug
+}
+
+// CategoryRequest 创建和修改分类的请求
+type CategoryRequest struct {
+	Name        string `json:"name" binding:"required,notblank,max=50,slug"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// Apply 把请求内容写入分类，Slug 随名称更新
+func (r *CategoryRequest) Apply(category *model.Category) {
+	category.Name = strings.Join(strings.Fields(r.Name), " ")
+	category.Slug = model.Slugify(category.Name)
+	category.Description = strings.TrimSpace(r.Description)
+}
+
+// TagResponse 标签信息
+type TagResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// NewTagResponse 从标签模型生成响应
+func NewTagResponse(t *model.Tag) TagResponse {
+	return TagResponse{ID: t.ID, Name: t.Name, Slug: t.Slug}
+}
+
+// NewTagResponses 批量转换标签，nil 转换为空数组
+func NewTagResponses(tags []model.Tag) []TagResponse {
+	resp := make([]TagResponse, len(tags))
+	for i := range tags {
+		resp[i] = NewTagResponse(&tags[i])
+	}
+	return resp
+}
+
+// TagCloudItem 标签云中的一项，Weight 为1到5的显示权重
+type TagCloudItem struct {
+	TagResponse
+	PostCount int64 `json:"post_count"`
+	Weight    int   `json:"weight"`
+}
+
+// tagCloudLevels 标签云的权重级数
+const tagCloudLevels = 5
+
+// NewTagCloud 按文章数的对数计算权重，避免少数热门标签把其余标签都压到最低一级
+func NewTagCloud(tags []model.Tag) []TagCloudItem {
+	var maxCount int64
+	for _, t := range tags {
+		maxCount = max(maxCount, t.PostCount)
+	}
+
+	items := make([]TagCloudItem, len(tags))
+	for i, t := range tags {
+		weight := 1
+		if maxCount > 0 {
+			ratio := math.Log1p(float64(t.PostCount)) / math.Log1p(float64(maxCount))
+			weight = 1 + int(math.Round(ratio*(tagCloudLevels-1)))
+		}
+		items[i] = TagCloudItem{
+			TagResponse: NewTagResponse(&tags[i]),
+			PostCount:   t.PostCount,
+			Weight:      weight,
+		}
+	}
+	return items
+}
+
+// CategoryResponse 分类信息，PostCount 只在分类列表中返回
+type CategoryResponse struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description,omitempty"`
+	PostCount   *int64 `json:"post_count,omitempty"`
+}
+
+// NewCategoryResponse 从分类模型生成响应
+func NewCategoryResponse(c *model.Category) CategoryResponse {
+	return CategoryResponse{ID: c.ID, Name: c.Name, Slug: c.Slug, Description: c.Description}
+}
+
+// NewCategoryResponses 批量转换分类，nil 转换为空数组
+func NewCategoryResponses(categories []model.Category) []CategoryResponse {
+	resp := make([]CategoryResponse, len(categories))
+	for i := range categories {
+		resp[i] = NewCategoryResponse(&categories[i])
+	}
+	return resp
+}
+
+// NewCategoryListResponses 转换分类列表，包含每个分类的文章数
+func NewCategoryListResponses(categories []model.Category) []CategoryResponse {
+	resp := NewCategoryResponses(categories)
+	for i := range categories {
+		resp[i].PostCount = &categories[i].PostCount
+	}
+	return resp
+}
@@ -0,0 +1,146 @@
+package dto
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// RegisterRequest 注册请求，长度限制与 model.User 的字段长度一致
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50,username"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+	Password string `json:"password" binding:"required,min=8,password"`
+}
+
+// Model 转换为用户模型，密码需由调用方加密后再保存
+func (r *RegisterRequest) Model() *model.User {
+	return &model.User{
+		Username: r.Username,
+		Email:    strings.ToLower(r.Email),
+	}
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" binding:"required,max=50"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+// EmailRequest 按邮箱找回密码或重新发送验证邮件
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+// VerifyEmailRequest 提交邮箱验证邮件中的令牌
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required,max=128"`
+}
+
+// ResetPasswordRequest 提交重置密码邮件中的令牌和新密码，新密码规则与注册一致
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,max=128"`
+	NewPassword string `json:"new_password" binding:"required,min=8,password"`
+}
+
+// ChangePasswordRequest 修改密码的请求，新密码规则与注册一致
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required,max=72"`
+	NewPassword string `json:"new_password" binding:"required,min=8,password,nefield=OldPassword"`
+}
+
+// DeleteAccountRequest 注销账号的请求，需要再次输入密码确认
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+// UpdateUserRequest 用户修改自己的资料，PUT 提交全部字段，PATCH 通过合并补丁提交，规则与注册一致
+type UpdateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50,username"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+}
+
+// PatchableUserFields PATCH 用户资料时补丁可以包含的字段
+var PatchableUserFields = []string{"username", "email"}
+
+// NewUpdateUserRequest 由用户当前的资料生成请求，作为合并补丁的基础
+func NewUpdateUserRequest(u *model.User) UpdateUserRequest {
+	return UpdateUserRequest{Username: u.Username, Email: u.Email}
+}
+
+// Apply 把资料写入用户，返回值发生变化的模型字段名，修改邮箱后需要重新验证
+func (r *UpdateUserRequest) Apply(u *model.User) []string {
+	var changed []string
+	if r.Username != u.Username {
+		u.Username = r.Username
+		changed = append(changed, "Username")
+	}
+	if email := strings.ToLower(r.Email); email != u.Email {
+		u.Email = email
+		u.EmailVerifiedAt = nil
+		changed = append(changed, "Email", "EmailVerifiedAt")
+	}
+	return changed
+}
+
+// UserResponse 用户信息，不包含密码哈希
+type UserResponse struct {
+	ID              uint       `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"` // 邮箱验证时间，未验证时为 null
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// NewUserResponse 从用户模型生成响应
+func NewUserResponse(u *model.User) UserResponse {
+	return UserResponse{
+		ID:              u.ID,
+		Username:        u.Username,
+		Email:           u.Email,
+		Role:            u.Role,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		CreatedAt:       u.CreatedAt,
+	}
+}
+
+// UserProfileResponse 公开的用户资料，不包含邮箱
+type UserProfileResponse struct {
+	ID           uint      `json:"id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	PostCount    int64     `json:"post_count"`    // 已发布的文章数
+	CommentCount int64     `json:"comment_count"` // 评论数
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewUserProfileResponse 从用户模型生成公开资料
+func NewUserProfileResponse(u *model.User) UserProfileResponse {
+	return UserProfileResponse{
+		ID:           u.ID,
+		Username:     u.Username,
+		Role:         u.Role,
+		PostCount:    u.PostCount,
+		CommentCount: u.CommentCount,
+		CreatedAt:    u.CreatedAt,
+	}
+}
+
+// UserSummary 文章和评论中嵌入的作者信息
+type UserSummary struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+// DeletedUsername 已注销用户在评论中显示的名称
+const DeletedUsername = "deleted user"
+
+// newUserSummary 关联的用户未加载时返回 nil
+func newUserSummary(u *model.User) *UserSummary {
+	if u.ID == 0 {
+		return nil
+	}
+	return &UserSummary{ID: u.ID, Username: u.Username}
+}
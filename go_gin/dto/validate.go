@@ -0,0 +1,66 @@
+// Package dto 定义接口的请求和响应结构，以及与数据库模型之间的转换
+// 请求体不直接绑定到 GORM 模型，响应也不直接返回模型，避免客户端写入或读到内部字段
+package dto
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// maxPasswordBytes bcrypt 只使用密码的前72个字节，更长的密码直接拒绝
+const maxPasswordBytes = 72
+
+// RegisterValidators 注册请求结构中使用的自定义校验规则，需在处理请求前调用一次
+//   - username: 只能包含字母、数字和下划线
+//   - password: 同时包含字母和数字，且不超过72字节
+//   - notblank: 去掉首尾空白后不能为空
+//   - slug: 能生成非空的 Slug，即至少包含一个字母或数字
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+	rules := map[string]validator.Func{
+		"username": func(fl validator.FieldLevel) bool {
+			return usernamePattern.MatchString(fl.Field().String())
+		},
+		"password": func(fl validator.FieldLevel) bool {
+			return validPassword(fl.Field().String())
+		},
+		"notblank": func(fl validator.FieldLevel) bool {
+			return strings.TrimSpace(fl.Field().String()) != ""
+		},
+		"slug": func(fl validator.FieldLevel) bool {
+			return model.Slugify(fl.Field().String()) != ""
+		},
+	}
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validPassword(s string) bool {
+	if len(s) > maxPasswordBytes {
+		return false
+	}
+	var letter, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return letter && digit
+}
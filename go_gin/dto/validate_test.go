@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func testValidator(t *testing.T) *validator.Validate {
+	t.Helper()
+	if err := RegisterValidators(); err != nil {
+		t.Fatalf("RegisterValidators: %v", err)
+	}
+	return binding.Validator.Engine().(*validator.Validate)
+}
+
+func TestCustomValidators(t *testing.T) {
+	v := testValidator(t)
+
+	tests := []struct {
+		tag   string
+		value string
+		valid bool
+	}{
+		{"username", "alice_01", true},
+		{"username", "ALICE", true},
+		{"username", "alice-01", false},
+		{"username", "alice bob", false},
+		{"username", "管理员", false},
+		{"username", "alice\n", false},
+
+		{"password", "secret123", true},
+		{"password", "密码abc123", true},
+		{"password", "12345678", false},
+		{"password", "password", false},
+		{"password", "!@#$%^&*", false},
+		{"password", "a1" + strings.Repeat("x", 70), true},
+		{"password", "a1" + strings.Repeat("x", 71), false},
+		{"password", "a1" + strings.Repeat("密", 24), false},
+
+		{"notblank", "hello", true},
+		{"notblank", "  hello  ", true},
+		{"notblank", "   ", false},
+		{"notblank", "\t\n", false},
+		{"notblank", "　", false},
+
+		{"slug", "Go", true},
+		{"slug", "Hello World", true},
+		{"slug", "编程", true},
+		{"slug", "c++", true},
+		{"slug", "---", false},
+		{"slug", "!!!", false},
+		{"slug", "  ", false},
+	}
+	for _, tt := range tests {
+		err := v.Var(tt.value, tt.tag)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s(%q) error = %v, want valid=%v", tt.tag, tt.value, err, tt.valid)
+		}
+	}
+}
+
+// TestRequestValidation 自定义规则与请求结构中的其他规则组合使用
+func TestRequestValidation(t *testing.T) {
+	testValidator(t)
+
+	tests := []struct {
+		name string
+		req  any
+		tag  string // 为空表示校验通过
+	}{
+		{"valid register", &RegisterRequest{Username: "alice", Email: "a@example.com", Password: "secret123"}, ""},
+		{"register bad username", &RegisterRequest{Username: "al ice", Email: "a@example.com", Password: "secret123"}, "username"},
+		{"register weak password", &RegisterRequest{Username: "alice", Email: "a@example.com", Password: "abcdefgh"}, "password"},
+		{"change to same password", &ChangePasswordRequest{OldPassword: "secret123", NewPassword: "secret123"}, "nefield"},
+		{"blank post title", &PostRequest{Title: "  ", Content: "body"}, "notblank"},
+		{"tag without letters", &PostRequest{Title: "t", Content: "body", Tags: []string{"go", "--"}}, "slug"},
+		{"valid post tags", &PostRequest{Title: "t", Content: "body", Tags: []string{"go", "Web 开发"}}, ""},
+		{"tag name without letters", &TagRequest{Name: "***"}, "slug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(tt.req)
+			if tt.tag == "" {
+				if err != nil {
+					t.Errorf("ValidateStruct = %v, want nil", err)
+				}
+				return
+			}
+			var ves validator.ValidationErrors
+			if !errors.As(err, &ves) || len(ves) != 1 || ves[0].Tag() != tt.tag {
+				t.Errorf("ValidateStruct = %v, want one %s error", err, tt.tag)
+			}
+		})
+	}
+}
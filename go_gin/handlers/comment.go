@@ -1,78 +1,310 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
 	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
 )
 
-// CreateComment 创建评论
-func CreateComment(c *gin.Context) {
+// CommentHandler 评论相关处理函数
+type CommentHandler struct {
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	maxDepth int // 树形展示的默认最大层级
+}
+
+// NewCommentHandler 创建评论处理器，maxDepth 为树形展示时默认的最大嵌套层级
+func NewCommentHandler(posts repository.PostRepository, comments repository.CommentRepository, maxDepth int) *CommentHandler {
+	return &CommentHandler{posts: posts, comments: comments, maxDepth: maxDepth}
+}
+
+// commentNode 树形展示的评论节点
+type commentNode struct {
+	dto.CommentResponse
+	Replies []*commentNode `json:"replies,omitempty"`
+}
+
+// CreateComment 创建评论，parent_id 不为空时回复该评论
+func (h *CommentHandler) CreateComment(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperr.Unauthorized("User not authenticated"))
 		return
 	}
 
 	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		c.Error(apperr.Validation("Invalid post ID"))
 		return
 	}
 
-	// 检查文章是否存在
-	var post model.Post
-	if err := model.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	// 检查文章是否存在，未发布的文章对无权查看的用户视为不存在
+	post, err := h.posts.FindByID(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if !canViewPost(c, post) {
+		c.Error(apperr.NotFound("Post not found"))
 		return
 	}
 
-	var comment model.Comment
-	if err := c.ShouldBindJSON(&comment); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
 		return
 	}
 
-	comment.UserID = userID.(uint)
-	comment.PostID = uint(postID)
+	comment := req.Model(uint(postID), userID.(uint))
 
-	if err := model.DB.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+	// 回复必须指向同一篇文章下的评论
+	if comment.ParentID != nil {
+		parent, err := h.comments.FindByID(c.Request.Context(), *comment.ParentID)
+		if err != nil || parent.PostID != comment.PostID {
+			c.Error(apperr.Validation("Parent comment not found in this post"))
+			return
+		}
+		if parent.Depth+1 > model.MaxCommentDepth {
+			c.Error(apperr.Validation("Reply is nested too deeply"))
+			return
+		}
+		comment.Depth = parent.Depth + 1
+	}
+
+	if err := h.comments.Create(c.Request.Context(), comment); err != nil {
+		c.Error(apperr.FromDB(err, "Comment"))
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Comment created successfully",
-		"comment": comment,
+		"comment": dto.NewCommentResponse(comment, markup.FormatMarkdown),
 	})
 }
 
-// GetComments 获取文章的所有评论
-func GetComments(c *gin.Context) {
+// GetComments 分页获取文章的评论，分页和过滤参数与 GetPosts 相同
+// view=flat（默认）返回平铺列表，sort=thread 时按楼层顺序排列，回复紧跟在被回复的评论之后；
+// view=tree 对顶层评论分页，每条顶层评论带上嵌套的 replies，超过 max_depth 的回复挂在最深一层，
+// 已删除但仍有回复的顶层评论以 deleted 占位返回；
+// format=markdown|html|text 指定内容格式，默认返回 Markdown 原文
+func (h *CommentHandler) GetComments(c *gin.Context) {
 	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		c.Error(apperr.Validation("Invalid post ID"))
+		return
+	}
+
+	// 检查文章是否存在，未发布的文章对无权查看的用户视为不存在
+	post, err := h.posts.FindByID(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if !canViewPost(c, post) {
+		c.Error(apperr.NotFound("Post not found"))
+		return
+	}
+
+	pageReq, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+	f, err := parseListFilter(c)
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+
+	format, err := markup.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+
+	view := c.DefaultQuery("view", "flat")
+	if view != "flat" && view != "tree" {
+		c.Error(apperr.Validation("view must be flat or tree"))
+		return
+	}
+	maxDepth := h.maxDepth
+	if v := c.Query("max_depth"); v != "" {
+		maxDepth, err = strconv.Atoi(v)
+		if err != nil || maxDepth < 1 || maxDepth > model.MaxCommentDepth {
+			c.Error(apperr.Validation("Invalid max_depth"))
+			return
+		}
+	}
+
+	filter := repository.CommentFilter{
+		RootsOnly:   view == "tree",
+		UserID:      f.userID,
+		CreatedFrom: f.from,
+		CreatedTo:   f.to,
+	}
+	page, err := h.comments.ListByPost(c.Request.Context(), uint(postID), filter, pageReq)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidPage) {
+			c.Error(apperr.Validation(err.Error()))
+			return
+		}
+		c.Error(apperr.Internal("Failed to fetch comments", err))
+		return
+	}
+
+	if view == "flat" {
+		c.JSON(http.StatusOK, pageResponse("comments", dto.NewCommentResponses(page.Items, format), page))
+		return
+	}
+
+	rootPaths := make([]string, len(page.Items))
+	for i, root := range page.Items {
+		rootPaths[i] = root.Path
+	}
+	replies, err := h.comments.ListReplies(c.Request.Context(), uint(postID), rootPaths)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch comments", err))
+		return
+	}
+
+	resp := pageResponse("comments", buildCommentTree(page.Items, replies, maxDepth, format), page)
+	c.JSON(http.StatusOK, resp)
+}
+
+// buildCommentTree 把回复挂到顶层评论下，replies 需按楼层顺序排列
+// 父评论已被删除时挂到最近的祖先下，超过 maxDepth 的回复挂在第 maxDepth 层
+func buildCommentTree(roots, replies []model.Comment, maxDepth int, format markup.Format) []*commentNode {
+	nodes := make(map[uint]*commentNode, len(roots)+len(replies))
+	parents := make(map[uint]*commentNode)
+	depths := make(map[uint]int)
+
+	tree := make([]*commentNode, len(roots))
+	for i := range roots {
+		n := &commentNode{CommentResponse: dto.NewCommentResponse(&roots[i], format)}
+		nodes[n.ID] = n
+		tree[i] = n
+	}
+
+	for i := range replies {
+		n := &commentNode{CommentResponse: dto.NewCommentResponse(&replies[i], format)}
+		parent := nearestAncestor(nodes, replies[i].Path)
+		if parent == nil {
+			continue
+		}
+		for depths[parent.ID] >= maxDepth {
+			parent = parents[parent.ID]
+		}
+		parent.Replies = append(parent.Replies, n)
+		nodes[n.ID] = n
+		parents[n.ID] = parent
+		depths[n.ID] = depths[parent.ID] + 1
+	}
+	return tree
+}
+
+// nearestAncestor 沿路径从近到远查找已加载的祖先评论
+func nearestAncestor(nodes map[uint]*commentNode, path string) *commentNode {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] != '/' {
+			continue
+		}
+		start := i - 1
+		for start >= 0 && path[start] != '/' {
+			start--
+		}
+		id, err := strconv.ParseUint(path[start+1:i], 10, 32)
+		if err != nil {
+			return nil
+		}
+		if n, ok := nodes[uint(id)]; ok {
+			return n
+		}
+	}
+	return nil
+}
+
+// UpdateComment 修改评论内容，评论作者本人或版主、管理员可以修改
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperr.Unauthorized("User not authenticated"))
+		return
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid comment ID"))
 		return
 	}
 
-	// 检查文章是否存在
-	var post model.Post
-	if err := model.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	comment, err := h.comments.FindByID(c.Request.Context(), uint(commentID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Comment"))
+		return
+	}
+
+	if !rbac.CanModify(middleware.CurrentRole(c), userID.(uint), comment.AuthorID(), rbac.PermCommentEditAny) {
+		c.Error(apperr.Forbidden("You can only update your own comments"))
 		return
 	}
 
-	var comments []model.Comment
-	// 预加载用户信息
-	if err := model.DB.Preload("User").Where("post_id = ?", postID).Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+	var req dto.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	req.Apply(comment)
+	if err := h.comments.Update(c.Request.Context(), comment); err != nil {
+		c.Error(apperr.FromDB(err, "Comment"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"comments": comments,
-		"count":    len(comments),
+		"message": "Comment updated successfully",
+		"comment": dto.NewCommentResponse(comment, markup.FormatMarkdown),
 	})
-}
\ No newline at end of file
+}
+
+// DeleteComment 删除评论，评论作者本人或版主、管理员可以删除
+// 回复不会随之删除，树形展示时挂到最近的祖先下，顶层评论被删除时保留为占位
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperr.Unauthorized("User not authenticated"))
+		return
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid comment ID"))
+		return
+	}
+
+	comment, err := h.comments.FindByID(c.Request.Context(), uint(commentID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Comment"))
+		return
+	}
+
+	if !rbac.CanModify(middleware.CurrentRole(c), userID.(uint), comment.AuthorID(), rbac.PermCommentDeleteAny) {
+		c.Error(apperr.Forbidden("You can only delete your own comments"))
+		return
+	}
+
+	if err := h.comments.Delete(c.Request.Context(), comment); err != nil {
+		c.Error(apperr.FromDB(err, "Comment"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"gorm.io/gorm"
+)
+
+// treeNode 树形展示响应中的评论节点
+type treeNode struct {
+	ID      uint        `json:"id"`
+	Content string      `json:"content"`
+	Deleted bool        `json:"deleted"`
+	User    *struct{}   `json:"user"`
+	Replies []*treeNode `json:"replies"`
+}
+
+func createTestComment(t *testing.T, store *repository.Store, postID, userID uint, parent *model.Comment) *model.Comment {
+	t.Helper()
+	comment := &model.Comment{Content: "comment", PostID: postID, UserID: &userID}
+	if parent != nil {
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+	if err := store.Comments.Create(context.Background(), comment); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	return comment
+}
+
+func TestGetCommentsTreeKeepsRepliesOfDeletedRoot(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user := createTestUser(t, store, "alice", rbac.RoleAuthor)
+	post := createTestPost(t, store, user.ID)
+
+	// root1 被删除，但它的回复 reply 仍在；root2 被删除且没有回复；root3 正常
+	root1 := createTestComment(t, store, post.ID, user.ID, nil)
+	reply := createTestComment(t, store, post.ID, user.ID, root1)
+	nested := createTestComment(t, store, post.ID, user.ID, reply)
+	root2 := createTestComment(t, store, post.ID, user.ID, nil)
+	root3 := createTestComment(t, store, post.ID, user.ID, nil)
+	for _, c := range []*model.Comment{root1, root2} {
+		if err := store.Comments.Delete(ctx, c); err != nil {
+			t.Fatalf("delete comment: %v", err)
+		}
+	}
+
+	router := newTestRouter(0, "")
+	h := NewCommentHandler(store.Posts, store.Comments, model.MaxCommentDepth)
+	router.GET("/posts/:id/comments", h.GetComments)
+
+	var resp struct {
+		Comments []*treeNode `json:"comments"`
+		Total    int64       `json:"total"`
+	}
+	w := doJSON(t, router, http.MethodGet, fmt.Sprintf("/posts/%d/comments?view=tree", post.ID), nil, nil, &resp)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	if len(resp.Comments) != 2 {
+		t.Fatalf("got %d roots, want 2 (tombstone and live root)", len(resp.Comments))
+	}
+	tombstone, live := resp.Comments[0], resp.Comments[1]
+	if tombstone.ID != root1.ID || !tombstone.Deleted || tombstone.Content != "" || tombstone.User != nil {
+		t.Errorf("first root = %+v, want blank tombstone for comment %d", tombstone, root1.ID)
+	}
+	if len(tombstone.Replies) != 1 || tombstone.Replies[0].ID != reply.ID {
+		t.Fatalf("tombstone replies = %+v, want reply %d", tombstone.Replies, reply.ID)
+	}
+	if r := tombstone.Replies[0]; r.Deleted || r.Content == "" || len(r.Replies) != 1 || r.Replies[0].ID != nested.ID {
+		t.Errorf("reply = %+v, want live reply with nested reply %d", r, nested.ID)
+	}
+	if live.ID != root3.ID || live.Deleted {
+		t.Errorf("second root = %+v, want live comment %d", live, root3.ID)
+	}
+	if resp.Total != 2 {
+		t.Errorf("total = %d, want 2", resp.Total)
+	}
+}
+
+// pathComment 按祖先ID路径构造评论，最后一个ID为评论自身
+func pathComment(ids ...uint) model.Comment {
+	segments := make([]string, len(ids))
+	for i, id := range ids {
+		segments[i] = model.CommentPathSegment(id)
+	}
+	c := model.Comment{ID: ids[len(ids)-1], Content: "comment", Depth: len(ids) - 1, Path: strings.Join(segments, "/")}
+	if len(ids) > 1 {
+		c.ParentID = &ids[len(ids)-2]
+	}
+	return c
+}
+
+// formatTree 把评论树格式化为 "1[2[3]] 4" 的形式，已删除的评论以 x 标记
+func formatTree(nodes []*commentNode) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		s := strconv.FormatUint(uint64(n.ID), 10)
+		if n.Deleted {
+			s += "x"
+		}
+		if len(n.Replies) > 0 {
+			s += "[" + formatTree(n.Replies) + "]"
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuildCommentTree(t *testing.T) {
+	deleted := pathComment(1)
+	deleted.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	tests := []struct {
+		name     string
+		roots    []model.Comment
+		replies  []model.Comment
+		maxDepth int
+		want     string
+	}{
+		{"no replies", []model.Comment{pathComment(1), pathComment(2)}, nil, 5, "1 2"},
+		{
+			"nested replies in floor order",
+			[]model.Comment{pathComment(1), pathComment(4)},
+			[]model.Comment{pathComment(1, 2), pathComment(1, 2, 3), pathComment(1, 5), pathComment(4, 6)},
+			5, "1[2[3] 5] 4[6]",
+		},
+		{
+			"deleted parent attaches to nearest ancestor",
+			[]model.Comment{pathComment(1)},
+			[]model.Comment{pathComment(1, 2, 3, 4), pathComment(1, 5)},
+			5, "1[4 5]",
+		},
+		{
+			"replies beyond max depth flattened",
+			[]model.Comment{pathComment(1)},
+			[]model.Comment{pathComment(1, 2), pathComment(1, 2, 3), pathComment(1, 2, 3, 4)},
+			2, "1[2[3 4]]",
+		},
+		{
+			"max depth 1",
+			[]model.Comment{pathComment(1)},
+			[]model.Comment{pathComment(1, 2), pathComment(1, 2, 3)},
+			1, "1[2 3]",
+		},
+		{
+			"replies of roots outside the page dropped",
+			[]model.Comment{pathComment(1)},
+			[]model.Comment{pathComment(9, 10), pathComment(1, 2)},
+			5, "1[2]",
+		},
+		{
+			"deleted root kept as tombstone",
+			[]model.Comment{deleted, pathComment(3)},
+			[]model.Comment{pathComment(1, 2)},
+			5, "1x[2] 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := buildCommentTree(tt.roots, tt.replies, tt.maxDepth, markup.FormatMarkdown)
+			if got := formatTree(tree); got != tt.want {
+				t.Errorf("tree = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeletedUserComments(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	alice := createTestUser(t, store, "alice", rbac.RoleAuthor)
+	bob := createTestUser(t, store, "bob", rbac.RoleReader)
+	post := createTestPost(t, store, bob.ID)
+
+	kept := createTestComment(t, store, post.ID, alice.ID, nil)
+	removed := createTestComment(t, store, post.ID, alice.ID, nil)
+	other := createTestComment(t, store, post.ID, bob.ID, kept)
+	if err := store.Comments.Delete(ctx, removed); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+	if err := store.Users.Delete(ctx, alice); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	router := newTestRouter(0, "")
+	h := NewCommentHandler(store.Posts, store.Comments, model.MaxCommentDepth)
+	router.GET("/posts/:id/comments", h.GetComments)
+
+	type author struct {
+		ID       uint   `json:"id"`
+		Username string `json:"username"`
+	}
+	var resp struct {
+		Comments []struct {
+			ID     uint    `json:"id"`
+			UserID *uint   `json:"user_id"`
+			User   *author `json:"user"`
+		} `json:"comments"`
+	}
+	w := doJSON(t, router, http.MethodGet, fmt.Sprintf("/posts/%d/comments", post.ID), nil, nil, &resp)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if len(resp.Comments) != 2 {
+		t.Fatalf("got %d comments, want 2", len(resp.Comments))
+	}
+	anon, reply := resp.Comments[0], resp.Comments[1]
+	if anon.ID != kept.ID || anon.UserID != nil || anon.User == nil || *anon.User != (author{Username: "deleted user"}) {
+		t.Errorf("comment of deleted user = %+v (user %+v), want placeholder author", anon, anon.User)
+	}
+	if reply.ID != other.ID || reply.UserID == nil || *reply.UserID != bob.ID || reply.User == nil || reply.User.Username != "bob" {
+		t.Errorf("comment of bob = %+v (user %+v), want bob", reply, reply.User)
+	}
+
+	// 匿名评论仍可删除，文章计数随之减少
+	comment, err := store.Comments.FindByID(ctx, kept.ID)
+	if err != nil {
+		t.Fatalf("find comment: %v", err)
+	}
+	if err := store.Comments.Delete(ctx, comment); err != nil {
+		t.Fatalf("delete anonymous comment: %v", err)
+	}
+	got, err := store.Posts.FindByID(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+	if got.CommentCount != 1 {
+		t.Errorf("post comment_count = %d, want 1", got.CommentCount)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// ViewRecorder 记录文章浏览，由 scheduler.ViewCounter 在内存中累计后批量写入
+type ViewRecorder interface {
+	Add(postID uint)
+}
+
+// LikePost 点赞文章，重复点赞不会重复计数
+func (h *PostHandler) LikePost(c *gin.Context) {
+	post, ok := h.viewablePost(c)
+	if !ok {
+		return
+	}
+	added, err := h.engagement.Like(c.Request.Context(), c.GetUint("userID"), post.ID)
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if added {
+		post.LikeCount++
+	}
+	c.JSON(http.StatusOK, gin.H{"liked": true, "like_count": post.LikeCount})
+}
+
+// UnlikePost 取消点赞，没有点过赞时直接返回成功
+func (h *PostHandler) UnlikePost(c *gin.Context) {
+	post, ok := h.viewablePost(c)
+	if !ok {
+		return
+	}
+	removed, err := h.engagement.Unlike(c.Request.Context(), c.GetUint("userID"), post.ID)
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if removed && post.LikeCount > 0 {
+		post.LikeCount--
+	}
+	c.JSON(http.StatusOK, gin.H{"liked": false, "like_count": post.LikeCount})
+}
+
+// BookmarkPost 收藏文章，重复收藏不会重复计数
+func (h *PostHandler) BookmarkPost(c *gin.Context) {
+	post, ok := h.viewablePost(c)
+	if !ok {
+		return
+	}
+	added, err := h.engagement.Bookmark(c.Request.Context(), c.GetUint("userID"), post.ID)
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if added {
+		post.BookmarkCount++
+	}
+	c.JSON(http.StatusOK, gin.H{"bookmarked": true, "bookmark_count": post.BookmarkCount})
+}
+
+// UnbookmarkPost 取消收藏，没有收藏过时直接返回成功
+func (h *PostHandler) UnbookmarkPost(c *gin.Context) {
+	post, ok := h.viewablePost(c)
+	if !ok {
+		return
+	}
+	removed, err := h.engagement.Unbookmark(c.Request.Context(), c.GetUint("userID"), post.ID)
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if removed && post.BookmarkCount > 0 {
+		post.BookmarkCount--
+	}
+	c.JSON(http.StatusOK, gin.H{"bookmarked": false, "bookmark_count": post.BookmarkCount})
+}
+
+// ListBookmarks 分页查询当前用户收藏的文章，按收藏时间倒序，已删除或不再公开的文章不返回
+// format 指定文章内容的格式，与 GetPosts 相同
+func (h *PostHandler) ListBookmarks(c *gin.Context) {
+	pageReq, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+	format, err := markup.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+
+	page, err := h.engagement.ListBookmarks(c.Request.Context(), c.GetUint("userID"), postVisibility(c), pageReq)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidPage) {
+			c.Error(apperr.Validation(err.Error()))
+			return
+		}
+		c.Error(apperr.Internal("Failed to fetch bookmarks", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse("bookmarks", dto.NewBookmarkResponses(page.Items, format), page))
+}
+
+// viewablePost 查询路径中的文章，当前用户看不到的文章返回 404
+// 返回 false 时已经记录了错误
+func (h *PostHandler) viewablePost(c *gin.Context) (*model.Post, bool) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid post ID"))
+		return nil, false
+	}
+
+	post, err := h.posts.FindByID(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return nil, false
+	}
+	if !canViewPost(c, post) {
+		c.Error(apperr.NotFound("Post not found"))
+		return nil, false
+	}
+	return post, true
+}
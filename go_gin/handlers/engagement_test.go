@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/search"
+)
+
+// TestEngagementIdempotent 重复点赞、收藏或取消时计数不变，响应中的计数与数据库一致
+func TestEngagementIdempotent(t *testing.T) {
+	store := newTestStore(t)
+	alice := createTestUser(t, store, "alice", rbac.RoleAuthor)
+	bob := createTestUser(t, store, "bob", rbac.RoleReader)
+	post := createTestPost(t, store, alice.ID)
+	h := NewPostHandler(store.Posts, store.Revisions, store.Tags, store.Categories, store.Engagement, noopViews{}, search.NewMemoryIndex())
+
+	routers := map[uint]*gin.Engine{}
+	for _, id := range []uint{alice.ID, bob.ID} {
+		router := newTestRouter(id, rbac.RoleReader)
+		router.GET("/posts/:id", h.GetPost)
+		router.POST("/posts/:id/like", h.LikePost)
+		router.DELETE("/posts/:id/like", h.UnlikePost)
+		router.POST("/posts/:id/bookmark", h.BookmarkPost)
+		router.DELETE("/posts/:id/bookmark", h.UnbookmarkPost)
+		routers[id] = router
+	}
+
+	tests := []struct {
+		name      string
+		user      uint
+		method    string
+		action    string
+		wantCount int64
+	}{
+		{"alice likes", alice.ID, http.MethodPost, "like", 1},
+		{"alice likes again", alice.ID, http.MethodPost, "like", 1},
+		{"bob likes", bob.ID, http.MethodPost, "like", 2},
+		{"alice unlikes", alice.ID, http.MethodDelete, "like", 1},
+		{"alice unlikes again", alice.ID, http.MethodDelete, "like", 1},
+		{"alice bookmarks", alice.ID, http.MethodPost, "bookmark", 1},
+		{"alice bookmarks again", alice.ID, http.MethodPost, "bookmark", 1},
+		{"bob unbookmarks without bookmark", bob.ID, http.MethodDelete, "bookmark", 1},
+		{"alice unbookmarks", alice.ID, http.MethodDelete, "bookmark", 0},
+		{"alice unbookmarks again", alice.ID, http.MethodDelete, "bookmark", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp map[string]any
+			path := fmt.Sprintf("/posts/%d/%s", post.ID, tt.action)
+			w := doJSON(t, routers[tt.user], tt.method, path, nil, nil, &resp)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if got := resp[tt.action+"_count"]; got != float64(tt.wantCount) {
+				t.Errorf("response %s_count = %v, want %d", tt.action, got, tt.wantCount)
+			}
+			flag := map[string]string{"like": "liked", "bookmark": "bookmarked"}[tt.action]
+			if got := resp[flag]; got != (tt.method == http.MethodPost) {
+				t.Errorf("response %s = %v", flag, got)
+			}
+
+			stored, err := store.Posts.FindByID(context.Background(), post.ID)
+			if err != nil {
+				t.Fatalf("find post: %v", err)
+			}
+			count := stored.LikeCount
+			if tt.action == "bookmark" {
+				count = stored.BookmarkCount
+			}
+			if count != tt.wantCount {
+				t.Errorf("stored %s_count = %d, want %d", tt.action, count, tt.wantCount)
+			}
+		})
+	}
+
+	// 文章详情中的互动状态
+	for _, tt := range []struct {
+		user              uint
+		liked, bookmarked bool
+	}{
+		{alice.ID, false, false},
+		{bob.ID, true, false},
+	} {
+		var resp struct {
+			Liked      bool `json:"liked"`
+			Bookmarked bool `json:"bookmarked"`
+		}
+		doJSON(t, routers[tt.user], http.MethodGet, fmt.Sprintf("/posts/%d", post.ID), nil, nil, &resp)
+		if resp.Liked != tt.liked || resp.Bookmarked != tt.bookmarked {
+			t.Errorf("user %d state = %+v, want liked %v bookmarked %v", tt.user, resp, tt.liked, tt.bookmarked)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// 文章的 ETag 以版本号开头，如 "3" 或 "3-9f86d081"，If-Match 只比较版本号；
+// GetPost 的响应还包含评论等随时会变化的内容，因此在版本号后附加响应体的摘要，供 If-None-Match 判断
+
+// versionETag 只由版本号组成的 ETag，用于修改文章后的响应
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// bodyETag 由版本号和响应体摘要组成的 ETag
+func bodyETag(version int, body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return fmt.Sprintf(`"%d-%x"`, version, h.Sum64())
+}
+
+// etagVersion 解析 ETag 中的版本号，弱 ETag 不能用于 If-Match
+func etagVersion(tag string) (int, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+	n, err := strconv.Atoi(v)
+	return n, err == nil
+}
+
+// ifMatch If-Match 头是否与当前版本匹配，* 匹配任何已存在的文章
+func ifMatch(header string, version int) bool {
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == "*" {
+			return true
+		}
+		if v, ok := etagVersion(tag); ok && v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ifNoneMatch If-None-Match 头是否包含 etag，按弱比较忽略 W/ 前缀
+func ifNoneMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPostVersion 校验客户端修改的是文章的当前版本
+// 优先使用 If-Match 头，不匹配时返回 412；没有 If-Match 时比较请求中的 version，不一致时返回 409；
+// 两者都没有时 required 为 true 则返回 428。失败时响应头带上当前版本的 ETag
+func checkPostVersion(c *gin.Context, post *model.Post, version *int, required bool) *apperr.Error {
+	var err *apperr.Error
+	if header := c.GetHeader("If-Match"); header != "" {
+		if !ifMatch(header, post.Version) {
+			err = apperr.PreconditionFailed("Post has been modified since it was fetched")
+		}
+	} else if version != nil {
+		if *version != post.Version {
+			err = apperr.Conflict("Post has been modified since it was fetched")
+		}
+	} else if required {
+		err = apperr.PreconditionRequired("If-Match header or version is required")
+	}
+	if err != nil {
+		c.Header("ETag", versionETag(post.Version))
+		return err.WithMeta("current_version", post.Version)
+	}
+	return nil
+}
+
+// versionConflict 保存时发现版本号已被其他请求修改，返回 409 和最新版本
+func versionConflict(c *gin.Context, current int) *apperr.Error {
+	c.Header("ETag", versionETag(current))
+	return apperr.Conflict("Post was modified by another request").WithMeta("current_version", current)
+}
+
+// writeConditionalJSON 输出 JSON 响应并设置 ETag，If-None-Match 命中时返回 304
+func writeConditionalJSON(c *gin.Context, version int, obj any) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to encode response", err))
+		return
+	}
+	etag := bodyETag(version, body)
+	c.Header("ETag", etag)
+	if header := c.GetHeader("If-None-Match"); header != "" && ifNoneMatch(header, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
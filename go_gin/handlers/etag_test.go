@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/search"
+)
+
+func TestETagVersion(t *testing.T) {
+	tests := []struct {
+		tag     string
+		version int
+		ok      bool
+	}{
+		{`"3"`, 3, true},
+		{`"3-9f86d081"`, 3, true},
+		{` "12" `, 12, true},
+		{`W/"3"`, 0, false},
+		{`3`, 0, false},
+		{`""`, 0, false},
+		{`"abc"`, 0, false},
+		{`"`, 0, false},
+	}
+	for _, tt := range tests {
+		version, ok := etagVersion(tt.tag)
+		if version != tt.version || ok != tt.ok {
+			t.Errorf("etagVersion(%q) = %d, %v, want %d, %v", tt.tag, version, ok, tt.version, tt.ok)
+		}
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"2"`, true},
+		{`"2-abcdef"`, true},
+		{`"1", "2"`, true},
+		{`*`, true},
+		{`"1"`, false},
+		{`W/"2"`, false},
+		{`2`, false},
+	}
+	for _, tt := range tests {
+		if got := ifMatch(tt.header, 2); got != tt.want {
+			t.Errorf("ifMatch(%q, 2) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	const etag = `"2-abcdef"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"2-abcdef"`, true},
+		{`W/"2-abcdef"`, true},
+		{`"1-000000", "2-abcdef"`, true},
+		{`*`, true},
+		{`"2"`, false},
+		{`"2-000000"`, false},
+	}
+	for _, tt := range tests {
+		if got := ifNoneMatch(tt.header, etag); got != tt.want {
+			t.Errorf("ifNoneMatch(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+type noopViews struct{}
+
+func (noopViews) Add(uint) {}
+
+// TestPostPreconditions 修改文章和恢复修订时的 If-Match、version 校验
+func TestPostPreconditions(t *testing.T) {
+	if err := dto.RegisterValidators(); err != nil {
+		t.Fatalf("register validators: %v", err)
+	}
+	store := newTestStore(t)
+	author := createTestUser(t, store, "alice", rbac.RoleAuthor)
+	post := createTestPost(t, store, author.ID)
+
+	router := newTestRouter(author.ID, rbac.RoleAuthor)
+	h := NewPostHandler(store.Posts, store.Revisions, store.Tags, store.Categories, store.Engagement, noopViews{}, search.NewMemoryIndex())
+	router.PUT("/posts/:id", h.UpdatePost)
+	router.PATCH("/posts/:id", h.PatchPost)
+	router.POST("/posts/:id/revisions/:rev/restore", h.RestoreRevision)
+
+	postPath := fmt.Sprintf("/posts/%d", post.ID)
+	restorePath := fmt.Sprintf("/posts/%d/revisions/1/restore", post.ID)
+	body := func(version *int) map[string]any {
+		b := map[string]any{"title": "Updated", "content": "Updated content"}
+		if version != nil {
+			b["version"] = *version
+		}
+		return b
+	}
+	ptr := func(v int) *int { return &v }
+	ifMatchHeader := func(tag string) http.Header { return http.Header{"If-Match": {tag}} }
+
+	// 按顺序执行，成功的请求会让版本号加1
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        any
+		header      http.Header
+		status      int
+		wantETag    string
+		wantVersion int
+	}{
+		{"put without precondition", http.MethodPut, postPath, body(nil), nil, http.StatusPreconditionRequired, `"1"`, 1},
+		{"put with stale If-Match", http.MethodPut, postPath, body(nil), ifMatchHeader(`"7"`), http.StatusPreconditionFailed, `"1"`, 1},
+		{"put with stale version", http.MethodPut, postPath, body(ptr(7)), nil, http.StatusConflict, `"1"`, 1},
+		{"If-Match takes precedence over version", http.MethodPut, postPath, body(ptr(1)), ifMatchHeader(`"7"`), http.StatusPreconditionFailed, `"1"`, 1},
+		{"put with matching If-Match", http.MethodPut, postPath, body(nil), ifMatchHeader(`"1"`), http.StatusOK, `"2"`, 2},
+		{"put with matching version", http.MethodPut, postPath, body(ptr(2)), nil, http.StatusOK, `"3"`, 3},
+		{"patch without precondition", http.MethodPatch, postPath, map[string]any{"title": "Patched"}, nil, http.StatusPreconditionRequired, `"3"`, 3},
+		{"patch with stale If-Match", http.MethodPatch, postPath, map[string]any{"title": "Patched"}, ifMatchHeader(`"2"`), http.StatusPreconditionFailed, `"3"`, 3},
+		{"patch with matching If-Match", http.MethodPatch, postPath, map[string]any{"title": "Patched"}, ifMatchHeader(`"3"`), http.StatusOK, `"4"`, 4},
+		{"restore without precondition", http.MethodPost, restorePath, nil, nil, http.StatusPreconditionRequired, `"4"`, 4},
+		{"restore with stale version", http.MethodPost, restorePath, map[string]any{"version": 1}, nil, http.StatusConflict, `"4"`, 4},
+		{"restore with matching If-Match", http.MethodPost, restorePath, nil, ifMatchHeader(`"4"`), http.StatusOK, `"5"`, 5},
+		{"restore with matching version", http.MethodPost, restorePath, map[string]any{"version": 5}, nil, http.StatusOK, `"6"`, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			w := doJSON(t, router, tt.method, tt.path, tt.body, tt.header, &body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.status, w.Body.String())
+			}
+			if etag := w.Header().Get("ETag"); etag != tt.wantETag {
+				t.Errorf("ETag = %s, want %s", etag, tt.wantETag)
+			}
+			saved, err := store.Posts.FindByID(t.Context(), post.ID)
+			if err != nil {
+				t.Fatalf("find post: %v", err)
+			}
+			if saved.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", saved.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestGetPostIfNoneMatch(t *testing.T) {
+	store := newTestStore(t)
+	author := createTestUser(t, store, "alice", rbac.RoleAuthor)
+	post := createTestPost(t, store, author.ID)
+
+	router := newTestRouter(0, "")
+	h := NewPostHandler(store.Posts, store.Revisions, store.Tags, store.Categories, store.Engagement, noopViews{}, search.NewMemoryIndex())
+	router.GET("/posts/:id", h.GetPost)
+	path := fmt.Sprintf("/posts/%d", post.ID)
+
+	w := doJSON(t, router, http.MethodGet, path, nil, nil, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q", w.Code, etag)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"current etag", etag, http.StatusNotModified},
+		{"weak current etag", "W/" + etag, http.StatusNotModified},
+		{"stale etag", `"1-0"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, router, http.MethodGet, path, nil, http.Header{"If-None-Match": {tt.header}}, nil)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 response has body %q", w.Body.String())
+			}
+		})
+	}
+
+	// 评论数变化后响应体变化，旧的 ETag 不再匹配
+	createTestComment(t, store, post.ID, author.ID, nil)
+	w = doJSON(t, router, http.MethodGet, path, nil, http.Header{"If-None-Match": {etag}}, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("after new comment: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
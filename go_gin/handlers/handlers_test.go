@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/migrations"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestStore 基于内存 SQLite 创建仓储并执行全部迁移
+func newTestStore(t *testing.T) *repository.Store {
+	t.Helper()
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { model.Close(db) })
+
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	return repository.NewGormStore(db)
+}
+
+// newTestRouter 创建带统一错误处理的路由，userID 不为0时模拟已登录的用户
+func newTestRouter(userID uint, role rbac.Role) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	if userID != 0 {
+		router.Use(func(c *gin.Context) {
+			c.Set("userID", userID)
+			c.Set("role", role)
+			c.Next()
+		})
+	}
+	return router
+}
+
+// createTestUser 创建用户，用户名同时用作邮箱前缀
+func createTestUser(t *testing.T, store *repository.Store, username string, role rbac.Role) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Email: username + "@example.com", Password: "x", Role: string(role)}
+	if err := store.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// createTestPost 创建一篇已发布的文章
+func createTestPost(t *testing.T, store *repository.Store, userID uint) *model.Post {
+	t.Helper()
+	now := time.Now()
+	post := &model.Post{Title: "Hello", Content: "World", UserID: userID,
+		Status: model.PostStatusPublished, PublishedAt: &now}
+	if err := store.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	return post
+}
+
+// doJSON 发送请求并把响应体解析到 out，out 为 nil 时不解析
+func doJSON(t *testing.T, router http.Handler, method, path string, body any, header http.Header, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode response %q: %v", w.Body.String(), err)
+		}
+	}
+	return w
+}
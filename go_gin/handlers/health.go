@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout 单次就绪检查的超时时间
+const readinessTimeout = 2 * time.Second
+
+// HealthCheck 就绪检查项，Check 返回错误表示依赖不可用
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthHandler 存活和就绪检查
+type HealthHandler struct {
+	checks   []HealthCheck
+	draining atomic.Bool
+}
+
+// NewHealthHandler 创建健康检查处理器，checks 为就绪检查项，如数据库连通性、表结构
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// SetDraining 标记服务正在退出，之后就绪检查返回 503，负载均衡不再转发新请求
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Healthz 存活检查，进程能处理请求即返回 200
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz 就绪检查，所有检查项通过时返回 200，否则返回 503 和失败的检查项
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	results := make(gin.H, len(h.checks))
+	for _, check := range h.checks {
+		if err := check.Check(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			results[check.Name] = err.Error()
+			continue
+		}
+		results[check.Name] = "ok"
+	}
+
+	resp := gin.H{"status": "ok", "checks": results}
+	if status != http.StatusOK {
+		resp["status"] = "unavailable"
+	}
+	c.JSON(status, resp)
+}
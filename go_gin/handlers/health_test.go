@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// pingCheck 与 main 中的数据库就绪检查相同
+func pingCheck(t *testing.T) (HealthCheck, func()) {
+	t.Helper()
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return HealthCheck{Name: "database", Check: sqlDB.PingContext}, func() { sqlDB.Close() }
+}
+
+func TestReadyz(t *testing.T) {
+	type readyResponse struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	ok := HealthCheck{Name: "migrations", Check: func(context.Context) error { return nil }}
+
+	t.Run("all checks pass", func(t *testing.T) {
+		database, _ := pingCheck(t)
+		router := newTestRouter(0, "")
+		router.GET("/readyz", NewHealthHandler(database, ok).Readyz)
+
+		var resp readyResponse
+		w := doJSON(t, router, http.MethodGet, "/readyz", nil, nil, &resp)
+		if w.Code != http.StatusOK || resp.Status != "ok" {
+			t.Fatalf("status = %d %+v, want 200 ok", w.Code, resp)
+		}
+		if resp.Checks["database"] != "ok" || resp.Checks["migrations"] != "ok" {
+			t.Errorf("checks = %v, want all ok", resp.Checks)
+		}
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		database, closeDB := pingCheck(t)
+		router := newTestRouter(0, "")
+		router.GET("/readyz", NewHealthHandler(database, ok).Readyz)
+		closeDB()
+
+		var resp readyResponse
+		w := doJSON(t, router, http.MethodGet, "/readyz", nil, nil, &resp)
+		if w.Code != http.StatusServiceUnavailable || resp.Status != "unavailable" {
+			t.Fatalf("status = %d %+v, want 503 unavailable", w.Code, resp)
+		}
+		if resp.Checks["database"] == "ok" || resp.Checks["database"] == "" {
+			t.Errorf("database check = %q, want the ping error", resp.Checks["database"])
+		}
+		if resp.Checks["migrations"] != "ok" {
+			t.Errorf("migrations check = %q, want ok", resp.Checks["migrations"])
+		}
+	})
+
+	t.Run("failing check reports its error", func(t *testing.T) {
+		pending := HealthCheck{Name: "migrations", Check: func(context.Context) error { return errors.New("2 pending migrations") }}
+		router := newTestRouter(0, "")
+		router.GET("/readyz", NewHealthHandler(pending).Readyz)
+
+		var resp readyResponse
+		w := doJSON(t, router, http.MethodGet, "/readyz", nil, nil, &resp)
+		if w.Code != http.StatusServiceUnavailable || resp.Checks["migrations"] != "2 pending migrations" {
+			t.Errorf("status = %d %+v, want 503 with the check error", w.Code, resp)
+		}
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		h := NewHealthHandler(ok)
+		router := newTestRouter(0, "")
+		router.GET("/readyz", h.Readyz)
+		router.GET("/healthz", h.Healthz)
+		h.SetDraining()
+
+		var resp readyResponse
+		if w := doJSON(t, router, http.MethodGet, "/readyz", nil, nil, &resp); w.Code != http.StatusServiceUnavailable || resp.Status != "draining" {
+			t.Errorf("readyz = %d %+v, want 503 draining", w.Code, resp)
+		}
+		// 退出期间存活检查仍然通过，进程不会被重启
+		if w := doJSON(t, router, http.MethodGet, "/healthz", nil, nil, nil); w.Code != http.StatusOK {
+			t.Errorf("healthz = %d, want 200", w.Code)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// parsePageRequest 解析 limit、page、cursor、sort、order 查询参数
+func parsePageRequest(c *gin.Context) (repository.PageRequest, error) {
+	req := repository.PageRequest{
+		Cursor: c.Query("cursor"),
+		SortBy: c.Query("sort"),
+		Order:  c.Query("order"),
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return req, fmt.Errorf("invalid limit: %s", v)
+		}
+		req.Limit = limit
+	}
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page <= 0 {
+			return req, fmt.Errorf("invalid page: %s", v)
+		}
+		if req.Cursor != "" {
+			return req, fmt.Errorf("page and cursor cannot be used together")
+		}
+		req.Page = page
+	}
+	return req, nil
+}
+
+// listFilter 两个列表接口共用的作者和时间范围过滤
+type listFilter struct {
+	userID uint
+	from   *time.Time
+	to     *time.Time
+}
+
+// parseListFilter 解析 user_id、from、to 查询参数
+// 时间支持 RFC3339 或 2006-01-02，仅有日期的 to 包含当天全部时间
+func parseListFilter(c *gin.Context) (listFilter, error) {
+	var f listFilter
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return f, fmt.Errorf("invalid user_id: %s", v)
+		}
+		f.userID = uint(id)
+	}
+	if v := c.Query("from"); v != "" {
+		t, _, err := parseTimeParam(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid from: %s", v)
+		}
+		f.from = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, dateOnly, err := parseTimeParam(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid to: %s", v)
+		}
+		if dateOnly {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		f.to = &t
+	}
+	if f.from != nil && f.to != nil && f.to.Before(*f.from) {
+		return f, fmt.Errorf("to must not be before from")
+	}
+	return f, nil
+}
+
+func parseTimeParam(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	return t, true, err
+}
+
+// pageResponse 统一的分页响应结构，key 为列表字段名，items 为转换后的列表
+func pageResponse[T any](key string, items any, page *repository.Page[T]) gin.H {
+	resp := gin.H{
+		key:           items,
+		"count":       len(page.Items),
+		"total":       page.Total,
+		"limit":       page.Limit,
+		"next_cursor": page.NextCursor,
+	}
+	if page.Page > 0 {
+		resp["page"] = page.Page
+	}
+	return resp
+}
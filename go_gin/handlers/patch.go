@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/mergepatch"
+)
+
+// mergePatchContentType RFC 7396 规定的媒体类型，也接受 application/json
+const mergePatchContentType = "application/merge-patch+json"
+
+// bindMergePatch 把请求体作为 JSON Merge Patch 合并到 current，结果写入 dst 并按 binding 标签校验
+// current 和 dst 为同一种请求结构，current 由资源当前的值填充；补丁只能包含 allowed 中的字段。
+// 返回补丁中出现的字段，值为 null 的字段表示清除
+func bindMergePatch(c *gin.Context, current, dst any, allowed ...string) (map[string]json.RawMessage, *apperr.Error) {
+	if ct := c.ContentType(); ct != mergePatchContentType && ct != binding.MIMEJSON {
+		return nil, apperr.New(apperr.CodeMediaType, "Content-Type must be "+mergePatchContentType)
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, apperr.Validation("Failed to read request body")
+	}
+	fields, err := mergepatch.Fields(body)
+	if err != nil {
+		if errors.Is(err, mergepatch.ErrNotObject) {
+			return nil, apperr.Validation("Merge patch must be a JSON object")
+		}
+		return nil, apperr.FromBinding(err)
+	}
+
+	var unknown []apperr.FieldError
+	for name := range fields {
+		if !slices.Contains(allowed, name) {
+			unknown = append(unknown, apperr.FieldError{Field: name, Rule: "patchable", Message: "cannot be updated"})
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Slice(unknown, func(i, j int) bool { return unknown[i].Field < unknown[j].Field })
+		return nil, apperr.Validation("Validation failed", unknown...)
+	}
+
+	// 合并后的完整文档按普通请求校验，补丁把必填字段设为 null 时同样会校验失败
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, apperr.Internal("Failed to encode resource", err)
+	}
+	merged, err := mergepatch.Apply(doc, body)
+	if err != nil {
+		return nil, apperr.FromBinding(err)
+	}
+	if err := json.Unmarshal(merged, dst); err != nil {
+		return nil, apperr.FromBinding(err)
+	}
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		return nil, apperr.FromBinding(err)
+	}
+	return fields, nil
+}
@@ -1,154 +1,560 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
 	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"github.com/zhanglegen/go_task/go_gin/search"
+	"github.com/zhanglegen/go_task/go_gin/utils"
 )
 
+// PostHandler 文章相关处理函数
+type PostHandler struct {
+	posts      repository.PostRepository
+	revisions  repository.RevisionRepository
+	tags       repository.TagRepository
+	categories repository.CategoryRepository
+	engagement repository.EngagementRepository
+	views      ViewRecorder
+	searcher   search.Searcher
+}
+
+// NewPostHandler 创建文章处理器，searcher 负责在文章增删改时同步搜索索引，views 记录文章详情的浏览数
+func NewPostHandler(posts repository.PostRepository, revisions repository.RevisionRepository, tags repository.TagRepository,
+	categories repository.CategoryRepository, engagement repository.EngagementRepository, views ViewRecorder,
+	searcher search.Searcher) *PostHandler {
+	return &PostHandler{
+		posts:      posts,
+		revisions:  revisions,
+		tags:       tags,
+		categories: categories,
+		engagement: engagement,
+		views:      views,
+		searcher:   searcher,
+	}
+}
+
 // CreatePost 创建文章
-func CreatePost(c *gin.Context) {
+func (h *PostHandler) CreatePost(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperr.Unauthorized("User not authenticated"))
 		return
 	}
 
-	var post model.Post
-	if err := c.ShouldBindJSON(&post); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.PostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
 		return
 	}
 
-	post.UserID = userID.(uint)
-	if err := model.DB.Create(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
+	post := req.Model(userID.(uint))
+	if err := req.ApplyStatus(post, time.Now().UTC()); err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+	if err := h.applyTaxonomy(c, &req, post); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := h.posts.Create(c.Request.Context(), post); err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
 		return
 	}
+	h.syncIndex(c, post)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Post created successfully",
-		"post":    post,
+		"post":    dto.NewPostResponse(post, markup.FormatMarkdown),
 	})
 }
 
-// GetPosts 获取所有文章
-func GetPosts(c *gin.Context) {
-	var posts []model.Post
+// GetPosts 分页获取文章列表
+// 支持 limit、page/cursor 分页，sort=created_at|updated_at|comment_count、order=asc|desc 排序，
+// 以及 user_id、tag、category、status、from、to 过滤，tag 和 category 可以传名称或 Slug；
+// format=markdown|html|text 指定内容格式，默认返回 Markdown 原文；
+// 匿名用户只能看到已发布的文章，登录用户还能看到自己的全部文章，版主和管理员可以看到所有文章
+func (h *PostHandler) GetPosts(c *gin.Context) {
+	pageReq, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+	format, err := markup.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+	f, err := parseListFilter(c)
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
 
-	// 预加载用户信息
-	if err := model.DB.Preload("User").Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+	filter := repository.PostFilter{
+		Visibility:  postVisibility(c),
+		Status:      c.Query("status"),
+		UserID:      f.userID,
+		CreatedFrom: f.from,
+		CreatedTo:   f.to,
+	}
+	switch filter.Status {
+	case "", model.PostStatusDraft, model.PostStatusScheduled, model.PostStatusPublished, model.PostStatusArchived:
+	default:
+		c.Error(apperr.Validation("status must be one of draft, scheduled, published, archived"))
+		return
+	}
+	if v, ok := c.GetQuery("tag"); ok {
+		if filter.TagSlug = model.Slugify(v); filter.TagSlug == "" {
+			c.Error(apperr.Validation("Invalid tag: " + v))
+			return
+		}
+	}
+	if v, ok := c.GetQuery("category"); ok {
+		if filter.CategorySlug = model.Slugify(v); filter.CategorySlug == "" {
+			c.Error(apperr.Validation("Invalid category: " + v))
+			return
+		}
+	}
+	page, err := h.posts.List(c.Request.Context(), filter, pageReq)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidPage) {
+			c.Error(apperr.Validation(err.Error()))
+			return
+		}
+		c.Error(apperr.Internal("Failed to fetch posts", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"posts": posts,
-		"count": len(posts),
-	})
+	c.JSON(http.StatusOK, pageResponse("posts", dto.NewPostResponses(page.Items, format), page))
 }
 
-// GetPost 获取单个文章详情
-func GetPost(c *gin.Context) {
+// GetPost 获取单个文章详情，未发布的文章对作者和管理员以外的用户返回 404
+// format 与 GetPosts 相同，同时作用于文章和评论的内容。
+// 登录用户的响应中附带自己是否点赞、收藏了该文章。响应带 ETag，If-None-Match 命中时返回 304，同样计入浏览数
+func (h *PostHandler) GetPost(c *gin.Context) {
 	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		c.Error(apperr.Validation("Invalid post ID"))
+		return
+	}
+	format, err := markup.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
 		return
 	}
 
-	var post model.Post
-	// 预加载用户和评论信息
-	if err := model.DB.Preload("User").Preload("Comments.User").First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	post, err := h.posts.FindDetail(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if !canViewPost(c, post) {
+		c.Error(apperr.NotFound("Post not found"))
 		return
 	}
+	h.views.Add(post.ID)
 
-	c.JSON(http.StatusOK, gin.H{"post": post})
+	resp := gin.H{"post": dto.NewPostResponse(post, format)}
+	if userID := c.GetUint("userID"); userID != 0 {
+		state, err := h.engagement.State(c.Request.Context(), userID, post.ID)
+		if err != nil {
+			c.Error(apperr.Internal("Failed to fetch post", err))
+			return
+		}
+		resp["liked"] = state.Liked
+		resp["bookmarked"] = state.Bookmarked
+	}
+	writeConditionalJSON(c, post.Version, resp)
 }
 
-// UpdatePost 更新文章
-func UpdatePost(c *gin.Context) {
+// UpdatePost 更新文章，需要 If-Match 头或请求中的 version 与文章当前版本一致
+func (h *PostHandler) UpdatePost(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperr.Unauthorized("User not authenticated"))
 		return
 	}
 
 	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		c.Error(apperr.Validation("Invalid post ID"))
 		return
 	}
 
-	var post model.Post
-	if err := model.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	post, err := h.posts.FindByID(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
 		return
 	}
 
-	// 检查是否是文章作者
-	if post.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own posts"})
+	// 作者本人或版主、管理员可以编辑
+	if !rbac.CanModify(middleware.CurrentRole(c), userID.(uint), post.UserID, rbac.PermPostEditAny) {
+		c.Error(apperr.Forbidden("You can only update your own posts"))
 		return
 	}
 
-	var updateData struct {
-		Title   string `json:"title"`
-		Content string `json:"content"`
+	var req dto.PostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
 	}
-
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := checkPostVersion(c, post, req.Version, true); err != nil {
+		c.Error(err)
 		return
 	}
 
 	// 更新文章
-	post.Title = updateData.Title
-	post.Content = updateData.Content
+	req.Apply(post)
+	if err := req.ApplyStatus(post, time.Now().UTC()); err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+	if err := h.applyTaxonomy(c, &req, post); err != nil {
+		c.Error(err)
+		return
+	}
 
-	if err := model.DB.Save(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+	revision := &model.PostRevision{EditorID: userID.(uint)}
+	if err := h.posts.Update(c.Request.Context(), post, revision); err != nil {
+		c.Error(h.saveError(c, post.ID, err))
 		return
 	}
+	h.syncIndex(c, post)
+	c.Header("ETag", versionETag(post.Version))
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Post updated successfully",
-		"post":    post,
+		"message":  "Post updated successfully",
+		"post":     dto.NewPostResponse(post, markup.FormatMarkdown),
+		"revision": revision.Number,
 	})
 }
 
-// DeletePost 删除文章
-func DeletePost(c *gin.Context) {
+// PatchPost 按 JSON Merge Patch 部分更新文章，只写入值发生变化的字段
+// 补丁与文章当前内容合并后按 PUT 的规则校验，版本检查与 UpdatePost 相同；tags、category_ids 为 null 表示清空
+func (h *PostHandler) PatchPost(c *gin.Context) {
+	userID := c.GetUint("userID")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid post ID"))
+		return
+	}
+
+	post, err := h.posts.FindByID(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return
+	}
+	if !rbac.CanModify(middleware.CurrentRole(c), userID, post.UserID, rbac.PermPostEditAny) {
+		c.Error(apperr.Forbidden("You can only update your own posts"))
+		return
+	}
+
+	base := dto.NewPostRequest(post)
+	var req dto.PostRequest
+	fields, appErr := bindMergePatch(c, &base, &req, dto.PatchablePostFields...)
+	if appErr != nil {
+		c.Error(appErr)
+		return
+	}
+	has := func(name string) bool {
+		_, ok := fields[name]
+		return ok
+	}
+	// 版本号只认补丁中的值，合并结果里的是文章当前版本
+	if !has("version") {
+		req.Version = nil
+	}
+	if err := checkPostVersion(c, post, req.Version, true); err != nil {
+		c.Error(err)
+		return
+	}
+
+	old := *post
+	req.Apply(post)
+	if has("status") || has("published_at") {
+		if err := req.ApplyStatus(post, time.Now().UTC()); err != nil {
+			c.Error(apperr.Validation(err.Error()))
+			return
+		}
+	}
+	// 补丁中没有的关联保持不变；null 在合并时被删除，按空数组清空关联
+	switch {
+	case !has("tags"):
+		req.Tags = nil
+	case req.Tags == nil:
+		req.Tags = []string{}
+	}
+	switch {
+	case !has("category_ids"):
+		req.CategoryIDs = nil
+	case req.CategoryIDs == nil:
+		req.CategoryIDs = []uint{}
+	}
+	if err := h.applyTaxonomy(c, &req, post); err != nil {
+		c.Error(err)
+		return
+	}
+
+	changed := changedPostFields(&old, post)
+	if len(changed) == 0 {
+		c.Header("ETag", versionETag(post.Version))
+		c.JSON(http.StatusOK, gin.H{"message": "Post not modified", "post": dto.NewPostResponse(post, markup.FormatMarkdown)})
+		return
+	}
+	revision := &model.PostRevision{EditorID: userID}
+	if err := h.posts.Update(c.Request.Context(), post, revision, changed...); err != nil {
+		c.Error(h.saveError(c, post.ID, err))
+		return
+	}
+	h.syncIndex(c, post)
+	c.Header("ETag", versionETag(post.Version))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Post updated successfully",
+		"post":     dto.NewPostResponse(post, markup.FormatMarkdown),
+		"revision": revision.Number,
+	})
+}
+
+// DeletePost 删除文章，需要 If-Match 头或查询参数 version 与文章当前版本一致
+func (h *PostHandler) DeletePost(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apperr.Unauthorized("User not authenticated"))
 		return
 	}
 
 	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		c.Error(apperr.Validation("Invalid post ID"))
 		return
 	}
 
-	var post model.Post
-	if err := model.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	post, err := h.posts.FindByID(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
 		return
 	}
 
-	// 检查是否是文章作者
-	if post.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own posts"})
+	// 作者本人或版主、管理员可以删除
+	if !rbac.CanModify(middleware.CurrentRole(c), userID.(uint), post.UserID, rbac.PermPostDeleteAny) {
+		c.Error(apperr.Forbidden("You can only delete your own posts"))
 		return
 	}
 
-	if err := model.DB.Delete(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+	var version *int
+	if v, ok := c.GetQuery("version"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.Error(apperr.Validation("Invalid version"))
+			return
+		}
+		version = &n
+	}
+	if err := checkPostVersion(c, post, version, true); err != nil {
+		c.Error(err)
 		return
 	}
 
+	if err := h.posts.Delete(c.Request.Context(), post); err != nil {
+		c.Error(h.saveError(c, post.ID, err))
+		return
+	}
+	if err := h.searcher.Remove(c.Request.Context(), post.ID); err != nil {
+		utils.Logger(c.Request.Context()).Error("Failed to remove post from search index", "post_id", post.ID, "error", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
-}
\ No newline at end of file
+}
+
+// SearchPosts 全文搜索文章标题和内容，按相关度排序并返回高亮摘要
+func (h *PostHandler) SearchPosts(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.Error(apperr.Validation("Query parameter q is required"))
+		return
+	}
+
+	pageReq, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(apperr.Validation(err.Error()))
+		return
+	}
+	if pageReq.Cursor != "" || pageReq.SortBy != "" {
+		c.Error(apperr.Validation("Search results only support limit and page"))
+		return
+	}
+	limit := pageReq.Limit
+	if limit == 0 {
+		limit = repository.DefaultPageLimit
+	}
+	limit = min(limit, repository.MaxPageLimit)
+	page := max(pageReq.Page, 1)
+
+	hits, total, err := h.searcher.Search(c.Request.Context(), query, limit, (page-1)*limit)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to search posts", err))
+		return
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.PostID
+	}
+	posts, err := h.posts.FindByIDs(c.Request.Context(), ids)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch posts", err))
+		return
+	}
+	byID := make(map[uint]model.Post, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = p
+	}
+
+	// 按相关度顺序组装结果，跳过在搜索之后才被删除或撤回发布的文章，总数同时扣除
+	results := make([]gin.H, 0, len(hits))
+	for _, hit := range hits {
+		post, ok := byID[hit.PostID]
+		if !ok || !post.Published() {
+			total--
+			continue
+		}
+		results = append(results, gin.H{
+			"post":    dto.NewPostResponse(&post, markup.FormatMarkdown),
+			"score":   hit.Score,
+			"title":   search.Highlight(post.Title, query),
+			"snippet": search.Snippet(markup.PlainText(post.ContentHTML), query),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"results": results,
+		"count":   len(results),
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// applyTaxonomy 按请求设置文章的标签和分类，请求中省略的字段保持不变
+// 分类必须已经存在，先校验分类再创建缺少的标签，校验失败时不会留下多余的标签
+func (h *PostHandler) applyTaxonomy(c *gin.Context, req *dto.PostRequest, post *model.Post) error {
+	ctx := c.Request.Context()
+	if ids := req.UniqueCategoryIDs(); ids != nil {
+		found, err := h.categories.FindByIDs(ctx, ids)
+		if err != nil {
+			return apperr.FromDB(err, "Category")
+		}
+		if len(found) != len(ids) {
+			return apperr.Validation("Validation failed", apperr.FieldError{
+				Field: "category_ids", Rule: "exists", Message: "contains unknown category IDs",
+			})
+		}
+		post.Categories = found
+	}
+	if tags := req.TagModels(); tags != nil {
+		found, err := h.tags.FindOrCreate(ctx, tags)
+		if err != nil {
+			return apperr.FromDB(err, "Tag")
+		}
+		post.Tags = found
+	}
+	return nil
+}
+
+// postVisibility 当前访问者在文章列表中的可见范围
+func postVisibility(c *gin.Context) repository.Visibility {
+	if middleware.CurrentRole(c).Can(rbac.PermPostViewAny) {
+		return repository.Visibility{All: true}
+	}
+	return repository.Visibility{UserID: c.GetUint("userID")}
+}
+
+// canViewPost 已发布的文章所有人可见，其他状态只有作者本人、版主和管理员可见
+func canViewPost(c *gin.Context, post *model.Post) bool {
+	if post.Published() {
+		return true
+	}
+	userID := c.GetUint("userID")
+	return userID != 0 && rbac.CanModify(middleware.CurrentRole(c), userID, post.UserID, rbac.PermPostViewAny)
+}
+
+// saveError 转换更新或删除文章时的错误，版本冲突时查询最新版本一并返回
+func (h *PostHandler) saveError(c *gin.Context, postID uint, err error) *apperr.Error {
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		return apperr.FromDB(err, "Post")
+	}
+	current, findErr := h.posts.FindByID(c.Request.Context(), postID)
+	if findErr != nil {
+		return apperr.FromDB(findErr, "Post")
+	}
+	return versionConflict(c, current.Version)
+}
+
+// changedPostFields 比较修改前后的文章，返回值发生变化的模型字段名
+func changedPostFields(old, post *model.Post) []string {
+	var changed []string
+	if post.Title != old.Title {
+		changed = append(changed, "Title")
+	}
+	if post.Content != old.Content {
+		changed = append(changed, "Content", "ContentHTML")
+	}
+	if post.Status != old.Status {
+		changed = append(changed, "Status")
+	}
+	if !equalTime(post.PublishedAt, old.PublishedAt) {
+		changed = append(changed, "PublishedAt")
+	}
+	if !sameIDs(post.Tags, old.Tags, func(t model.Tag) uint { return t.ID }) {
+		changed = append(changed, "Tags")
+	}
+	if !sameIDs(post.Categories, old.Categories, func(c model.Category) uint { return c.ID }) {
+		changed = append(changed, "Categories")
+	}
+	return changed
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// sameIDs 两组关联的ID集合是否相同，不考虑顺序
+func sameIDs[T any](a, b []T, id func(T) uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[uint]bool, len(a))
+	for _, v := range a {
+		seen[id(v)] = true
+	}
+	for _, v := range b {
+		if !seen[id(v)] {
+			return false
+		}
+	}
+	return true
+}
+
+// syncIndex 更新搜索索引，失败只记录日志，数据库仍是唯一数据源
+func (h *PostHandler) syncIndex(c *gin.Context, post *model.Post) {
+	if err := h.searcher.Index(c.Request.Context(), post); err != nil {
+		utils.Logger(c.Request.Context()).Error("Failed to update search index", "post_id", post.ID, "error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/search"
+)
+
+// TestDraftVisibility 他人的草稿只有版主和管理员可以查看，与编辑和修订历史的权限一致
+func TestDraftVisibility(t *testing.T) {
+	store := newTestStore(t)
+	author := createTestUser(t, store, "alice", rbac.RoleAuthor)
+	now := time.Now()
+	draft := &model.Post{Title: "Draft", Content: "WIP", UserID: author.ID, Status: model.PostStatusDraft, PublishedAt: &now}
+	if err := store.Posts.Create(context.Background(), draft); err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+
+	tests := []struct {
+		role   rbac.Role
+		status int
+		listed bool
+	}{
+		{rbac.RoleAdmin, http.StatusOK, true},
+		{rbac.RoleModerator, http.StatusOK, true},
+		{rbac.RoleAuthor, http.StatusNotFound, false},
+		{rbac.RoleReader, http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			viewer := createTestUser(t, store, "viewer_"+string(tt.role), tt.role)
+			router := newTestRouter(viewer.ID, tt.role)
+			h := NewPostHandler(store.Posts, store.Revisions, store.Tags, store.Categories, store.Engagement, noopViews{}, search.NewMemoryIndex())
+			router.GET("/posts", h.GetPosts)
+			router.GET("/posts/:id", h.GetPost)
+			router.GET("/posts/:id/revisions", h.ListRevisions)
+
+			if w := doJSON(t, router, http.MethodGet, fmt.Sprintf("/posts/%d", draft.ID), nil, nil, nil); w.Code != tt.status {
+				t.Errorf("GET draft: status = %d, want %d", w.Code, tt.status)
+			}
+
+			var list struct {
+				Posts []struct {
+					ID uint `json:"id"`
+				} `json:"posts"`
+			}
+			doJSON(t, router, http.MethodGet, "/posts", nil, nil, &list)
+			listed := false
+			for _, p := range list.Posts {
+				listed = listed || p.ID == draft.ID
+			}
+			if listed != tt.listed {
+				t.Errorf("draft listed = %v, want %v", listed, tt.listed)
+			}
+
+			// 能查看修订历史（编辑权限）的角色也能查看草稿本身
+			w := doJSON(t, router, http.MethodGet, fmt.Sprintf("/posts/%d/revisions", draft.ID), nil, nil, nil)
+			if canEdit := w.Code == http.StatusOK; canEdit && tt.status != http.StatusOK {
+				t.Errorf("role can list revisions of a draft it cannot view")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+)
+
+// ListRevisions 按修订号从新到旧列出文章的修订历史
+func (h *PostHandler) ListRevisions(c *gin.Context) {
+	post, ok := h.editablePost(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.revisions.ListByPost(c.Request.Context(), post.ID)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch revisions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": dto.NewRevisionSummaries(revisions),
+		"count":     len(revisions),
+	})
+}
+
+// GetRevision 获取单个修订的完整内容
+func (h *PostHandler) GetRevision(c *gin.Context) {
+	post, ok := h.editablePost(c)
+	if !ok {
+		return
+	}
+	revision, ok := h.findRevision(c, post.ID, c.Param("rev"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revision": dto.NewRevisionResponse(revision)})
+}
+
+// DiffRevisions 按行比较两个修订，from、to 为修订号，两者的先后顺序不限
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	post, ok := h.editablePost(c)
+	if !ok {
+		return
+	}
+	if c.Query("from") == "" || c.Query("to") == "" {
+		c.Error(apperr.Validation("Query parameters from and to are required"))
+		return
+	}
+	from, ok := h.findRevision(c, post.ID, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := h.findRevision(c, post.ID, c.Query("to"))
+	if !ok {
+		return
+	}
+
+	diff, err := dto.NewRevisionDiff(from, to)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to compare revisions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"diff": diff})
+}
+
+// RestoreRevision 把文章的标题和内容恢复为指定修订，恢复结果作为一个新修订保存，历史记录不会丢失
+// 与修改文章一样需要 If-Match 头或请求体中的 version，只在文章仍是该版本时恢复
+func (h *PostHandler) RestoreRevision(c *gin.Context) {
+	post, ok := h.editablePost(c)
+	if !ok {
+		return
+	}
+	old, ok := h.findRevision(c, post.ID, c.Param("rev"))
+	if !ok {
+		return
+	}
+
+	var req dto.RestoreRevisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperr.FromBinding(err))
+			return
+		}
+	}
+	if err := checkPostVersion(c, post, req.Version, true); err != nil {
+		c.Error(err)
+		return
+	}
+
+	post.Title = old.Title
+	post.Content = old.Content
+	post.ContentHTML = markup.Render(old.Content)
+	revision := &model.PostRevision{EditorID: c.GetUint("userID"), RestoredFrom: &old.Number}
+	if err := h.posts.Update(c.Request.Context(), post, revision); err != nil {
+		c.Error(h.saveError(c, post.ID, err))
+		return
+	}
+	h.syncIndex(c, post)
+	c.Header("ETag", versionETag(post.Version))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Revision restored successfully",
+		"post":     dto.NewPostResponse(post, markup.FormatMarkdown),
+		"revision": revision.Number,
+	})
+}
+
+// editablePost 查询路径中的文章，只有作者本人或版主、管理员可以访问修订历史
+// 返回 false 时已经记录了错误
+func (h *PostHandler) editablePost(c *gin.Context) (*model.Post, bool) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid post ID"))
+		return nil, false
+	}
+
+	post, err := h.posts.FindByID(c.Request.Context(), uint(postID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Post"))
+		return nil, false
+	}
+	if !rbac.CanModify(middleware.CurrentRole(c), c.GetUint("userID"), post.UserID, rbac.PermPostEditAny) {
+		c.Error(apperr.Forbidden("You can only access revisions of your own posts"))
+		return nil, false
+	}
+	return post, true
+}
+
+// findRevision 按修订号查询文章的修订，返回 false 时已经记录了错误
+func (h *PostHandler) findRevision(c *gin.Context, postID uint, number string) (*model.PostRevision, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		c.Error(apperr.Validation("Invalid revision number: " + number))
+		return nil, false
+	}
+
+	revision, err := h.revisions.FindByNumber(c.Request.Context(), postID, n)
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Revision"))
+		return nil, false
+	}
+	return revision, true
+}
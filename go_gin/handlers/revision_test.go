@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/search"
+)
+
+// TestRestoreRevision 恢复修订会保存为新的修订并增加版本号，原有的修订保持不变
+func TestRestoreRevision(t *testing.T) {
+	if err := dto.RegisterValidators(); err != nil {
+		t.Fatalf("register validators: %v", err)
+	}
+	store := newTestStore(t)
+	author := createTestUser(t, store, "alice", rbac.RoleAuthor)
+	post := createTestPost(t, store, author.ID)
+
+	router := newTestRouter(author.ID, rbac.RoleAuthor)
+	h := NewPostHandler(store.Posts, store.Revisions, store.Tags, store.Categories, store.Engagement, noopViews{}, search.NewMemoryIndex())
+	router.PUT("/posts/:id", h.UpdatePost)
+	router.GET("/posts/:id/revisions", h.ListRevisions)
+	router.GET("/posts/:id/revisions/diff", h.DiffRevisions)
+	router.GET("/posts/:id/revisions/:rev", h.GetRevision)
+	router.POST("/posts/:id/revisions/:rev/restore", h.RestoreRevision)
+	base := fmt.Sprintf("/posts/%d", post.ID)
+
+	update := map[string]any{"title": "Rewritten", "content": "New line\nWorld", "version": 1}
+	if w := doJSON(t, router, http.MethodPut, base, update, nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var diff struct {
+		Diff dto.RevisionDiff `json:"diff"`
+	}
+	if w := doJSON(t, router, http.MethodGet, base+"/revisions/diff?from=1&to=2", nil, nil, &diff); w.Code != http.StatusOK {
+		t.Fatalf("diff: status = %d", w.Code)
+	}
+	wantUnified := "--- revision 1\n+++ revision 2\n@@ -1 +1,2 @@\n+New line\n World\n"
+	if diff.Diff.Unified != wantUnified {
+		t.Errorf("unified =\n%s\nwant\n%s", diff.Diff.Unified, wantUnified)
+	}
+
+	var restored struct {
+		Post     dto.PostResponse `json:"post"`
+		Revision int              `json:"revision"`
+	}
+	w := doJSON(t, router, http.MethodPost, base+"/revisions/1/restore", map[string]any{"version": 2}, nil, &restored)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: status = %d, body %s", w.Code, w.Body.String())
+	}
+	if restored.Revision != 3 || restored.Post.Version != 3 {
+		t.Errorf("restore created revision %d with version %d, want revision 3 version 3", restored.Revision, restored.Post.Version)
+	}
+	if restored.Post.Title != post.Title || restored.Post.Content != post.Content {
+		t.Errorf("restored post = %q %q, want %q %q", restored.Post.Title, restored.Post.Content, post.Title, post.Content)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"3"` {
+		t.Errorf("ETag = %s, want \"3\"", etag)
+	}
+
+	var list struct {
+		Revisions []dto.RevisionSummary `json:"revisions"`
+	}
+	doJSON(t, router, http.MethodGet, base+"/revisions", nil, nil, &list)
+	if len(list.Revisions) != 3 {
+		t.Fatalf("revisions = %d, want 3", len(list.Revisions))
+	}
+	newest := list.Revisions[0]
+	if newest.Number != 3 || newest.RestoredFrom == nil || *newest.RestoredFrom != 1 || newest.EditorID != author.ID {
+		t.Errorf("newest revision = %+v, want number 3 restored from 1 by the author", newest)
+	}
+
+	// 被覆盖的修订仍然保留
+	var rev2 struct {
+		Revision dto.RevisionResponse `json:"revision"`
+	}
+	doJSON(t, router, http.MethodGet, base+"/revisions/2", nil, nil, &rev2)
+	if rev2.Revision.Title != "Rewritten" || rev2.Revision.Content != "New line\nWorld" {
+		t.Errorf("revision 2 = %+v, want the rewritten content", rev2.Revision)
+	}
+
+	// 恢复后的内容与修订1相同
+	var same struct {
+		Diff dto.RevisionDiff `json:"diff"`
+	}
+	doJSON(t, router, http.MethodGet, base+"/revisions/diff?from=1&to=3", nil, nil, &same)
+	if same.Diff.Unified != "" || same.Diff.Title != nil {
+		t.Errorf("diff 1..3 = %+v, want no changes", same.Diff)
+	}
+
+	// 使用恢复前的版本号再次恢复会冲突
+	if w := doJSON(t, router, http.MethodPost, base+"/revisions/2/restore", map[string]any{"version": 2}, nil, nil); w.Code != http.StatusConflict {
+		t.Errorf("restore with stale version: status = %d, want 409", w.Code)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"github.com/zhanglegen/go_task/go_gin/search"
+)
+
+// TestSearchPostsTotal 索引建立后被删除或撤回发布的文章不出现在结果中，也不计入总数
+func TestSearchPostsTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		searcher func(store *repository.Store) search.Searcher
+	}{
+		{"index filters stale entries", func(store *repository.Store) search.Searcher {
+			s, err := search.New(context.Background(), search.EngineMemory, model.DriverSQLite, nil,
+				func(ctx context.Context, fn func([]model.Post) error) error {
+					return store.Posts.EachBatch(ctx, 100, fn)
+				}, store.Posts.PublishedIDs)
+			if err != nil {
+				t.Fatalf("new searcher: %v", err)
+			}
+			return s
+		}},
+		{"handler drops stale hits", func(store *repository.Store) search.Searcher {
+			idx := search.NewMemoryIndex()
+			posts, err := store.Posts.FindByIDs(context.Background(), []uint{1, 2, 3, 4})
+			if err != nil {
+				t.Fatalf("find posts: %v", err)
+			}
+			for i := range posts {
+				idx.Index(context.Background(), &posts[i])
+			}
+			return idx
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			ctx := context.Background()
+			author := createTestUser(t, store, "alice", rbac.RoleAuthor)
+			posts := make([]*model.Post, 4)
+			for i := range posts {
+				posts[i] = createTestPost(t, store, author.ID)
+				posts[i].Content = "gopher"
+				if err := store.Posts.Update(ctx, posts[i], &model.PostRevision{EditorID: author.ID}, "Content"); err != nil {
+					t.Fatalf("update post: %v", err)
+				}
+			}
+			searcher := tt.searcher(store)
+
+			// 模拟其他实例的修改：数据库已变化，本进程的索引没有更新
+			if err := store.Posts.Delete(ctx, posts[0]); err != nil {
+				t.Fatalf("delete post: %v", err)
+			}
+			posts[1].Status, posts[1].PublishedAt = model.PostStatusDraft, nil
+			if err := store.Posts.Update(ctx, posts[1], &model.PostRevision{EditorID: author.ID}, "Status", "PublishedAt"); err != nil {
+				t.Fatalf("unpublish post: %v", err)
+			}
+
+			router := newTestRouter(0, "")
+			h := NewPostHandler(store.Posts, store.Revisions, store.Tags, store.Categories, store.Engagement, noopViews{}, searcher)
+			router.GET("/posts/search", h.SearchPosts)
+
+			var resp struct {
+				Results []struct {
+					Post struct {
+						ID uint `json:"id"`
+					} `json:"post"`
+				} `json:"results"`
+				Count int   `json:"count"`
+				Total int64 `json:"total"`
+			}
+			w := doJSON(t, router, http.MethodGet, "/posts/search?q=gopher&limit=10", nil, nil, &resp)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if resp.Count != 2 || resp.Total != 2 {
+				t.Errorf("count = %d, total = %d, want 2 and 2", resp.Count, resp.Total)
+			}
+			for _, r := range resp.Results {
+				if r.Post.ID == posts[0].ID || r.Post.ID == posts[1].ID {
+					t.Errorf("stale post %d in results", r.Post.ID)
+				}
+			}
+		})
+	}
+}
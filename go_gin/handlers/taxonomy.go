@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// 标签云默认和最多返回的标签数
+const (
+	defaultTagCloudLimit = 100
+	maxTagCloudLimit     = 500
+)
+
+// TaxonomyHandler 标签和分类处理函数
+type TaxonomyHandler struct {
+	tags       repository.TagRepository
+	categories repository.CategoryRepository
+}
+
+// NewTaxonomyHandler 创建标签和分类处理器
+func NewTaxonomyHandler(tags repository.TagRepository, categories repository.CategoryRepository) *TaxonomyHandler {
+	return &TaxonomyHandler{tags: tags, categories: categories}
+}
+
+// GetTags 标签云，按文章数从多到少返回标签、文章数和显示权重，支持 limit 参数
+func (h *TaxonomyHandler) GetTags(c *gin.Context) {
+	limit := defaultTagCloudLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.Error(apperr.Validation("Invalid limit: " + v))
+			return
+		}
+		limit = min(n, maxTagCloudLimit)
+	}
+
+	tags, err := h.tags.Cloud(c.Request.Context(), limit)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch tags", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": dto.NewTagCloud(tags), "count": len(tags)})
+}
+
+// CreateTag 创建标签（管理员、版主），Slug 已存在时返回冲突
+func (h *TaxonomyHandler) CreateTag(c *gin.Context) {
+	var req dto.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	var tag model.Tag
+	req.Apply(&tag)
+	if err := h.tags.Create(c.Request.Context(), &tag); err != nil {
+		c.Error(apperr.FromDB(err, "Tag"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tag created successfully",
+		"tag":     dto.NewTagResponse(&tag),
+	})
+}
+
+// UpdateTag 重命名标签（管理员、版主），Slug 随名称变化，与其他标签冲突时返回冲突
+func (h *TaxonomyHandler) UpdateTag(c *gin.Context) {
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid tag ID"))
+		return
+	}
+
+	var req dto.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	tag, err := h.tags.FindByID(c.Request.Context(), uint(tagID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Tag"))
+		return
+	}
+
+	req.Apply(tag)
+	if err := h.tags.Update(c.Request.Context(), tag); err != nil {
+		c.Error(apperr.FromDB(err, "Tag"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag updated successfully",
+		"tag":     dto.NewTagResponse(tag),
+	})
+}
+
+// DeleteTag 删除标签（管理员、版主），文章上的该标签一并移除
+func (h *TaxonomyHandler) DeleteTag(c *gin.Context) {
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid tag ID"))
+		return
+	}
+
+	tag, err := h.tags.FindByID(c.Request.Context(), uint(tagID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Tag"))
+		return
+	}
+
+	if err := h.tags.Delete(c.Request.Context(), tag); err != nil {
+		c.Error(apperr.FromDB(err, "Tag"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// GetCategories 按名称返回全部分类及其文章数
+func (h *TaxonomyHandler) GetCategories(c *gin.Context) {
+	categories, err := h.categories.List(c.Request.Context())
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch categories", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": dto.NewCategoryListResponses(categories),
+		"count":      len(categories),
+	})
+}
+
+// CreateCategory 创建分类（管理员、版主）
+func (h *TaxonomyHandler) CreateCategory(c *gin.Context) {
+	var req dto.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	var category model.Category
+	req.Apply(&category)
+	if err := h.categories.Create(c.Request.Context(), &category); err != nil {
+		c.Error(apperr.FromDB(err, "Category"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Category created successfully",
+		"category": dto.NewCategoryResponse(&category),
+	})
+}
+
+// UpdateCategory 修改分类名称和描述（管理员、版主）
+func (h *TaxonomyHandler) UpdateCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid category ID"))
+		return
+	}
+
+	var req dto.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	category, err := h.categories.FindByID(c.Request.Context(), uint(categoryID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Category"))
+		return
+	}
+
+	req.Apply(category)
+	if err := h.categories.Update(c.Request.Context(), category); err != nil {
+		c.Error(apperr.FromDB(err, "Category"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Category updated successfully",
+		"category": dto.NewCategoryResponse(category),
+	})
+}
+
+// DeleteCategory 删除分类（管理员、版主），其下的文章保留，只移除关联
+func (h *TaxonomyHandler) DeleteCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid category ID"))
+		return
+	}
+
+	category, err := h.categories.FindByID(c.Request.Context(), uint(categoryID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "Category"))
+		return
+	}
+
+	if err := h.categories.Delete(c.Request.Context(), category); err != nil {
+		c.Error(apperr.FromDB(err, "Category"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+)
+
+// TestTagSlugs 标签名称按 Slug 去重，重命名时 Slug 跟着变化
+func TestTagSlugs(t *testing.T) {
+	if err := dto.RegisterValidators(); err != nil {
+		t.Fatalf("register validators: %v", err)
+	}
+	apperr.UseJSONFieldNames()
+	store := newTestStore(t)
+	moderator := createTestUser(t, store, "mod", rbac.RoleModerator)
+
+	router := newTestRouter(moderator.ID, rbac.RoleModerator)
+	h := NewTaxonomyHandler(store.Tags, store.Categories)
+	router.POST("/tags", h.CreateTag)
+	router.PUT("/tags/:id", h.UpdateTag)
+	router.POST("/categories", h.CreateCategory)
+
+	type tagResponse struct {
+		Tag dto.TagResponse `json:"tag"`
+	}
+	var created tagResponse
+	if w := doJSON(t, router, http.MethodPost, "/tags", map[string]string{"name": "  Go   Lang "}, nil, &created); w.Code != http.StatusCreated {
+		t.Fatalf("create tag: status = %d", w.Code)
+	}
+	if created.Tag.Name != "Go Lang" || created.Tag.Slug != "go-lang" {
+		t.Errorf("created tag = %+v, want name %q slug %q", created.Tag, "Go Lang", "go-lang")
+	}
+	var other tagResponse
+	if w := doJSON(t, router, http.MethodPost, "/tags", map[string]string{"name": "Web 开发"}, nil, &other); w.Code != http.StatusCreated {
+		t.Fatalf("create second tag: status = %d", w.Code)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   apperr.Code
+	}{
+		{"same slug different case", http.MethodPost, "/tags", "GO_LANG", http.StatusConflict, apperr.CodeConflict},
+		{"same slug different punctuation", http.MethodPost, "/tags", "go/lang!", http.StatusConflict, apperr.CodeConflict},
+		{"name without letters", http.MethodPost, "/tags", "+++", http.StatusBadRequest, apperr.CodeValidation},
+		{"blank name", http.MethodPost, "/tags", "   ", http.StatusBadRequest, apperr.CodeValidation},
+		{"rename onto existing slug", http.MethodPut, fmt.Sprintf("/tags/%d", other.Tag.ID), "go-lang", http.StatusConflict, apperr.CodeConflict},
+		{"rename to empty slug", http.MethodPut, fmt.Sprintf("/tags/%d", other.Tag.ID), "***", http.StatusBadRequest, apperr.CodeValidation},
+		{"category may reuse a tag slug", http.MethodPost, "/categories", "Go Lang", http.StatusCreated, ""},
+		{"duplicate category", http.MethodPost, "/categories", "go-lang", http.StatusConflict, apperr.CodeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp middleware.ErrorResponse
+			w := doJSON(t, router, tt.method, tt.path, map[string]string{"name": tt.body}, nil, &resp)
+			if w.Code != tt.status || resp.Code != tt.code {
+				t.Errorf("status = %d code %q, want %d %q (body %s)", w.Code, resp.Code, tt.status, tt.code, w.Body.String())
+			}
+		})
+	}
+
+	// 重命名后 Slug 随名称更新
+	var renamed tagResponse
+	path := fmt.Sprintf("/tags/%d", other.Tag.ID)
+	if w := doJSON(t, router, http.MethodPut, path, map[string]string{"name": "Web 后端"}, nil, &renamed); w.Code != http.StatusOK {
+		t.Fatalf("rename tag: status = %d", w.Code)
+	}
+	if renamed.Tag.Slug != "web-后端" {
+		t.Errorf("renamed slug = %q, want %q", renamed.Tag.Slug, "web-后端")
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/auth"
+	"github.com/zhanglegen/go_task/go_gin/dto"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"github.com/zhanglegen/go_task/go_gin/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserHandler 用户资料和账号管理处理函数
+type UserHandler struct {
+	users    repository.UserRepository
+	sessions *auth.Service
+	accounts *auth.Accounts
+}
+
+// NewUserHandler 创建用户处理器，sessions 用于修改密码、注销账号和修改角色后吊销已有会话，
+// accounts 用于修改邮箱后给新邮箱发送验证邮件
+func NewUserHandler(users repository.UserRepository, sessions *auth.Service, accounts *auth.Accounts) *UserHandler {
+	return &UserHandler{users: users, sessions: sessions, accounts: accounts}
+}
+
+// GetUser 获取用户的公开资料，包含已发布的文章数和评论数，已注销的用户返回 404
+func (h *UserHandler) GetUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid user ID"))
+		return
+	}
+
+	user, err := h.users.FindByID(c.Request.Context(), uint(userID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": dto.NewUserProfileResponse(user)})
+}
+
+// GetMe 获取当前用户的资料，包含邮箱
+func (h *UserHandler) GetMe(c *gin.Context) {
+	user, err := h.users.FindByID(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  dto.NewUserResponse(user),
+		"stats": gin.H{"post_count": user.PostCount, "comment_count": user.CommentCount},
+	})
+}
+
+// UpdateMe 修改当前用户的用户名和邮箱，请求需要包含全部字段
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	user, err := h.users.FindByID(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+
+	var req dto.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+	h.saveProfile(c, user, &req)
+}
+
+// PatchMe 按 JSON Merge Patch 修改当前用户的用户名和邮箱
+func (h *UserHandler) PatchMe(c *gin.Context) {
+	user, err := h.users.FindByID(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+
+	base := dto.NewUpdateUserRequest(user)
+	var req dto.UpdateUserRequest
+	if _, err := bindMergePatch(c, &base, &req, dto.PatchableUserFields...); err != nil {
+		c.Error(err)
+		return
+	}
+	h.saveProfile(c, user, &req)
+}
+
+// saveProfile 只写入值发生变化的字段，用户名或邮箱已被占用时返回 409
+// 新用户名在下次刷新令牌后出现在令牌中；修改邮箱后发往旧邮箱的验证链接作废，并给新邮箱发送验证邮件
+func (h *UserHandler) saveProfile(c *gin.Context, user *model.User, req *dto.UpdateUserRequest) {
+	ctx := c.Request.Context()
+	oldEmail := user.Email
+	if changed := req.Apply(user); len(changed) > 0 {
+		if err := h.users.Update(ctx, user, changed...); err != nil {
+			c.Error(apperr.FromDB(err, "User"))
+			return
+		}
+	}
+
+	// 验证邮件发送失败不影响资料修改，用户可以稍后重新发送
+	if user.Email != oldEmail {
+		if err := h.accounts.EmailChanged(ctx, user); err != nil {
+			utils.Logger(ctx).Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile updated successfully",
+		"user":    dto.NewUserResponse(user),
+	})
+}
+
+// ChangePassword 校验旧密码后修改密码，吊销该用户的全部会话，并为当前客户端签发新令牌
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.users.FindByID(ctx, c.GetUint("userID"))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+		c.Error(apperr.Forbidden("Old password is incorrect"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to hash password", err))
+		return
+	}
+	user.Password = string(hashedPassword)
+	if err := h.users.Update(ctx, user, "Password"); err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+
+	// 其他设备上的会话全部下线，包括可能泄露的令牌
+	if err := h.sessions.RevokeUser(ctx, user.ID); err != nil {
+		c.Error(apperr.Internal("Failed to revoke sessions", err))
+		return
+	}
+	pair, err := h.sessions.IssueTokens(ctx, user)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to generate token", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed successfully",
+		"access_token":  pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    int(pair.ExpiresIn.Seconds()),
+	})
+}
+
+// DeleteMe 确认密码后注销当前用户，账号软删除并匿名化，全部会话立即失效
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	var req dto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.users.FindByID(ctx, c.GetUint("userID"))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		c.Error(apperr.Forbidden("Password is incorrect"))
+		return
+	}
+
+	if err := h.users.Delete(ctx, user); err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+	if err := h.sessions.RevokeUser(ctx, user.ID); err != nil {
+		c.Error(apperr.Internal("Failed to revoke sessions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// UpdateRole 修改用户角色（管理员）
+// 角色写在令牌中，修改后吊销该用户的全部会话，用户重新登录后按新角色签发令牌，
+// 避免被降级的用户在访问令牌过期前或通过刷新令牌继续使用旧角色
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(apperr.Validation("Invalid user ID"))
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+	if !rbac.Role(req.Role).Valid() {
+		c.Error(apperr.Validation("Role must be one of admin, moderator, author, reader"))
+		return
+	}
+
+	user, err := h.users.FindByID(c.Request.Context(), uint(userID))
+	if err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+
+	if err := h.users.UpdateRole(c.Request.Context(), user.ID, req.Role); err != nil {
+		c.Error(apperr.FromDB(err, "User"))
+		return
+	}
+	if err := h.sessions.RevokeUser(c.Request.Context(), user.ID); err != nil {
+		c.Error(apperr.Internal("Failed to revoke sessions", err))
+		return
+	}
+
+	user.Role = req.Role
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role updated successfully",
+		"user":    dto.NewUserResponse(user),
+	})
+}
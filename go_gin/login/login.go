@@ -1,81 +1,278 @@
 package login
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/auth"
+	"github.com/zhanglegen/go_task/go_gin/dto"
 	"github.com/zhanglegen/go_task/go_gin/middleware"
-	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/ratelimit"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"github.com/zhanglegen/go_task/go_gin/utils"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-func Register(c *gin.Context) {
-	var user model.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// Handler 用户注册、登录、会话管理以及邮箱验证和找回密码处理函数
+type Handler struct {
+	users    repository.UserRepository
+	sessions *auth.Service
+	accounts *auth.Accounts
+	lockout  *ratelimit.Lockout
+}
+
+// NewHandler 创建认证处理器，lockout 负责连续登录失败后的账号锁定，accounts 负责发送验证和重置密码邮件
+func NewHandler(users repository.UserRepository, sessions *auth.Service, accounts *auth.Accounts, lockout *ratelimit.Lockout) *Handler {
+	return &Handler{users: users, sessions: sessions, accounts: accounts, lockout: lockout}
+}
+
+// Register 注册新用户，只接受用户名、邮箱和密码，角色固定为默认角色
+func (h *Handler) Register(c *gin.Context) {
+	var req dto.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
 		return
 	}
+	user := req.Model()
 
 	// 检查用户名和邮箱是否已存在
-	var existingUser model.User
-	if err := model.DB.Where("username = ? OR email = ?", user.Username, user.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email already exists"})
+	exists, err := h.users.ExistsByUsernameOrEmail(c.Request.Context(), user.Username, user.Email)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to check user", err))
+		return
+	}
+	if exists {
+		c.Error(apperr.Conflict("Username or email already exists"))
 		return
 	}
 
 	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		c.Error(apperr.Internal("Failed to hash password", err))
 		return
 	}
 	user.Password = string(hashedPassword)
+	// 角色只能由管理员修改
+	user.Role = string(rbac.DefaultRole)
 
-	if err := model.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	if err := h.users.Create(c.Request.Context(), user); err != nil {
+		c.Error(apperr.FromDB(err, "User"))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
+	// 验证邮件发送失败不影响注册，用户可以稍后重新发送
+	if err := h.accounts.SendVerification(c.Request.Context(), user); err != nil {
+		utils.Logger(c.Request.Context()).Error("Failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "User registered successfully",
+		"user":    dto.NewUserResponse(user),
+	})
 }
 
-func Login(c *gin.Context) {
-	var loginData struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
-	}
+// Login 校验用户名和密码，开启新会话并签发令牌
+func (h *Handler) Login(c *gin.Context) {
+	var loginData dto.LoginRequest
 
 	if err := c.ShouldBindJSON(&loginData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	// 账号锁定期间不再校验密码，锁定存储故障时放行
+	ctx := c.Request.Context()
+	until, err := h.lockout.LockedUntil(ctx, loginData.Username)
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to check login lockout", "error", err)
+	}
+	if !until.IsZero() {
+		middleware.SetRetryAfter(c, time.Until(until))
+		c.Error(apperr.TooManyRequests("Too many failed login attempts, please retry later"))
 		return
 	}
 
-	var storedUser model.User
-	if err := model.DB.Where("username = ?", loginData.Username).First(&storedUser).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+	storedUser, err := h.users.FindByUsername(ctx, loginData.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.loginFailed(c, loginData.Username)
+		} else {
+			c.Error(apperr.Internal("Failed to fetch user", err))
+		}
 		return
 	}
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(loginData.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password111"})
+		h.loginFailed(c, loginData.Username)
+		return
+	}
+	if err := h.lockout.Reset(ctx, loginData.Username); err != nil {
+		utils.Logger(ctx).Error("Failed to reset login lockout", "error", err)
+	}
+	// 密码正确后才提示邮箱未验证，不泄露账号状态
+	if !h.accounts.CanLogin(storedUser) {
+		c.Error(apperr.Forbidden("Email address not verified"))
+		return
+	}
+
+	// 开启新会话并签发令牌
+	pair, err := h.sessions.IssueTokens(c.Request.Context(), storedUser)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to generate token", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         pair.AccessToken, // 兼容旧客户端，与 access_token 相同
+		"access_token":  pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    int(pair.ExpiresIn.Seconds()),
+		"user":          dto.NewUserResponse(storedUser),
+	})
+}
+
+// loginFailed 记录一次登录失败，用户不存在和密码错误返回相同的错误，避免泄露用户名是否存在
+func (h *Handler) loginFailed(c *gin.Context, username string) {
+	ctx := c.Request.Context()
+	until, err := h.lockout.Fail(ctx, username)
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to record login failure", "error", err)
+	}
+	if !until.IsZero() {
+		utils.Logger(ctx).Warn("Account locked after repeated login failures", "username", username, "until", until)
+	}
+	c.Error(apperr.Unauthorized("Invalid username or password"))
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌
+func (h *Handler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	pair, err := h.sessions.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrRefreshTokenReused):
+			c.Error(apperr.Unauthorized("Refresh token reused, all sessions have been revoked"))
+		case errors.Is(err, auth.ErrInvalidRefreshToken):
+			c.Error(apperr.Unauthorized("Invalid refresh token"))
+		default:
+			c.Error(apperr.Internal("Failed to refresh token", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    int(pair.ExpiresIn.Seconds()),
+	})
+}
+
+// Logout 注销当前会话，会话下的访问令牌和刷新令牌全部失效
+func (h *Handler) Logout(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.Error(apperr.Unauthorized("User not authenticated"))
+		return
+	}
+
+	if err := h.sessions.Logout(c.Request.Context(), claims.(*middleware.Claims)); err != nil {
+		c.Error(apperr.Internal("Failed to logout", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
+}
+
+// ResendVerification 重新发送邮箱验证邮件，无论邮箱是否注册或已验证都返回相同的响应
+func (h *Handler) ResendVerification(c *gin.Context) {
+	var req dto.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
 		return
 	}
 
-	// 生成 JWT token
-	tokenString, err := middleware.GenerateToken(storedUser.ID, storedUser.Username)
+	if err := h.accounts.ResendVerification(c.Request.Context(), strings.ToLower(req.Email)); err != nil {
+		utils.Logger(c.Request.Context()).Error("Failed to send verification email", "error", err)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered and not yet verified, a verification link has been sent"})
+}
+
+// VerifyEmail 核销邮件中的令牌，完成邮箱验证
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	user, err := h.accounts.VerifyEmail(c.Request.Context(), req.Token)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		if errors.Is(err, auth.ErrInvalidUserToken) {
+			c.Error(apperr.Validation("Invalid or expired token"))
+		} else {
+			c.Error(apperr.Internal("Failed to verify email", err))
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   tokenString,
-		"user": gin.H{
-			"id":       storedUser.ID,
-			"username": storedUser.Username,
-			"email":    storedUser.Email,
-		},
+		"message": "Email verified successfully",
+		"user":    dto.NewUserResponse(user),
 	})
 }
+
+// ForgotPassword 发送重置密码邮件，无论邮箱是否注册都返回相同的响应
+// 发送失败只记录日志，否则错误响应本身就说明了邮箱已注册
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req dto.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	if err := h.accounts.SendPasswordReset(c.Request.Context(), strings.ToLower(req.Email)); err != nil {
+		utils.Logger(c.Request.Context()).Error("Failed to send password reset email", "error", err)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a password reset link has been sent"})
+}
+
+// ResetPassword 用邮件中的令牌设置新密码，成功后用户的全部会话失效并解除登录锁定
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.FromBinding(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.accounts.ResetPassword(ctx, req.Token, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidUserToken) {
+			c.Error(apperr.Validation("Invalid or expired token"))
+		} else {
+			c.Error(apperr.Internal("Failed to reset password", err))
+		}
+		return
+	}
+	if err := h.lockout.Reset(ctx, user.Username); err != nil {
+		utils.Logger(ctx).Error("Failed to reset login lockout", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully, please log in again"})
+}
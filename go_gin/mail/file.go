@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer 把邮件保存为发件箱目录中的 .eml 文件，不真正发送
+// 本地开发时可以直接打开文件点击验证和重置链接
+type FileMailer struct {
+	dir  string
+	from string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileMailer 创建文件发送器，目录不存在时自动创建
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail outbox: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send 写入一封邮件，文件名按时间排序
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	now := time.Now()
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405.000000000"), m.seq)
+	m.mu.Unlock()
+
+	// 先写临时文件再改名，读取发件箱时不会看到写了一半的邮件
+	path := filepath.Join(m.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, msg.encode(m.from, now), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
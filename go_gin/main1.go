@@ -2,11 +2,31 @@ package main
 
 import (
 	//_ "github.com/gin-gonic/gin
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/zhanglegen/go_task/go_gin/auth"
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/handlers"
+	"github.com/zhanglegen/go_task/go_gin/mail"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/migrations"
 	"github.com/zhanglegen/go_task/go_gin/model"
-	_ "github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/ratelimit"
+	"github.com/zhanglegen/go_task/go_gin/repository"
 	"github.com/zhanglegen/go_task/go_gin/routes"
+	"github.com/zhanglegen/go_task/go_gin/scheduler"
+	"github.com/zhanglegen/go_task/go_gin/search"
+	"github.com/zhanglegen/go_task/go_gin/telemetry"
 	"github.com/zhanglegen/go_task/go_gin/utils"
 )
 
@@ -20,26 +40,214 @@ func main() {
 	// router.Run() // 默认监听 0.0.0.0:8080
 	//model.InitDb()
 
+	// 加载配置：默认值 < 配置文件 < 环境变量 < 命令行参数
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fatal("Failed to load config", err)
+	}
+
 	// 初始化日志
-	if err := utils.InitLogger(); err != nil {
-		log.Fatalf("Failed to initialize logger: %v", err)
+	if err := utils.InitLogger(cfg.Log); err != nil {
+		fatal("Failed to initialize logger", err)
 	}
 
-	// 初始化数据库
-	if err := model.InitDb(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	// 初始化链路追踪和指标
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+	var metrics *telemetry.Metrics
+	if cfg.Metrics.Enabled {
+		metrics = telemetry.NewMetrics()
 	}
 
+	// 初始化数据库，driver=sqlite 时无需MySQL即可在本地运行
+	db, err := model.InitDb(cfg.Database, cfg.Log.Level)
+	if err != nil {
+		fatal("Failed to initialize database", err)
+	}
+	if err := db.Use(telemetry.NewGormPlugin(metrics)); err != nil {
+		fatal("Failed to instrument database", err)
+	}
+	migrator, err := migrate.New(db, migrations.All())
+	if err != nil {
+		fatal("Failed to load migrations", err)
+	}
+
+	store := repository.NewGormStore(db)
+
+	// 带子命令时执行命令后退出，如 migrate up
+	if len(args) > 0 {
+		code := runCommand(context.Background(), migrator, store, args)
+		model.Close(db)
+		shutdownTracing(context.Background())
+		utils.CloseLogger()
+		os.Exit(code)
+	}
+
+	if cfg.Database.AutoMigrate {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			fatal("Failed to migrate database", err)
+		}
+	}
+
+	// 加载JWT签名密钥
+	keys, err := middleware.LoadKeySet(cfg.JWT)
+	if err != nil {
+		fatal("Failed to load jwt keys", err)
+	}
+	middleware.InitJWT(keys, cfg.JWT.TokenTTL.Duration)
+
 	utils.LogInfo("Blog system starting...")
 
+	// 初始化全文搜索，内存索引需要在启动时从数据库重建
+	searcher, err := search.New(context.Background(), cfg.Search.Engine, cfg.Database.Driver, db,
+		func(ctx context.Context, fn func(posts []model.Post) error) error {
+			return store.Posts.EachBatch(ctx, 500, fn)
+		}, store.Posts.PublishedIDs)
+	if err != nil {
+		fatal("Failed to initialize search", err)
+	}
+
+	// 就绪检查：数据库可以连通且迁移已全部执行
+	health := handlers.NewHealthHandler(
+		handlers.HealthCheck{Name: "database", Check: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}},
+		handlers.HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			pending, err := migrator.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d pending migrations", len(pending))
+			}
+			return nil
+		}},
+	)
+
+	// 定时发布：到期的定时文章改为已发布并加入搜索索引，服务退出前停止
+	publisher := scheduler.NewPostPublisher(store.Posts, cfg.Posts.SchedulerInterval.Duration,
+		func(ctx context.Context, post *model.Post) {
+			if err := searcher.Index(ctx, post); err != nil {
+				slog.ErrorContext(ctx, "Failed to update search index", "post_id", post.ID, "error", err)
+			}
+		})
+	// 浏览数在内存中累计后定期写入，服务退出前写入剩余的计数
+	views := scheduler.NewViewCounter(store.Engagement, cfg.Posts.ViewFlushInterval.Duration)
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	var schedulerDone sync.WaitGroup
+	schedulerDone.Add(2)
+	go func() {
+		defer schedulerDone.Done()
+		publisher.Run(schedulerCtx)
+	}()
+	go func() {
+		defer schedulerDone.Done()
+		views.Run(schedulerCtx)
+	}()
+
+	// 会话、邮箱验证和找回密码
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		fatal("Failed to initialize mailer", err)
+	}
+	sessions := auth.NewService(store.Users, store.Tokens, cfg.JWT.RefreshTokenTTL.Duration)
+	accounts := auth.NewAccounts(store.Users, store.Tokens, sessions, mailer, auth.AccountOptions{
+		Secret:        cfg.Account.TokenSecret,
+		VerifyTTL:     cfg.Account.VerifyTTL.Duration,
+		ResetTTL:      cfg.Account.ResetTTL.Duration,
+		LinkBaseURL:   cfg.Account.LinkBaseURL,
+		RequireVerify: cfg.Account.RequireVerify,
+	})
+
 	// 设置路由
-	router := routes.SetupRouter()
+	router, err := routes.SetupRouter(routes.Dependencies{
+		Store:    store,
+		Searcher: searcher,
+		Views:    views,
+		Sessions: sessions,
+		Accounts: accounts,
+		Health:   health,
+
+		CommentMaxDepth: cfg.Comments.MaxDepth,
+		RateLimitStore:  ratelimit.NewMemoryStore(),
+		RateLimits:      cfg.RateLimit,
+		TrustedProxies:  cfg.Server.TrustedProxies,
+		Metrics:         metrics,
+		MetricsPath:     cfg.Metrics.Path,
+	})
+	if err != nil {
+		fatal("Failed to set up router", err)
+	}
 
-	utils.LogInfo("Server starting on port 8080...")
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout.Duration,
+		ReadTimeout:       cfg.Server.ReadTimeout.Duration,
+		WriteTimeout:      cfg.Server.WriteTimeout.Duration,
+		IdleTimeout:       cfg.Server.IdleTimeout.Duration,
+	}
 
 	// 启动服务器
-	if err := router.Run(":8080"); err != nil {
-		utils.LogErrorWithDetails("Failed to start server", err)
-		log.Fatalf("Failed to start server: %v", err)
+	slog.Info("Server starting", "addr", cfg.Server.Addr)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	// 收到 SIGINT/SIGTERM 后停止接收新请求，等待处理中的请求完成
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			utils.LogErrorWithDetails("Failed to start server", err)
+			exitCode = 1
+		}
+	case <-ctx.Done():
+		stop()
+		slog.Info("Shutting down server", "timeout", cfg.Server.ShutdownTimeout.Duration.String())
+		health.SetDraining()
+		time.Sleep(cfg.Server.DrainDelay.Duration)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Duration)
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			utils.LogErrorWithDetails("Server shutdown did not complete", err)
+			exitCode = 1
+		}
+		cancel()
+	}
+
+	stopScheduler()
+	schedulerDone.Wait()
+
+	// 请求全部结束后再关闭数据库、导出剩余的span并关闭日志文件
+	if err := model.Close(db); err != nil {
+		utils.LogErrorWithDetails("Failed to close database", err)
+		exitCode = 1
 	}
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		utils.LogErrorWithDetails("Failed to flush traces", err)
+	}
+	cancel()
+	slog.Info("Server stopped")
+	if err := utils.CloseLogger(); err != nil {
+		slog.Error("Failed to close log file", "error", err)
+	}
+	os.Exit(exitCode)
+}
+
+// fatal 记录错误后退出，日志初始化之前输出到标准错误
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
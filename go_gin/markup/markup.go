@@ -0,0 +1,93 @@
+// Package markup 把文章和评论的 Markdown 原文渲染为可以直接输出到页面的安全 HTML
+package markup
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Format 内容的输出格式
+type Format string
+
+// 内容格式
+const (
+	FormatMarkdown Format = "markdown" // 作者提交的 Markdown 原文
+	FormatHTML     Format = "html"     // 渲染并过滤后的 HTML
+	FormatText     Format = "text"     // 去掉全部标记的纯文本
+)
+
+// ErrUnknownFormat 不支持的输出格式
+var ErrUnknownFormat = errors.New("format must be one of markdown, html, text")
+
+// ParseFormat 解析查询参数中的格式，空字符串表示 Markdown 原文
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "":
+		return FormatMarkdown, nil
+	case FormatMarkdown, FormatHTML, FormatText:
+		return f, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Select 按格式从原文和渲染结果中取出要输出的内容
+func (f Format) Select(source, rendered string) string {
+	switch f {
+	case FormatHTML:
+		return rendered
+	case FormatText:
+		return PlainText(rendered)
+	}
+	return source
+}
+
+// 支持 GitHub 风格的表格、删除线、任务列表和自动链接
+// 原文中的 HTML 标签原样输出，统一交给 policy 过滤
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+)
+
+// policy 在 bluemonday 的 UGC 白名单基础上允许代码块的语言标记和任务列表的复选框
+// 不允许 script、style、iframe 和 on* 事件属性，链接只能是 http、https、mailto 或相对地址，
+// 所有链接带 rel="nofollow"，外部链接在新窗口打开并加上 noopener noreferrer
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$`)).OnElements("input")
+	p.RequireNoReferrerOnFullyQualifiedLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}()
+
+// text 只保留文本内容
+var text = bluemonday.StrictPolicy()
+
+// blankLines 连续的空行
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// Render 把 Markdown 渲染为过滤后的 HTML
+func Render(source string) string {
+	var buf bytes.Buffer
+	// 写入 bytes.Buffer 不会失败，解析器对任意输入都能给出结果
+	_ = md.Convert([]byte(source), &buf)
+	return policy.Sanitize(buf.String())
+}
+
+// PlainText 去掉 HTML 中的全部标签并还原字符实体，去掉每行首尾的空白，块级元素之间最多保留一个空行
+func PlainText(rendered string) string {
+	lines := strings.Split(html.UnescapeString(text.Sanitize(rendered)), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,118 @@
+package markup
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// htmlTag 渲染结果中的标签，文本中转义后的 &lt; 不会匹配
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func TestRenderSanitizesXSS(t *testing.T) {
+	// 渲染结果的标签中不能出现的片段，不区分大小写；作为纯文本输出的内容不会被浏览器执行
+	forbidden := []string{"<script", "javascript:", "vbscript:", "onerror", "onload", "onclick", "onmouseover",
+		"<iframe", "<style", "<object", "<embed", "<svg", "<form", "data:text/html", "srcdoc", "expression("}
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"script tag", `<script>alert(1)</script>`},
+		{"script tag mixed case", `<ScRiPt>alert(1)</sCrIpT>`},
+		{"img onerror", `<img src=x onerror="alert(1)">`},
+		{"svg onload", `<svg onload=alert(1)>`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`},
+		{"javascript link with entities", `<a href="jav&#x09;ascript:alert(1)">x</a>`},
+		{"markdown javascript link", `[click](javascript:alert(1))`},
+		{"markdown javascript link mixed case", `[click](JaVaScRiPt:alert(1))`},
+		{"markdown vbscript image", `![x](vbscript:msgbox(1))`},
+		{"markdown data uri link", `[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)`},
+		{"autolink javascript", `<javascript:alert(1)>`},
+		{"iframe", `<iframe src="https://evil.example" srcdoc="<script>alert(1)</script>"></iframe>`},
+		{"style tag", `<style>body{background:url(javascript:alert(1))}</style>`},
+		{"style attribute", `<p style="width:expression(alert(1))">x</p>`},
+		{"object and embed", `<object data="evil.swf"></object><embed src="evil.swf">`},
+		{"form", `<form action="https://evil.example"><input name=x></form>`},
+		{"event handler on allowed tag", `<a href="https://example.com" onmouseover="alert(1)">x</a>`},
+		{"html inside code span is escaped", "`<script>alert(1)</script>`"},
+		{"unclosed tag", `<img src=x onerror=alert(1)//`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.source)
+			tags := strings.ToLower(strings.Join(htmlTag.FindAllString(got, -1), ""))
+			for _, f := range forbidden {
+				if strings.Contains(tags, f) {
+					t.Errorf("Render(%q) = %q, tags contain %q", tt.source, got, f)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderKeepsSafeMarkup(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"emphasis", "**bold** _em_", []string{"<strong>bold</strong>", "<em>em</em>"}},
+		{"code block language", "```go\nfmt.Println(1)\n```", []string{`<code class="language-go">`}},
+		{"external link", "[x](https://example.com)", []string{`href="https://example.com"`, `nofollow`, `noopener`, `target="_blank"`}},
+		{"relative link", "[x](/posts/1)", []string{`href="/posts/1"`}},
+		{"mailto link", "[x](mailto:a@example.com)", []string{`href="mailto:a@example.com"`}},
+		{"task list", "- [x] done", []string{`type="checkbox"`, `checked`}},
+		{"table", "| a |\n|---|\n| b |", []string{"<table>", "<td>b</td>"}},
+		{"escaped html in code span", "`<b>`", []string{"<code>&lt;b&gt;</code>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.source)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("Render(%q) = %q, want it to contain %q", tt.source, got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatSelect(t *testing.T) {
+	source := "# Title\n\nSome **bold** & <i>text</i>"
+	rendered := Render(source)
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatMarkdown, source},
+		{FormatHTML, rendered},
+		{FormatText, "Title\nSome bold & text"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.Select(source, rendered); got != tt.want {
+			t.Errorf("%s: Select = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatMarkdown, false},
+		{"markdown", FormatMarkdown, false},
+		{"html", FormatHTML, false},
+		{"text", FormatText, false},
+		{"HTML", "", true},
+		{"pdf", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package mergepatch 实现 RFC 7396 JSON Merge Patch
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrNotObject 补丁不是 JSON 对象，资源的部分更新只接受对象形式的补丁
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply 把 patch 合并到 doc：对象逐个成员递归合并，值为 null 的成员从结果中删除，其他值（包括数组）整体替换
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target, p any
+	if err := decode(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := decode(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p))
+}
+
+// Fields 解析对象形式的补丁，返回各成员的原始值，值为 null 的成员表示删除
+func Fields(patch []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, ErrNotObject
+		}
+		return nil, err
+	}
+	if fields == nil {
+		return nil, ErrNotObject
+	}
+	return fields, nil
+}
+
+// IsNull 成员的值是否为 null
+func IsNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
+
+// decode 数字保留原始文本，避免大整数经过 float64 丢失精度
+func decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
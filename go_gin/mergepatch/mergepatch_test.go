@@ -0,0 +1,120 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// RFC 7396 附录 A 的示例，以及大整数精度
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null removes member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"null removes only that member", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array replaces member", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"member replaces array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays are not merged", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"array document replaced", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"object replaces array document", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"null patch", `{"a":"foo"}`, `null`, `null`},
+		{"string patch", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null member kept in target", `{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{"patch onto array creates object", `[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{"nested null dropped from new object", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{"large integers keep precision", `{"id":9007199254740993}`, `{"n":12345678901234567890}`, `{"id":9007199254740993,"n":12345678901234567890}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !jsonEqual(t, string(got), tt.want) {
+				t.Errorf("Apply(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyInvalidJSON(t *testing.T) {
+	if _, err := Apply([]byte(`{"a":1}`), []byte(`{"a":`)); err == nil {
+		t.Error("Apply with malformed patch: want error")
+	}
+	if _, err := Apply([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("Apply with malformed document: want error")
+	}
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		want    []string // 成员名
+		nulls   []string // 值为 null 的成员
+		wantErr error
+	}{
+		{name: "object", patch: `{"title":"x","tags":null}`, want: []string{"tags", "title"}, nulls: []string{"tags"}},
+		{name: "empty object", patch: `{}`, want: []string{}},
+		{name: "array", patch: `["title"]`, wantErr: ErrNotObject},
+		{name: "string", patch: `"title"`, wantErr: ErrNotObject},
+		{name: "null", patch: `null`, wantErr: ErrNotObject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := Fields([]byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Fields(%s) error = %v, want %v", tt.patch, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fields(%s): %v", tt.patch, err)
+			}
+			if len(fields) != len(tt.want) {
+				t.Fatalf("Fields(%s) returned %d members, want %d", tt.patch, len(fields), len(tt.want))
+			}
+			for _, name := range tt.want {
+				if _, ok := fields[name]; !ok {
+					t.Errorf("member %q missing", name)
+				}
+			}
+			for _, name := range tt.nulls {
+				if !IsNull(fields[name]) {
+					t.Errorf("member %q = %s, want null", name, fields[name])
+				}
+			}
+		})
+	}
+
+	if _, err := Fields([]byte(`{"a":`)); err == nil || errors.Is(err, ErrNotObject) {
+		t.Errorf("Fields with malformed JSON: error = %v, want syntax error", err)
+	}
+}
+
+func TestIsNull(t *testing.T) {
+	tests := map[string]bool{`null`: true, ` null `: true, `"null"`: false, `0`: false, `{}`: false}
+	for raw, want := range tests {
+		if got := IsNull(json.RawMessage(raw)); got != want {
+			t.Errorf("IsNull(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func jsonEqual(t *testing.T, a, b string) bool {
+	t.Helper()
+	var va, vb any
+	if err := decode([]byte(a), &va); err != nil {
+		t.Fatalf("decode %s: %v", a, err)
+	}
+	if err := decode([]byte(b), &vb); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
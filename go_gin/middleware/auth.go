@@ -1,94 +1,166 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
 )
 
-var jwtSecret = []byte("your_secret_key")
+// tokenIssuer 令牌签发方
+const tokenIssuer = "blog-system"
 
-// Claims JWT claims结构
+var (
+	keySet   *KeySet
+	tokenTTL = 15 * time.Minute
+)
+
+// InitJWT 设置JWT密钥集合和访问令牌有效期
+func InitJWT(keys *KeySet, ttl time.Duration) {
+	keySet = keys
+	tokenTTL = ttl
+}
+
+// TokenTTL 访问令牌有效期
+func TokenTTL() time.Duration {
+	return tokenTTL
+}
+
+// Claims JWT claims结构，RegisteredClaims.ID 即 jti
 type Claims struct {
-	UserID   uint   `json:"id"`
-	Username string `json:"username"`
-	jwt.StandardClaims
+	UserID    uint   `json:"id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"` // 登录会话ID，对应刷新令牌的 FamilyID
+	jwt.RegisteredClaims
+}
+
+// RevocationChecker 检查访问令牌的 jti 或会话 sid 是否已被吊销
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
 }
 
 // AuthMiddleware JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(revocations RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		if c.GetHeader("Authorization") == "" {
+			AbortWithError(c, apperr.Unauthorized("Authorization header required"))
 			return
 		}
-
-		// 检查Bearer token格式
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
-			c.Abort()
+		if err := authenticate(c, revocations); err != nil {
+			AbortWithError(c, err)
 			return
 		}
+		c.Next()
+	}
+}
 
-		tokenString := parts[1]
+// OptionalAuth 可选认证中间件，用于公开接口按访问者调整返回内容
+// 没有 Authorization 头时按匿名用户处理；带了令牌但令牌无效时仍返回401，避免客户端误以为已登录
+func OptionalAuth(revocations RevocationChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			if err := authenticate(c, revocations); err != nil {
+				AbortWithError(c, err)
+				return
+			}
+		}
+		c.Next()
+	}
+}
 
-		// 解析和验证token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
+// authenticate 校验 Authorization 头中的访问令牌，成功后把用户信息写入context
+func authenticate(c *gin.Context, revocations RevocationChecker) *apperr.Error {
+	// 检查Bearer token格式
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		return apperr.Unauthorized("Authorization header format must be Bearer {token}")
+	}
 
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
+	// 解析和验证token，签名算法必须与 kid 对应密钥的算法一致
+	claims, err := ParseToken(parts[1])
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return apperr.Unauthorized("Token expired")
 		}
+		return apperr.Unauthorized("Invalid token")
+	}
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
+	// 检查token或所属会话是否已被吊销（注销、改密、重放检测）
+	revoked, err := revocations.IsRevoked(c.Request.Context(), claims.ID, claims.SessionID)
+	if err != nil {
+		return apperr.Internal("Failed to verify token", err)
+	}
+	if revoked {
+		return apperr.Unauthorized("Token revoked")
+	}
 
-		// 检查token是否过期
-		if time.Now().Unix() > claims.ExpiresAt {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-			c.Abort()
-			return
-		}
+	// 将用户信息存储到context中
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", rbac.Role(claims.Role))
+	c.Set("claims", claims)
+	return nil
+}
 
-		// 将用户信息存储到context中
-		c.Set("userID", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Next()
+// ParseToken 验证访问令牌的签名、签发方和有效期
+func ParseToken(tokenString string) (*Claims, error) {
+	if keySet == nil {
+		return nil, errors.New("jwt keys not configured")
 	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, keySet.keyFunc,
+		jwt.WithValidMethods(keySet.algorithms()),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(userID uint, username string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateToken 生成JWT访问令牌，sessionID 为所属登录会话
+func GenerateToken(userID uint, username, role, sessionID string) (string, error) {
+	if keySet == nil {
+		return "", errors.New("jwt keys not configured")
+	}
+
+	jti, err := RandomID()
+	if err != nil {
+		return "", err
+	}
 
+	now := time.Now()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-			IssuedAt:  time.Now().Unix(),
-			Issuer:    "blog-system",
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    tokenIssuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
-	if err != nil {
+	return keySet.sign(claims)
+}
+
+// RandomID 生成128位随机ID，用于 jti 和会话ID
+func RandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
-
-	return tokenString, nil
+	return hex.EncodeToString(b), nil
 }
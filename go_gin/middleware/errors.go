@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/utils"
+)
+
+// ErrorResponse 统一的错误响应结构
+type ErrorResponse struct {
+	Error     string              `json:"error"`
+	Code      apperr.Code         `json:"code"`
+	Details   []apperr.FieldError `json:"details,omitempty"`
+	Meta      map[string]any      `json:"meta,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+// ErrorHandler 错误处理和恢复中间件，需放在 RequestLogger 之后、其他中间件之前
+// 处理函数通过 c.Error 记录错误后直接返回，由这里统一转换为 ErrorResponse；panic 转换为 500
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Logger(c.Request.Context()).Error("Panic recovered",
+					"panic", r, "stack", string(debug.Stack()))
+				c.Abort()
+				if !c.Writer.Written() {
+					AbortWithError(c, apperr.Internal("Internal server error", nil))
+				}
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		AbortWithError(c, apperr.From(c.Errors.Last().Err))
+	}
+}
+
+// AbortWithError 立即写出错误响应并中止后续处理函数，内部错误会记录日志
+func AbortWithError(c *gin.Context, err *apperr.Error) {
+	if err.Code == apperr.CodeInternal {
+		utils.Logger(c.Request.Context()).Error(err.Message, "error", err.Err)
+	}
+	c.AbortWithStatusJSON(err.Status(), ErrorResponse{
+		Error:     err.Message,
+		Code:      err.Code,
+		Details:   err.Details,
+		Meta:      err.Meta,
+		RequestID: c.GetString("requestID"),
+	})
+}
+
+// NotFoundHandler 未匹配路由时返回统一的 NOT_FOUND 响应
+func NotFoundHandler(c *gin.Context) {
+	AbortWithError(c, apperr.NotFound("Route not found"))
+}
+
+// MethodNotAllowedHandler 路由存在但方法不匹配
+func MethodNotAllowedHandler(c *gin.Context) {
+	AbortWithError(c, apperr.New(apperr.CodeMethod, "Method not allowed"))
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"gorm.io/gorm"
+)
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		status  int
+		want    ErrorResponse
+	}{
+		{
+			name: "app error with details",
+			handler: func(c *gin.Context) {
+				c.Error(apperr.Validation("Validation failed", apperr.FieldError{Field: "title", Rule: "required", Message: "is required"}))
+			},
+			status: http.StatusBadRequest,
+			want: ErrorResponse{
+				Error: "Validation failed", Code: apperr.CodeValidation,
+				Details: []apperr.FieldError{{Field: "title", Rule: "required", Message: "is required"}},
+			},
+		},
+		{
+			name:    "app error with meta",
+			handler: func(c *gin.Context) { c.Error(apperr.Conflict("Version mismatch").WithMeta("version", 3)) },
+			status:  http.StatusConflict,
+			want:    ErrorResponse{Error: "Version mismatch", Code: apperr.CodeConflict, Meta: map[string]any{"version": float64(3)}},
+		},
+		{
+			name:    "database error",
+			handler: func(c *gin.Context) { c.Error(gorm.ErrRecordNotFound) },
+			status:  http.StatusNotFound,
+			want:    ErrorResponse{Error: "Record not found", Code: apperr.CodeNotFound},
+		},
+		{
+			name:    "internal error hides cause",
+			handler: func(c *gin.Context) { c.Error(errors.New("dial tcp: connection refused")) },
+			status:  http.StatusInternalServerError,
+			want:    ErrorResponse{Error: "Internal server error", Code: apperr.CodeInternal},
+		},
+		{
+			name:    "panic",
+			handler: func(c *gin.Context) { panic("boom") },
+			status:  http.StatusInternalServerError,
+			want:    ErrorResponse{Error: "Internal server error", Code: apperr.CodeInternal},
+		},
+		{
+			name: "last error wins",
+			handler: func(c *gin.Context) {
+				c.Error(apperr.Forbidden("first"))
+				c.Error(apperr.Unauthorized("second"))
+			},
+			status: http.StatusUnauthorized,
+			want:   ErrorResponse{Error: "second", Code: apperr.CodeUnauthorized},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set("requestID", "req-1") }, ErrorHandler())
+			r.GET("/", tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			var got ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body.String(), err)
+			}
+			tt.want.RequestID = "req-1"
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestErrorHandlerKeepsWrittenResponse 处理函数已写出响应时不再覆盖
+func TestErrorHandlerKeepsWrittenResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "ok")
+		c.Error(apperr.Internal("late", nil))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusAccepted || w.Body.String() != "ok" {
+		t.Errorf("response = %d %q, want 202 ok", w.Code, w.Body.String())
+	}
+}
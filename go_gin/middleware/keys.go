@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/zhanglegen/go_task/go_gin/config"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// legacyKeyID 只配置了 jwt.secret 时使用的密钥ID
+const legacyKeyID = "default"
+
+// SigningKey 一个签名密钥，只有公钥的密钥只能用于验证
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	method    jwt.SigningMethod
+	signKey   interface{} // HS256为[]byte，RS256为*rsa.PrivateKey，EdDSA为ed25519.PrivateKey
+	verifyKey interface{} // HS256为[]byte，RS256为*rsa.PublicKey，EdDSA为ed25519.PublicKey
+}
+
+// CanSign 是否持有签名所需的私钥
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeySet 同时生效的多把密钥，新令牌使用 signing 签名，验证时按令牌头中的 kid 选择密钥
+// 轮换密钥时先加入新密钥并切换 signing，旧密钥保留到它签发的令牌全部过期后再移除
+type KeySet struct {
+	keys    map[string]*SigningKey
+	signing *SigningKey
+}
+
+// NewKeySet 创建密钥集合，signingID 必须指向持有私钥的密钥
+func NewKeySet(signingID string, keys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey, len(keys))}
+	for _, k := range keys {
+		if _, dup := ks.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate jwt key id: %s", k.ID)
+		}
+		ks.keys[k.ID] = k
+	}
+
+	signing, ok := ks.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %q not found", signingID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("jwt signing key %q has no private key", signingID)
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+// NewHMACKey 创建HS256密钥
+func NewHMACKey(id string, secret []byte) (*SigningKey, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("jwt key %s: secret is empty", id)
+	}
+	return &SigningKey{ID: id, Algorithm: AlgHS256, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+// NewRSAKey 创建RS256密钥，private 为空时只能用于验证
+func NewRSAKey(id string, private *rsa.PrivateKey, public *rsa.PublicKey) (*SigningKey, error) {
+	k := &SigningKey{ID: id, Algorithm: AlgRS256, method: jwt.SigningMethodRS256}
+	if private != nil {
+		k.signKey = private
+		public = &private.PublicKey
+	}
+	if public == nil {
+		return nil, fmt.Errorf("jwt key %s: public or private key required", id)
+	}
+	k.verifyKey = public
+	return k, nil
+}
+
+// NewEdDSAKey 创建EdDSA（Ed25519）密钥，private 为空时只能用于验证
+func NewEdDSAKey(id string, private ed25519.PrivateKey, public ed25519.PublicKey) (*SigningKey, error) {
+	k := &SigningKey{ID: id, Algorithm: AlgEdDSA, method: jwt.SigningMethodEdDSA}
+	if private != nil {
+		k.signKey = private
+		public = private.Public().(ed25519.PublicKey)
+	}
+	if public == nil {
+		return nil, fmt.Errorf("jwt key %s: public or private key required", id)
+	}
+	k.verifyKey = public
+	return k, nil
+}
+
+// LoadKeySet 根据配置加载密钥，只配置 jwt.secret 时等同于一把ID为 default 的HS256密钥
+func LoadKeySet(cfg config.JWTConfig) (*KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		key, err := NewHMACKey(legacyKeyID, []byte(cfg.Secret))
+		if err != nil {
+			return nil, err
+		}
+		return NewKeySet(legacyKeyID, key)
+	}
+
+	keys := make([]*SigningKey, 0, len(cfg.Keys))
+	for _, kc := range cfg.Keys {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(cfg.SigningKeyID, keys...)
+}
+
+func loadKey(kc config.JWTKeyConfig) (*SigningKey, error) {
+	switch kc.Algorithm {
+	case AlgHS256:
+		return NewHMACKey(kc.ID, []byte(kc.Secret))
+	case AlgRS256:
+		private, public, err := readPEMKeys(kc, jwt.ParseRSAPrivateKeyFromPEM, jwt.ParseRSAPublicKeyFromPEM)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAKey(kc.ID, private, public)
+	case AlgEdDSA:
+		private, public, err := readPEMKeys(kc,
+			func(b []byte) (crypto.PrivateKey, error) { return jwt.ParseEdPrivateKeyFromPEM(b) },
+			func(b []byte) (crypto.PublicKey, error) { return jwt.ParseEdPublicKeyFromPEM(b) })
+		if err != nil {
+			return nil, err
+		}
+		var priv ed25519.PrivateKey
+		var pub ed25519.PublicKey
+		if private != nil {
+			priv = private.(ed25519.PrivateKey)
+		}
+		if public != nil {
+			pub = public.(ed25519.PublicKey)
+		}
+		return NewEdDSAKey(kc.ID, priv, pub)
+	default:
+		return nil, fmt.Errorf("jwt key %s: unsupported algorithm %q", kc.ID, kc.Algorithm)
+	}
+}
+
+// readPEMKeys 读取PEM格式的私钥和公钥文件，未配置的文件返回零值
+func readPEMKeys[Priv, Pub any](kc config.JWTKeyConfig, parsePriv func([]byte) (Priv, error), parsePub func([]byte) (Pub, error)) (Priv, Pub, error) {
+	var private Priv
+	var public Pub
+	if kc.PrivateKeyFile == "" && kc.PublicKeyFile == "" {
+		return private, public, fmt.Errorf("jwt key %s: private_key_file or public_key_file required", kc.ID)
+	}
+	if kc.PrivateKeyFile != "" {
+		data, err := os.ReadFile(kc.PrivateKeyFile)
+		if err != nil {
+			return private, public, fmt.Errorf("jwt key %s: %w", kc.ID, err)
+		}
+		if private, err = parsePriv(data); err != nil {
+			return private, public, fmt.Errorf("jwt key %s: parse private key: %w", kc.ID, err)
+		}
+	}
+	if kc.PublicKeyFile != "" {
+		data, err := os.ReadFile(kc.PublicKeyFile)
+		if err != nil {
+			return private, public, fmt.Errorf("jwt key %s: %w", kc.ID, err)
+		}
+		if public, err = parsePub(data); err != nil {
+			return private, public, fmt.Errorf("jwt key %s: parse public key: %w", kc.ID, err)
+		}
+	}
+	return private, public, nil
+}
+
+// sign 使用当前签名密钥签发令牌，令牌头中带上 kid
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.signKey)
+}
+
+// keyFunc 按 kid 查找验证密钥，并要求令牌的算法与密钥登记的算法一致，防止算法混淆攻击
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := ks.signing
+	if kid, ok := token.Header["kid"]; ok {
+		id, _ := kid.(string)
+		if key, ok = ks.keys[id]; !ok {
+			return nil, fmt.Errorf("unknown jwt key id %q", id)
+		}
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected jwt signing method " + token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// algorithms 集合中所有密钥使用的算法
+func (ks *KeySet) algorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, k := range ks.keys {
+		if !seen[k.method.Alg()] {
+			seen[k.method.Alg()] = true
+			algs = append(algs, k.method.Alg())
+		}
+	}
+	return algs
+}
@@ -0,0 +1,342 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/zhanglegen/go_task/go_gin/config"
+)
+
+func mustHMACKey(t *testing.T, id, secret string) *SigningKey {
+	t.Helper()
+	k, err := NewHMACKey(id, []byte(secret))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+	return k
+}
+
+func mustKeySet(t *testing.T, signingID string, keys ...*SigningKey) *KeySet {
+	t.Helper()
+	ks, err := NewKeySet(signingID, keys...)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return ks
+}
+
+func testClaims(exp time.Time) *Claims {
+	return &Claims{
+		UserID: 1,
+		Role:   "author",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			ExpiresAt: jwt.NewNumericDate(exp),
+			Issuer:    tokenIssuer,
+		},
+	}
+}
+
+// signToken 用任意算法和密钥签发令牌，kid 为空时不写入令牌头
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return s
+}
+
+func TestNewKeySetErrors(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	verifyOnly, err := NewRSAKey("public", nil, &rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("NewRSAKey: %v", err)
+	}
+	a := mustHMACKey(t, "a", "secret-a")
+
+	tests := []struct {
+		name      string
+		signingID string
+		keys      []*SigningKey
+		want      string
+	}{
+		{"duplicate id", "a", []*SigningKey{a, mustHMACKey(t, "a", "secret-b")}, "duplicate jwt key id"},
+		{"unknown signing key", "b", []*SigningKey{a}, `jwt signing key "b" not found`},
+		{"signing key without private key", "public", []*SigningKey{a, verifyOnly}, "has no private key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeySet(tt.signingID, tt.keys...); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewKeySet error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewHMACKey("empty", nil); err == nil {
+		t.Error("NewHMACKey accepted an empty secret")
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	rsaPublicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: mustMarshalPKIX(t, &rsaKey.PublicKey)})
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+
+	hmacKey := mustHMACKey(t, "hs", "hmac-secret")
+	rsaSigning, _ := NewRSAKey("rs", rsaKey, nil)
+	edSigning, _ := NewEdDSAKey("ed", edPrivate, nil)
+	// 同时包含 HS256 和 RS256 的集合，WithValidMethods 放行两种算法，只能靠 keyFunc 防止算法混淆
+	mixed := mustKeySet(t, "rs", hmacKey, rsaSigning, edSigning)
+
+	valid := time.Now().Add(time.Hour)
+	tests := []struct {
+		name  string
+		token func() string
+		ok    bool
+	}{
+		{"rs256 with kid", func() string { return signToken(t, jwt.SigningMethodRS256, rsaKey, "rs", testClaims(valid)) }, true},
+		{"hs256 with kid", func() string {
+			return signToken(t, jwt.SigningMethodHS256, []byte("hmac-secret"), "hs", testClaims(valid))
+		}, true},
+		{"eddsa with kid", func() string { return signToken(t, jwt.SigningMethodEdDSA, edPrivate, "ed", testClaims(valid)) }, true},
+		{"no kid uses signing key", func() string { return signToken(t, jwt.SigningMethodRS256, rsaKey, "", testClaims(valid)) }, true},
+		{"unknown kid", func() string {
+			return signToken(t, jwt.SigningMethodHS256, []byte("hmac-secret"), "other", testClaims(valid))
+		}, false},
+		{"non-string kid", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims(valid))
+			token.Header["kid"] = 42
+			s, _ := token.SignedString([]byte("hmac-secret"))
+			return s
+		}, false},
+		{"hs256 signed with rsa public key", func() string {
+			return signToken(t, jwt.SigningMethodHS256, rsaPublicPEM, "rs", testClaims(valid))
+		}, false},
+		{"rs256 token claiming the hmac kid", func() string { return signToken(t, jwt.SigningMethodRS256, rsaKey, "hs", testClaims(valid)) }, false},
+		{"hs256 without kid against rsa signing key", func() string {
+			return signToken(t, jwt.SigningMethodHS256, rsaPublicPEM, "", testClaims(valid))
+		}, false},
+		{"alg none", func() string {
+			return signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "rs", testClaims(valid))
+		}, false},
+		{"wrong hmac secret", func() string { return signToken(t, jwt.SigningMethodHS256, []byte("guess"), "hs", testClaims(valid)) }, false},
+		{"expired", func() string {
+			return signToken(t, jwt.SigningMethodRS256, rsaKey, "rs", testClaims(time.Now().Add(-time.Minute)))
+		}, false},
+		{"wrong issuer", func() string {
+			c := testClaims(valid)
+			c.Issuer = "someone-else"
+			return signToken(t, jwt.SigningMethodRS256, rsaKey, "rs", c)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			InitJWT(mixed, time.Minute)
+			claims, err := ParseToken(tt.token())
+			if tt.ok && (err != nil || claims.UserID != 1) {
+				t.Errorf("ParseToken = %+v, %v, want valid claims", claims, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("ParseToken accepted the token")
+			}
+		})
+	}
+}
+
+// TestKeyFunc keyFunc 按 kid 选择密钥，令牌算法与密钥登记的算法不一致时直接拒绝，
+// 不依赖签名库对密钥类型的检查
+func TestKeyFunc(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	hmacKey := mustHMACKey(t, "hs", "hmac-secret")
+	rsaSigning, _ := NewRSAKey("rs", rsaKey, nil)
+	ks := mustKeySet(t, "rs", hmacKey, rsaSigning)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    interface{} // nil 表示令牌头中没有 kid
+		want   interface{} // nil 表示应当拒绝
+	}{
+		{"hmac kid", jwt.SigningMethodHS256, "hs", hmacKey.verifyKey},
+		{"rsa kid", jwt.SigningMethodRS256, "rs", rsaSigning.verifyKey},
+		{"no kid uses signing key", jwt.SigningMethodRS256, nil, rsaSigning.verifyKey},
+		{"unknown kid", jwt.SigningMethodHS256, "retired", nil},
+		{"non-string kid", jwt.SigningMethodHS256, 42, nil},
+		{"hs256 with rsa kid", jwt.SigningMethodHS256, "rs", nil},
+		{"rs256 with hmac kid", jwt.SigningMethodRS256, "hs", nil},
+		{"hs256 without kid against rsa signing key", jwt.SigningMethodHS256, nil, nil},
+		{"eddsa with rsa kid", jwt.SigningMethodEdDSA, "rs", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwt.New(tt.method)
+			if tt.kid != nil {
+				token.Header["kid"] = tt.kid
+			}
+			key, err := ks.keyFunc(token)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("keyFunc returned key %T, want error", key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("keyFunc: %v", err)
+			}
+			if !reflect.DeepEqual(key, tt.want) {
+				t.Errorf("keyFunc returned %T, want %T", key, tt.want)
+			}
+		})
+	}
+}
+
+// TestKeyRotation 切换签名密钥后旧令牌在旧密钥保留期间仍然有效，旧密钥移除后失效
+func TestKeyRotation(t *testing.T) {
+	oldKey := mustHMACKey(t, "2024", "old-secret")
+	newKey := mustHMACKey(t, "2025", "new-secret")
+
+	InitJWT(mustKeySet(t, "2024", oldKey), time.Minute)
+	oldToken, err := GenerateToken(1, "alice", "author", "sid")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	// 第一步：加入新密钥并切换签名密钥
+	InitJWT(mustKeySet(t, "2025", oldKey, newKey), time.Minute)
+	newToken, err := GenerateToken(1, "alice", "author", "sid")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if err != nil || parsed.Header["kid"] != "2025" {
+		t.Fatalf("new token kid = %v, %v, want 2025", parsed.Header["kid"], err)
+	}
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := ParseToken(token); err != nil {
+			t.Errorf("%s token during rotation: %v", name, err)
+		}
+	}
+
+	// 第二步：旧令牌全部过期后移除旧密钥
+	InitJWT(mustKeySet(t, "2025", newKey), time.Minute)
+	if _, err := ParseToken(oldToken); err == nil {
+		t.Error("token signed by a retired key is still accepted")
+	}
+	if _, err := ParseToken(newToken); err != nil {
+		t.Errorf("new token after retiring old key: %v", err)
+	}
+}
+
+func mustMarshalPKIX(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return der
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatalf("marshal ed25519 key: %v", err)
+	}
+	rsaPrivateFile := writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	rsaPublicFile := writePEM(t, dir, "rsa.pub", "PUBLIC KEY", mustMarshalPKIX(t, &rsaKey.PublicKey))
+	edPrivateFile := writePEM(t, dir, "ed.pem", "PRIVATE KEY", edDER)
+	edPublicFile := writePEM(t, dir, "ed.pub", "PUBLIC KEY", mustMarshalPKIX(t, edPublic))
+
+	tests := []struct {
+		name string
+		cfg  config.JWTConfig
+		want string // 为空表示加载成功
+	}{
+		{"legacy secret", config.JWTConfig{Secret: "s"}, ""},
+		{"rsa signing key", config.JWTConfig{SigningKeyID: "rs", Keys: []config.JWTKeyConfig{
+			{ID: "rs", Algorithm: AlgRS256, PrivateKeyFile: rsaPrivateFile},
+		}}, ""},
+		{"eddsa signing key with rsa verify-only key", config.JWTConfig{SigningKeyID: "ed", Keys: []config.JWTKeyConfig{
+			{ID: "ed", Algorithm: AlgEdDSA, PrivateKeyFile: edPrivateFile},
+			{ID: "rs", Algorithm: AlgRS256, PublicKeyFile: rsaPublicFile},
+		}}, ""},
+		{"verify-only signing key", config.JWTConfig{SigningKeyID: "ed", Keys: []config.JWTKeyConfig{
+			{ID: "ed", Algorithm: AlgEdDSA, PublicKeyFile: edPublicFile},
+		}}, "has no private key"},
+		{"missing key file", config.JWTConfig{SigningKeyID: "rs", Keys: []config.JWTKeyConfig{
+			{ID: "rs", Algorithm: AlgRS256, PrivateKeyFile: filepath.Join(dir, "missing.pem")},
+		}}, "no such file"},
+		{"wrong key type", config.JWTConfig{SigningKeyID: "rs", Keys: []config.JWTKeyConfig{
+			{ID: "rs", Algorithm: AlgRS256, PrivateKeyFile: edPrivateFile},
+		}}, "parse private key"},
+		{"unsupported algorithm", config.JWTConfig{SigningKeyID: "x", Keys: []config.JWTKeyConfig{
+			{ID: "x", Algorithm: "HS512", Secret: "s"},
+		}}, "unsupported algorithm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := LoadKeySet(tt.cfg)
+			if tt.want != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("LoadKeySet error = %v, want containing %q", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKeySet: %v", err)
+			}
+			InitJWT(ks, time.Minute)
+			token, err := GenerateToken(1, "alice", "author", "sid")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			if _, err := ParseToken(token); err != nil {
+				t.Errorf("ParseToken: %v", err)
+			}
+		})
+	}
+}
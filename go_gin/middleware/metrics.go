@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/telemetry"
+)
+
+// unmatchedRoute 没有匹配任何路由的请求使用的 route 标签，避免按原始路径打标签导致基数膨胀
+const unmatchedRoute = "unmatched"
+
+// Metrics 按方法、路由模板和状态码记录请求数和耗时
+func Metrics(m *telemetry.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		done := m.RequestStarted()
+		defer done()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
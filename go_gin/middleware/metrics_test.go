@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/telemetry"
+)
+
+// scrape 以 Prometheus 文本格式读取指标
+func scrape(t *testing.T, m *telemetry.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("scrape status = %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetricsLabels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := telemetry.NewMetrics()
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.Use(Metrics(m), ErrorHandler())
+	r.NoRoute(NotFoundHandler)
+	r.NoMethod(MethodNotAllowedHandler)
+	r.GET("/posts/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.Error(apperr.NotFound("Post not found"))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/posts/1"},
+		{http.MethodGet, "/posts/2"},
+		{http.MethodGet, "/posts/0"},
+		{http.MethodGet, "/no/such/path"},
+		{http.MethodGet, "/another/missing/path"},
+		{http.MethodPost, "/posts/1"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	out := scrape(t, m)
+	// 路由标签使用路由模板而不是原始路径，未匹配的请求合并为 unmatched
+	for _, want := range []string{
+		`blog_http_requests_total{method="GET",route="/posts/:id",status="200"} 2`,
+		`blog_http_requests_total{method="GET",route="/posts/:id",status="404"} 1`,
+		`blog_http_requests_total{method="GET",route="unmatched",status="404"} 2`,
+		`blog_http_requests_total{method="POST",route="unmatched",status="405"} 1`,
+		`blog_http_request_duration_seconds_count{method="GET",route="/posts/:id",status="200"} 2`,
+		`blog_http_requests_in_flight 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+	for _, raw := range []string{"/posts/1", "/no/such/path"} {
+		if strings.Contains(out, `route="`+raw+`"`) {
+			t.Errorf("metrics use raw path %s as route label", raw)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/ratelimit"
+	"github.com/zhanglegen/go_task/go_gin/utils"
+)
+
+// RateLimitKey 限流的维度
+type RateLimitKey int
+
+const (
+	// ByIP 按客户端IP限流
+	ByIP RateLimitKey = iota
+	// ByUser 按登录用户限流，未登录时退化为按IP，需放在 AuthMiddleware 之后
+	ByUser
+)
+
+// RateLimit 令牌桶限流中间件，name 区分不同的路由分组，各分组的令牌桶互不影响
+// 每个响应都带有 X-RateLimit-Limit/Remaining/Reset 头，超限时返回 429 和 Retry-After
+// 存储出错时放行请求并记录日志，避免限流存储故障导致服务不可用
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit, by RateLimitKey) gin.HandlerFunc {
+	if !limit.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		key := rateLimitKey(c, name, by)
+		res, err := store.Take(c.Request.Context(), key, limit)
+		if err != nil {
+			utils.Logger(c.Request.Context()).Error("Rate limit store failed", "key", key, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(res.ResetAfter).Unix(), 10))
+		if !res.Allowed {
+			SetRetryAfter(c, res.RetryAfter)
+			AbortWithError(c, apperr.TooManyRequests("Too many requests, please retry later"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetRetryAfter 设置 Retry-After 响应头，单位为秒，向上取整
+func SetRetryAfter(c *gin.Context, d time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+func rateLimitKey(c *gin.Context, name string, by RateLimitKey) string {
+	if by == ByUser {
+		if userID, ok := c.Get("userID"); ok {
+			return fmt.Sprintf("rl:%s:user:%v", name, userID)
+		}
+	}
+	return fmt.Sprintf("rl:%s:ip:%s", name, c.ClientIP())
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/ratelimit"
+)
+
+// failingStore 每次操作都失败的限流存储
+type failingStore struct{ ratelimit.Store }
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func newRateLimitRouter(store ratelimit.Store, limit ratelimit.Limit, by RateLimitKey) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler(), func(c *gin.Context) {
+		if id := c.GetHeader("X-User"); id != "" {
+			c.Set("userID", id)
+		}
+	})
+	r.GET("/", RateLimit(store, "test", limit, by), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func doRateLimited(r *gin.Engine, ip, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = ip + ":1234"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	// 每分钟补充一个令牌，测试期间不会补充
+	r := newRateLimitRouter(ratelimit.NewMemoryStore(), ratelimit.Every(1, time.Minute, 2), ByIP)
+
+	for i, remaining := range []string{"1", "0"} {
+		w := doRateLimited(r, "10.0.0.1", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 2", i+1, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %s", i+1, got, remaining)
+		}
+		reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() || reset > time.Now().Add(2*time.Minute+time.Second).Unix() {
+			t.Errorf("request %d: X-RateLimit-Reset = %q, want a unix time within the refill period", i+1, w.Header().Get("X-RateLimit-Reset"))
+		}
+		if got := w.Header().Get("Retry-After"); got != "" {
+			t.Errorf("request %d: Retry-After = %q on an allowed request", i+1, got)
+		}
+	}
+
+	w := doRateLimited(r, "10.0.0.1", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 0", got)
+	}
+
+	// 其他IP使用自己的令牌桶
+	if w := doRateLimited(r, "10.0.0.2", ""); w.Code != http.StatusOK {
+		t.Errorf("other IP status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	r := newRateLimitRouter(ratelimit.NewMemoryStore(), ratelimit.Every(1, time.Minute, 1), ByUser)
+
+	if w := doRateLimited(r, "10.0.0.1", "1"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	// 同一用户换IP仍然受限，同一IP的其他用户不受影响
+	if w := doRateLimited(r, "10.0.0.2", "1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("same user from another IP: status = %d, want 429", w.Code)
+	}
+	if w := doRateLimited(r, "10.0.0.1", "2"); w.Code != http.StatusOK {
+		t.Errorf("other user: status = %d, want 200", w.Code)
+	}
+	// 未登录时按IP计数
+	if w := doRateLimited(r, "10.0.0.1", ""); w.Code != http.StatusOK {
+		t.Errorf("anonymous: status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitDisabledAndStoreFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		store ratelimit.Store
+		limit ratelimit.Limit
+	}{
+		{"disabled", ratelimit.NewMemoryStore(), ratelimit.Limit{}},
+		{"store failure", failingStore{}, ratelimit.Every(1, time.Minute, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRateLimitRouter(tt.store, tt.limit, ByIP)
+			for i := 0; i < 3; i++ {
+				w := doRateLimited(r, "10.0.0.1", "")
+				if w.Code != http.StatusOK {
+					t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+				}
+				if got := w.Header().Get("X-RateLimit-Limit"); got != "" {
+					t.Errorf("request %d: X-RateLimit-Limit = %q, want no header", i+1, got)
+				}
+			}
+		})
+	}
+}
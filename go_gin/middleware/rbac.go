@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+)
+
+// RequirePermission 权限检查中间件，需放在 AuthMiddleware 之后
+func RequirePermission(perm rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CurrentRole(c).Can(perm) {
+			AbortWithError(c, apperr.Forbidden("Permission denied"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentRole 当前登录用户的角色，未登录时为空角色，不拥有任何权限
+func CurrentRole(c *gin.Context) rbac.Role {
+	role, _ := c.Get("role")
+	r, _ := role.(rbac.Role)
+	return r
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans 把全局 TracerProvider 换成记录到内存的实现
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func spanAttrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := recordSpans(t)
+
+	r := gin.New()
+	r.Use(Tracing(), ErrorHandler())
+	r.NoRoute(NotFoundHandler)
+	r.GET("/posts/:id", func(c *gin.Context) {
+		c.Set("userID", uint(7))
+		if c.Param("id") == "0" {
+			c.Error(apperr.Internal("Internal server error", nil))
+			return
+		}
+		if !trace.SpanContextFromContext(c.Request.Context()).IsValid() {
+			t.Error("request context has no span")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	req.Header.Set("traceparent", parent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/0", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(spans))
+	}
+
+	ok := spans[0]
+	attrs := spanAttrs(ok)
+	if ok.Name() != "GET /posts/:id" || ok.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span = %q kind %v, want server span named after the route", ok.Name(), ok.SpanKind())
+	}
+	if attrs["http.route"].AsString() != "/posts/:id" || attrs["url.path"].AsString() != "/posts/1" ||
+		attrs["http.response.status_code"].AsInt64() != 200 || attrs["enduser.id"].AsInt64() != 7 {
+		t.Errorf("attributes = %v", attrs)
+	}
+	// 沿用上游 traceparent 中的链路
+	if got := ok.Parent().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %s, want the upstream trace", got)
+	}
+	if ok.Status().Code == codes.Error {
+		t.Errorf("successful request marked as error")
+	}
+
+	failed := spans[1]
+	if failed.Status().Code != codes.Error || spanAttrs(failed)["http.response.status_code"].AsInt64() != 500 {
+		t.Errorf("failed span status = %v attrs %v, want error with 500", failed.Status(), spanAttrs(failed))
+	}
+	if len(failed.Events()) == 0 || failed.Events()[0].Name != "exception" {
+		t.Errorf("failed span events = %v, want the recorded error", failed.Events())
+	}
+
+	// 未匹配的路由只用方法命名，避免按原始路径产生大量span名称
+	if spans[2].Name() != "GET" {
+		t.Errorf("unmatched span name = %q, want GET", spans[2].Name())
+	}
+}
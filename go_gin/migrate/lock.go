@@ -0,0 +1,163 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockName MySQL GET_LOCK 使用的锁名
+const lockName = "blog_schema_migrations"
+
+// 锁表中的锁超过 staleLockAfter 没有刷新时视为持有者已崩溃；
+// 持有者每隔 lockRefreshInterval 刷新一次 locked_at，迁移运行多久都不会被当作过期锁
+const (
+	staleLockAfter      = 10 * time.Minute
+	lockRefreshInterval = time.Minute
+)
+
+// ErrLockTimeout 等待迁移锁超时
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// Locker 迁移锁，ctx 的截止时间即为等待锁的超时时间
+type Locker interface {
+	Lock(ctx context.Context) (unlock func() error, err error)
+}
+
+// mysqlLocker 使用 GET_LOCK 命名锁，锁与连接绑定，进程崩溃时随连接断开自动释放
+type mysqlLocker struct {
+	db   *gorm.DB
+	name string
+}
+
+func (l *mysqlLocker) Lock(ctx context.Context) (func() error, error) {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// 加锁和解锁必须在同一个连接上
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := 0
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = max(int(time.Until(deadline).Seconds()), 0)
+	}
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", l.name, timeout).Scan(&got); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		conn.Close()
+		return nil, ErrLockTimeout
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", l.name)
+		return err
+	}, nil
+}
+
+// migrationLock schema_migrations_lock 表，最多只有一行
+type migrationLock struct {
+	ID       int       `gorm:"primaryKey;autoIncrement:false"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+func (migrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// tableLocker 通过插入主键固定的一行实现互斥，适用于不支持命名锁的数据库
+type tableLocker struct {
+	db *gorm.DB
+	// staleAfter、refreshEvery 为0时使用 staleLockAfter、lockRefreshInterval
+	staleAfter   time.Duration
+	refreshEvery time.Duration
+}
+
+func (l *tableLocker) Lock(ctx context.Context) (func() error, error) {
+	db := l.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&migrationLock{}) {
+		if err := db.Migrator().CreateTable(&migrationLock{}); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		lockedAt := time.Now()
+		err := db.Create(&migrationLock{ID: 1, LockedAt: lockedAt}).Error
+		if err == nil {
+			return l.hold(lockedAt), nil
+		}
+
+		// 已被其他实例持有，清理超时的锁后重试
+		var held migrationLock
+		if err := db.First(&held, 1).Error; err == nil && time.Since(held.LockedAt) > l.stale() {
+			db.Where("id = 1 AND locked_at = ?", held.LockedAt).Delete(&migrationLock{})
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, ErrLockTimeout
+			}
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// hold 持有锁期间在后台定期刷新 locked_at，返回的 unlock 停止刷新并释放锁
+func (l *tableLocker) hold(lockedAt time.Time) func() error {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		refreshEvery := l.refreshEvery
+		if refreshEvery <= 0 {
+			refreshEvery = lockRefreshInterval
+		}
+		ticker := time.NewTicker(refreshEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				result := l.db.Model(&migrationLock{}).Where("id = 1 AND locked_at = ?", lockedAt).Update("locked_at", now)
+				switch {
+				case result.Error != nil:
+					slog.Error("Failed to refresh migration lock", "error", result.Error)
+				case result.RowsAffected == 0:
+					slog.Error("Migration lock was taken over by another instance")
+				default:
+					lockedAt = now
+				}
+			}
+		}
+	}()
+
+	return func() error {
+		close(stop)
+		<-done
+		return l.db.Where("id = 1 AND locked_at = ?", lockedAt).Delete(&migrationLock{}).Error
+	}
+}
+
+func (l *tableLocker) stale() time.Duration {
+	if l.staleAfter > 0 {
+		return l.staleAfter
+	}
+	return staleLockAfter
+}
@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 打开单连接的内存 SQLite，所有查询共享同一个库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestTableLockerRefreshesHeldLock(t *testing.T) {
+	db := newTestDB(t)
+	l := &tableLocker{db: db, staleAfter: 300 * time.Millisecond, refreshEvery: 50 * time.Millisecond}
+
+	unlock, err := l.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	// 持有时间超过 staleAfter，但一直在刷新，第二个实例不能把它当作过期锁清理
+	time.Sleep(500 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(ctx); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("second lock while held: err = %v, want ErrLockTimeout", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	unlock, err = l.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+}
+
+func TestTableLockerTakesOverStaleLock(t *testing.T) {
+	db := newTestDB(t)
+	l := &tableLocker{db: db, staleAfter: 100 * time.Millisecond}
+	if err := db.Migrator().CreateTable(&migrationLock{}); err != nil {
+		t.Fatalf("create lock table: %v", err)
+	}
+	// 模拟崩溃的实例留下的锁
+	if err := db.Create(&migrationLock{ID: 1, LockedAt: time.Now().Add(-time.Second)}).Error; err != nil {
+		t.Fatalf("insert stale lock: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err := l.Lock(ctx)
+	if err != nil {
+		t.Fatalf("lock over stale lock: %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+}
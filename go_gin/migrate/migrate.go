@@ -0,0 +1,275 @@
+// Package migrate 执行按版本号排序的数据库迁移，已执行的版本记录在 schema_migrations 表中
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrIrreversible 迁移没有提供 Down，无法回滚
+var ErrIrreversible = errors.New("migration is irreversible")
+
+// Migration 一次表结构或数据变更
+// Up/Down 在事务中执行；MySQL 的 DDL 会隐式提交，一次迁移中的多条 DDL 无法整体回滚，
+// 因此每个迁移应尽量只做一件事，并保证 Up 可以安全地重复执行
+type Migration struct {
+	Version int64  // 版本号，按 YYYYMMDDNN 或递增整数编写，必须唯一
+	Name    string // 简短描述，如 "create_tags"
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为 nil 表示不可回滚
+}
+
+// SchemaMigration schema_migrations 表，每行对应一个已执行的迁移
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // 未执行时为 nil
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	locker     Locker
+	// LockTimeout 等待其他实例释放迁移锁的最长时间
+	LockTimeout time.Duration
+}
+
+// New 创建迁移执行器，migrations 的版本号必须唯一且大于0
+// MySQL 使用 GET_LOCK 加锁，其他数据库使用 schema_migrations_lock 表加锁
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q: version must be positive", m.Name)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d: Up is required", m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+
+	var locker Locker
+	if db.Dialector.Name() == "mysql" {
+		locker = &mysqlLocker{db: db, name: lockName}
+	} else {
+		locker = &tableLocker{db: db}
+	}
+	return &Migrator{db: db, migrations: sorted, locker: locker, LockTimeout: time.Minute}, nil
+}
+
+// Latest 最新的迁移版本，没有迁移时为0
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status 返回所有迁移的执行状态，以及数据库中存在但代码中没有的版本
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	known := make(map[int64]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if rec, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &rec.AppliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	for version, rec := range applied {
+		if !known[version] {
+			statuses = append(statuses, Status{Version: version, Name: rec.Name + " (unknown)", AppliedAt: &rec.AppliedAt})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending 返回尚未执行的迁移
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up 执行全部未执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down 按版本从高到低回滚最近执行的 steps 个迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if err := m.rollback(ctx, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// To 迁移到指定版本：执行不超过 version 的未执行迁移，回滚大于 version 的已执行迁移
+// version 为0时回滚全部迁移
+func (m *Migrator) To(ctx context.Context, version int64) ([]Migration, error) {
+	if version != 0 && !m.known(version) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		// 先从高到低回滚，再从低到高执行
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > version {
+				if err := m.rollback(ctx, mig); err != nil {
+					return err
+				}
+				done = append(done, mig)
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				if err := m.apply(ctx, mig); err != nil {
+					return err
+				}
+				done = append(done, mig)
+			}
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+	start := time.Now()
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := mig.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+	}
+	slog.Info("Migration applied", "version", mig.Version, "name", mig.Name, "duration", time.Since(start).String())
+	return nil
+}
+
+func (m *Migrator) rollback(ctx context.Context, mig Migration) error {
+	if mig.Down == nil {
+		return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, ErrIrreversible)
+	}
+	start := time.Now()
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := mig.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, mig.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+	}
+	slog.Info("Migration rolled back", "version", mig.Version, "name", mig.Name, "duration", time.Since(start).String())
+	return nil
+}
+
+// applied 读取已执行的迁移，迁移记录表不存在时视为没有执行过任何迁移
+func (m *Migrator) applied(ctx context.Context) (map[int64]SchemaMigration, error) {
+	db := m.db.WithContext(ctx)
+	result := make(map[int64]SchemaMigration)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return result, nil
+	}
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+// withLock 持有迁移锁执行 fn，保证多个实例不会同时迁移
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		if err := db.Migrator().CreateTable(&SchemaMigration{}); err != nil {
+			return err
+		}
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, m.LockTimeout)
+	defer cancel()
+	unlock, err := m.locker.Lock(lockCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			slog.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+	return fn()
+}
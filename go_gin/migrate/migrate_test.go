@@ -0,0 +1,225 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// createTableMigration 创建 t<version> 表的迁移，irreversible 为 true 时没有 Down
+func createTableMigration(version int64, irreversible bool) Migration {
+	table := fmt.Sprintf("t%d", version)
+	m := Migration{
+		Version: version,
+		Name:    "create_" + table,
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE " + table + " (id INTEGER PRIMARY KEY)").Error
+		},
+	}
+	if !irreversible {
+		m.Down = func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE " + table).Error
+		}
+	}
+	return m
+}
+
+func newTestMigrator(t *testing.T, migrations ...Migration) (*Migrator, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	if len(migrations) == 0 {
+		migrations = []Migration{createTableMigration(3, false), createTableMigration(1, false), createTableMigration(2, false)}
+	}
+	m, err := New(db, migrations)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return m, db
+}
+
+// versions 迁移的版本号列表
+func versions(migrations []Migration) []int64 {
+	vs := []int64{}
+	for _, m := range migrations {
+		vs = append(vs, m.Version)
+	}
+	return vs
+}
+
+// appliedVersions 已执行的版本，并检查对应的表是否存在
+func appliedVersions(t *testing.T, m *Migrator, db *gorm.DB) []int64 {
+	t.Helper()
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	vs := []int64{}
+	for _, s := range statuses {
+		applied := s.AppliedAt != nil
+		if applied {
+			vs = append(vs, s.Version)
+		}
+		if has := db.Migrator().HasTable(fmt.Sprintf("t%d", s.Version)); has != applied {
+			t.Errorf("table t%d exists = %v, but applied = %v", s.Version, has, applied)
+		}
+	}
+	return vs
+}
+
+func TestMigratorSteps(t *testing.T) {
+	type step struct {
+		name        string
+		run         func(m *Migrator) ([]Migration, error)
+		wantDone    []int64
+		wantApplied []int64
+	}
+	ctx := context.Background()
+	up := func(m *Migrator) ([]Migration, error) { return m.Up(ctx) }
+	down := func(n int) func(m *Migrator) ([]Migration, error) {
+		return func(m *Migrator) ([]Migration, error) { return m.Down(ctx, n) }
+	}
+	to := func(v int64) func(m *Migrator) ([]Migration, error) {
+		return func(m *Migrator) ([]Migration, error) { return m.To(ctx, v) }
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "up applies in version order and is idempotent",
+			steps: []step{
+				{"up", up, []int64{1, 2, 3}, []int64{1, 2, 3}},
+				{"up again", up, []int64{}, []int64{1, 2, 3}},
+			},
+		},
+		{
+			name: "down rolls back newest first",
+			steps: []step{
+				{"up", up, []int64{1, 2, 3}, []int64{1, 2, 3}},
+				{"down 1", down(1), []int64{3}, []int64{1, 2}},
+				{"down 5", down(5), []int64{2, 1}, []int64{}},
+				{"down on empty", down(1), []int64{}, []int64{}},
+			},
+		},
+		{
+			name: "to moves in both directions",
+			steps: []step{
+				{"to 2", to(2), []int64{1, 2}, []int64{1, 2}},
+				{"to 3", to(3), []int64{3}, []int64{1, 2, 3}},
+				{"to 1", to(1), []int64{3, 2}, []int64{1}},
+				{"to 0", to(0), []int64{1}, []int64{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, db := newTestMigrator(t)
+			for _, s := range tt.steps {
+				done, err := s.run(m)
+				if err != nil {
+					t.Fatalf("%s: %v", s.name, err)
+				}
+				if got := versions(done); !reflect.DeepEqual(got, s.wantDone) {
+					t.Errorf("%s: ran %v, want %v", s.name, got, s.wantDone)
+				}
+				if got := appliedVersions(t, m, db); !reflect.DeepEqual(got, s.wantApplied) {
+					t.Errorf("%s: applied %v, want %v", s.name, got, s.wantApplied)
+				}
+			}
+		})
+	}
+}
+
+func TestMigratorPendingAndLatest(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestMigrator(t)
+	if m.Latest() != 3 {
+		t.Errorf("Latest = %d, want 3", m.Latest())
+	}
+	if _, err := m.To(ctx, 1); err != nil {
+		t.Fatalf("To(1): %v", err)
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if got := versions(pending); !reflect.DeepEqual(got, []int64{2, 3}) {
+		t.Errorf("Pending = %v, want [2 3]", got)
+	}
+}
+
+func TestMigratorErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("irreversible down", func(t *testing.T) {
+		m, db := newTestMigrator(t, createTableMigration(1, false), createTableMigration(2, true))
+		if _, err := m.Up(ctx); err != nil {
+			t.Fatalf("Up: %v", err)
+		}
+		if _, err := m.Down(ctx, 1); !errors.Is(err, ErrIrreversible) {
+			t.Errorf("Down error = %v, want ErrIrreversible", err)
+		}
+		if got := appliedVersions(t, m, db); !reflect.DeepEqual(got, []int64{1, 2}) {
+			t.Errorf("applied %v after failed rollback, want [1 2]", got)
+		}
+	})
+
+	t.Run("failed up is not recorded", func(t *testing.T) {
+		failing := Migration{Version: 2, Name: "broken", Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE t1 (id INTEGER)").Error // t1 已存在
+		}}
+		m, db := newTestMigrator(t, createTableMigration(1, false), failing)
+		if _, err := m.Up(ctx); err == nil {
+			t.Fatal("Up: want error")
+		}
+		if got := appliedVersions(t, m, db); !reflect.DeepEqual(got, []int64{1}) {
+			t.Errorf("applied %v, want [1]", got)
+		}
+	})
+
+	t.Run("unknown target version", func(t *testing.T) {
+		m, _ := newTestMigrator(t)
+		if _, err := m.To(ctx, 7); err == nil {
+			t.Error("To(7): want error")
+		}
+	})
+
+	t.Run("unknown applied version is reported", func(t *testing.T) {
+		m, db := newTestMigrator(t)
+		if _, err := m.Up(ctx); err != nil {
+			t.Fatalf("Up: %v", err)
+		}
+		if err := db.Create(&SchemaMigration{Version: 99, Name: "from_newer_release"}).Error; err != nil {
+			t.Fatalf("insert record: %v", err)
+		}
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatalf("Status: %v", err)
+		}
+		last := statuses[len(statuses)-1]
+		if last.Version != 99 || last.Name != "from_newer_release (unknown)" {
+			t.Errorf("last status = %+v, want unknown version 99", last)
+		}
+	})
+}
+
+func TestNewValidatesMigrations(t *testing.T) {
+	noop := func(*gorm.DB) error { return nil }
+	tests := map[string][]Migration{
+		"zero version":      {{Version: 0, Name: "zero", Up: noop}},
+		"missing up":        {{Version: 1, Name: "no_up"}},
+		"duplicate version": {{Version: 1, Name: "a", Up: noop}, {Version: 1, Name: "b", Up: noop}},
+	}
+	for name, migrations := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(newTestDB(t), migrations); err == nil {
+				t.Error("New: want error")
+			}
+		})
+	}
+}
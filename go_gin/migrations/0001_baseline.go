@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+// 以下结构是引入版本化迁移时 model 包的表结构快照，只用于建表
+// 之后模型的变化通过新的迁移完成，这里的结构不要随模型修改
+
+type baselineUser struct {
+	ID        uint   `gorm:"primaryKey"`
+	Username  string `gorm:"size:50;not null;unique"`
+	Password  string `gorm:"size:100;not null"`
+	Email     string `gorm:"size:100;not null;unique"`
+	Role      string `gorm:"size:20;not null;default:author"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt    `gorm:"index"`
+	Posts     []baselinePost    `gorm:"foreignKey:UserID"`
+	Comments  []baselineComment `gorm:"foreignKey:UserID"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselinePost struct {
+	ID        uint   `gorm:"primaryKey"`
+	Title     string `gorm:"size:200;not null"`
+	Content   string `gorm:"type:text;not null"`
+	UserID    uint   `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt    `gorm:"index"`
+	Comments  []baselineComment `gorm:"foreignKey:PostID"`
+}
+
+func (baselinePost) TableName() string { return "posts" }
+
+type baselineComment struct {
+	ID        uint   `gorm:"primaryKey"`
+	Content   string `gorm:"size:500;not null"`
+	UserID    uint   `gorm:"not null"`
+	PostID    uint   `gorm:"not null"`
+	ParentID  *uint  `gorm:"index"`
+	Depth     int    `gorm:"not null;default:0"`
+	Path      string `gorm:"size:255;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineComment) TableName() string { return "comments" }
+
+type baselineRefreshToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	FamilyID  string    `gorm:"size:64;not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (baselineRefreshToken) TableName() string { return "refresh_tokens" }
+
+type baselineRevokedToken struct {
+	ID        string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func (baselineRevokedToken) TableName() string { return "revoked_tokens" }
+
+// baseline 创建初始表结构
+// 使用 AutoMigrate 而不是 CreateTable，之前由启动时自动迁移建好的库执行本迁移时只会补齐缺失的列和索引
+func baseline() migrate.Migration {
+	return migrate.Migration{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AutoMigrate(
+				&baselineUser{},
+				&baselinePost{},
+				&baselineComment{},
+				&baselineRefreshToken{},
+				&baselineRevokedToken{},
+			)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(
+				&baselineRevokedToken{},
+				&baselineRefreshToken{},
+				&baselineComment{},
+				&baselinePost{},
+				&baselineUser{},
+			)
+		},
+	}
+}
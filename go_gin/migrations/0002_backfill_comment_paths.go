@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"gorm.io/gorm"
+)
+
+// backfillCommentPaths 为引入楼层回复之前创建的评论补齐 Path，这些评论都是顶层评论
+func backfillCommentPaths() migrate.Migration {
+	return migrate.Migration{
+		Version: 2,
+		Name:    "backfill_comment_paths",
+		Up: func(tx *gorm.DB) error {
+			var ids []uint
+			if err := tx.Table("comments").Where("path = '' OR path IS NULL").Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			for _, id := range ids {
+				err := tx.Table("comments").Where("id = ?", id).
+					Update("path", model.CommentPathSegment(id)).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// 只补齐数据，回滚时无需处理
+		Down: func(tx *gorm.DB) error { return nil },
+	}
+}
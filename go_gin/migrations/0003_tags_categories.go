@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type tagsCategoriesPost struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (tagsCategoriesPost) TableName() string { return "posts" }
+
+type tagsCategoriesTag struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:50;not null"`
+	Slug      string `gorm:"size:50;not null;uniqueIndex"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (tagsCategoriesTag) TableName() string { return "tags" }
+
+type tagsCategoriesCategory struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"size:50;not null"`
+	Slug        string `gorm:"size:50;not null;uniqueIndex"`
+	Description string `gorm:"size:255"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (tagsCategoriesCategory) TableName() string { return "categories" }
+
+// 关联表与 model.Post 上 many2many 标签生成的表结构一致，主键为 (post_id, tag_id)
+type tagsCategoriesPostTag struct {
+	PostID uint               `gorm:"primaryKey"`
+	TagID  uint               `gorm:"primaryKey;index"`
+	Post   tagsCategoriesPost `gorm:"constraint:OnDelete:CASCADE"`
+	Tag    tagsCategoriesTag  `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (tagsCategoriesPostTag) TableName() string { return "post_tags" }
+
+type tagsCategoriesPostCategory struct {
+	PostID     uint                   `gorm:"primaryKey"`
+	CategoryID uint                   `gorm:"primaryKey;index"`
+	Post       tagsCategoriesPost     `gorm:"constraint:OnDelete:CASCADE"`
+	Category   tagsCategoriesCategory `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (tagsCategoriesPostCategory) TableName() string { return "post_categories" }
+
+// tagsCategories 创建标签、分类以及它们与文章的多对多关联表
+func tagsCategories() migrate.Migration {
+	return migrate.Migration{
+		Version: 3,
+		Name:    "tags_categories",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.CreateTable(&tagsCategoriesTag{}, &tagsCategoriesCategory{}); err != nil {
+				return err
+			}
+			return m.CreateTable(&tagsCategoriesPostTag{}, &tagsCategoriesPostCategory{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(
+				&tagsCategoriesPostCategory{},
+				&tagsCategoriesPostTag{},
+				&tagsCategoriesCategory{},
+				&tagsCategoriesTag{},
+			)
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type postStatusPost struct {
+	ID          uint       `gorm:"primaryKey"`
+	Status      string     `gorm:"size:20;not null;default:published;index:idx_posts_status_published_at,priority:1"`
+	PublishedAt *time.Time `gorm:"index:idx_posts_status_published_at,priority:2"`
+}
+
+func (postStatusPost) TableName() string { return "posts" }
+
+// postStatus 为文章增加状态和发布时间，已有文章视为在创建时发布
+// (status, published_at) 索引用于公开列表和定时发布调度器查询到期文章
+func postStatus() migrate.Migration {
+	return migrate.Migration{
+		Version: 4,
+		Name:    "post_status",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.AddColumn(&postStatusPost{}, "Status"); err != nil {
+				return err
+			}
+			if err := m.AddColumn(&postStatusPost{}, "PublishedAt"); err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE posts SET published_at = created_at WHERE published_at IS NULL").Error; err != nil {
+				return err
+			}
+			return m.CreateIndex(&postStatusPost{}, "idx_posts_status_published_at")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropIndex(&postStatusPost{}, "idx_posts_status_published_at"); err != nil {
+				return err
+			}
+			if err := m.DropColumn(&postStatusPost{}, "PublishedAt"); err != nil {
+				return err
+			}
+			return m.DropColumn(&postStatusPost{}, "Status")
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type postRevisionsPost struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (postRevisionsPost) TableName() string { return "posts" }
+
+type postRevisionsRevision struct {
+	ID           uint   `gorm:"primaryKey"`
+	PostID       uint   `gorm:"not null;uniqueIndex:idx_post_revisions_post_number,priority:1"`
+	Number       int    `gorm:"not null;uniqueIndex:idx_post_revisions_post_number,priority:2"`
+	Title        string `gorm:"size:200;not null"`
+	Content      string `gorm:"type:text;not null"`
+	EditorID     uint   `gorm:"not null;index"`
+	RestoredFrom *int
+	CreatedAt    time.Time
+	Post         postRevisionsPost `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (postRevisionsRevision) TableName() string { return "post_revisions" }
+
+// postRevisions 创建文章修订表，已有文章以当前内容作为第1个修订，修改人为作者
+func postRevisions() migrate.Migration {
+	return migrate.Migration{
+		Version: 5,
+		Name:    "post_revisions",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&postRevisionsRevision{}); err != nil {
+				return err
+			}
+			return tx.Exec(`INSERT INTO post_revisions (post_id, number, title, content, editor_id, created_at)
+				SELECT id, 1, title, content, user_id, updated_at FROM posts`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&postRevisionsRevision{})
+		},
+	}
+}
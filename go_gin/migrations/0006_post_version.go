@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type postVersionPost struct {
+	ID      uint `gorm:"primaryKey"`
+	Version int  `gorm:"not null;default:1"`
+}
+
+func (postVersionPost) TableName() string { return "posts" }
+
+// postVersion 为文章增加乐观锁版本号，已有文章从1开始
+func postVersion() migrate.Migration {
+	return migrate.Migration{
+		Version: 6,
+		Name:    "post_version",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&postVersionPost{}, "Version")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&postVersionPost{}, "Version")
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type accountTokensUser struct {
+	ID              uint `gorm:"primaryKey"`
+	EmailVerifiedAt *time.Time
+}
+
+func (accountTokensUser) TableName() string { return "users" }
+
+type accountTokensToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Purpose   string    `gorm:"size:20;not null"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (accountTokensToken) TableName() string { return "user_tokens" }
+
+// accountTokens 为用户增加邮箱验证时间，并创建邮箱验证和重置密码的一次性令牌表
+// 已有用户的邮箱视为未验证
+func accountTokens() migrate.Migration {
+	return migrate.Migration{
+		Version: 7,
+		Name:    "account_tokens",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&accountTokensUser{}, "EmailVerifiedAt"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateTable(&accountTokensToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&accountTokensToken{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&accountTokensUser{}, "EmailVerifiedAt")
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type postEngagementPost struct {
+	ID            uint  `gorm:"primaryKey"`
+	LikeCount     int64 `gorm:"not null;default:0"`
+	BookmarkCount int64 `gorm:"not null;default:0"`
+	ViewCount     int64 `gorm:"not null;default:0"`
+}
+
+func (postEngagementPost) TableName() string { return "posts" }
+
+type postEngagementLike struct {
+	UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+	PostID    uint `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time
+	Post      postEngagementPost `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (postEngagementLike) TableName() string { return "post_likes" }
+
+type postEngagementBookmark struct {
+	UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+	PostID    uint `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time
+	Post      postEngagementPost `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (postEngagementBookmark) TableName() string { return "bookmarks" }
+
+// postEngagement 为文章增加点赞、收藏和浏览计数，并创建点赞和收藏表
+func postEngagement() migrate.Migration {
+	return migrate.Migration{
+		Version: 8,
+		Name:    "post_engagement",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"LikeCount", "BookmarkCount", "ViewCount"} {
+				if err := tx.Migrator().AddColumn(&postEngagementPost{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateTable(&postEngagementLike{}, &postEngagementBookmark{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&postEngagementBookmark{}, &postEngagementLike{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"ViewCount", "BookmarkCount", "LikeCount"} {
+				if err := tx.Migrator().DropColumn(&postEngagementPost{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type counterColumnsUser struct {
+	ID           uint  `gorm:"primaryKey"`
+	PostCount    int64 `gorm:"not null;default:0"`
+	CommentCount int64 `gorm:"not null;default:0"`
+}
+
+func (counterColumnsUser) TableName() string { return "users" }
+
+type counterColumnsPost struct {
+	ID           uint  `gorm:"primaryKey"`
+	CommentCount int64 `gorm:"not null;default:0"`
+}
+
+func (counterColumnsPost) TableName() string { return "posts" }
+
+// counterColumns 为文章增加评论数、为用户增加已发布文章数和评论数，并按现有数据回填
+func counterColumns() migrate.Migration {
+	return migrate.Migration{
+		Version: 9,
+		Name:    "counter_columns",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&counterColumnsPost{}, "CommentCount"); err != nil {
+				return err
+			}
+			for _, column := range []string{"PostCount", "CommentCount"} {
+				if err := tx.Migrator().AddColumn(&counterColumnsUser{}, column); err != nil {
+					return err
+				}
+			}
+
+			backfill := []string{
+				`UPDATE posts SET comment_count =
+					(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL)`,
+				`UPDATE users SET post_count =
+					(SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.status = 'published' AND posts.deleted_at IS NULL)`,
+				`UPDATE users SET comment_count =
+					(SELECT COUNT(*) FROM comments WHERE comments.user_id = users.id AND comments.deleted_at IS NULL)`,
+			}
+			for _, sql := range backfill {
+				if err := tx.Exec(sql).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"CommentCount", "PostCount"} {
+				if err := tx.Migrator().DropColumn(&counterColumnsUser{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(&counterColumnsPost{}, "CommentCount")
+		},
+	}
+}
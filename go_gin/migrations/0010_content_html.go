@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"github.com/zhanglegen/go_task/go_gin/markup"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type contentHTMLPost struct {
+	ID          uint `gorm:"primaryKey"`
+	Content     string
+	ContentHTML string `gorm:"type:mediumtext"`
+}
+
+func (contentHTMLPost) TableName() string { return "posts" }
+
+type contentHTMLComment struct {
+	ID          uint `gorm:"primaryKey"`
+	Content     string
+	ContentHTML string `gorm:"type:text"`
+}
+
+func (contentHTMLComment) TableName() string { return "comments" }
+
+// contentHTML 为文章和评论增加渲染后的 HTML，并按 Markdown 渲染现有内容，包括已软删除的记录
+func contentHTML() migrate.Migration {
+	return migrate.Migration{
+		Version: 10,
+		Name:    "content_html",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&contentHTMLPost{}, "ContentHTML"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().AddColumn(&contentHTMLComment{}, "ContentHTML"); err != nil {
+				return err
+			}
+
+			var posts []contentHTMLPost
+			err := tx.Select("id", "content").FindInBatches(&posts, 200, func(*gorm.DB, int) error {
+				for _, p := range posts {
+					err := tx.Model(&p).UpdateColumn("content_html", markup.Render(p.Content)).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			var comments []contentHTMLComment
+			return tx.Select("id", "content").FindInBatches(&comments, 200, func(*gorm.DB, int) error {
+				for _, c := range comments {
+					err := tx.Model(&c).UpdateColumn("content_html", markup.Render(c.Content)).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&contentHTMLComment{}, "ContentHTML"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&contentHTMLPost{}, "ContentHTML")
+		},
+	}
+}
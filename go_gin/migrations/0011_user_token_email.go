@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type userTokenEmailToken struct {
+	ID    uint   `gorm:"primaryKey"`
+	Email string `gorm:"size:100;not null;default:''"`
+}
+
+func (userTokenEmailToken) TableName() string { return "user_tokens" }
+
+// userTokenEmail 一次性令牌记录发往的邮箱
+// 已发出的令牌没有邮箱，迁移后失效，用户需要重新发送验证或重置邮件
+func userTokenEmail() migrate.Migration {
+	return migrate.Migration{
+		Version: 11,
+		Name:    "user_token_email",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&userTokenEmailToken{}, "Email")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userTokenEmailToken{}, "Email")
+		},
+	}
+}
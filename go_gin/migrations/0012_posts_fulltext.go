@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type postsFulltextPost struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (postsFulltextPost) TableName() string { return "posts" }
+
+// postsFulltextIndex 与 search 包中 MySQLSearcher 检查的索引名一致
+const postsFulltextIndex = "idx_posts_fulltext"
+
+// postsFulltext 为文章标题和内容建立 ngram 全文索引以支持中文搜索，只在 MySQL 上执行
+// 之前的版本在启动时创建了同名索引，已存在时跳过
+func postsFulltext() migrate.Migration {
+	return migrate.Migration{
+		Version: 12,
+		Name:    "posts_fulltext",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" || tx.Migrator().HasIndex(&postsFulltextPost{}, postsFulltextIndex) {
+				return nil
+			}
+			return tx.Exec("CREATE FULLTEXT INDEX " + postsFulltextIndex + " ON posts (title, content) WITH PARSER ngram").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" || !tx.Migrator().HasIndex(&postsFulltextPost{}, postsFulltextIndex) {
+				return nil
+			}
+			return tx.Migrator().DropIndex(&postsFulltextPost{}, postsFulltextIndex)
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+// sqliteRebuiltIndexes 之前的迁移在 SQLite 上回滚时删除列会重建表，这些表上的普通索引和唯一索引会丢失
+// 回滚后再次执行迁移只会加回列，不会恢复索引
+var sqliteRebuiltIndexes = []struct {
+	model interface{}
+	index string
+}{
+	{&baselineUser{}, "DeletedAt"},
+	{&baselinePost{}, "DeletedAt"},
+	{&postStatusPost{}, "idx_posts_status_published_at"},
+	{&baselineComment{}, "ParentID"},
+	{&baselineComment{}, "Path"},
+	{&baselineComment{}, "DeletedAt"},
+	{&accountTokensToken{}, "UserID"},
+	{&accountTokensToken{}, "TokenHash"},
+}
+
+// restoreSQLiteIndexes 补建 SQLite 上因回滚迁移而丢失的索引，只在 SQLite 上执行
+// 现在删除列时会保留其余索引，本迁移只修复之前回滚过的库；补建的索引属于原来的迁移，回滚时不删除
+func restoreSQLiteIndexes() migrate.Migration {
+	return migrate.Migration{
+		Version: 13,
+		Name:    "restore_sqlite_indexes",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "sqlite" {
+				return nil
+			}
+			m := tx.Migrator()
+			for _, idx := range sqliteRebuiltIndexes {
+				if m.HasIndex(idx.model, idx.index) {
+					continue
+				}
+				if err := m.CreateIndex(idx.model, idx.index); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	}
+}
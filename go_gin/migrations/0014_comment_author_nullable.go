@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"gorm.io/gorm"
+)
+
+type nullableAuthorComment struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID *uint
+}
+
+func (nullableAuthorComment) TableName() string { return "comments" }
+
+type notNullAuthorComment struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null"`
+}
+
+func (notNullAuthorComment) TableName() string { return "comments" }
+
+// commentAuthorNullable 评论作者允许为空，注销用户时评论与用户解除关联
+// 回滚时已匿名的评论无法恢复作者，存在这样的评论时拒绝回滚
+func commentAuthorNullable() migrate.Migration {
+	return migrate.Migration{
+		Version: 14,
+		Name:    "comment_author_nullable",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AlterColumn(&nullableAuthorComment{}, "UserID")
+		},
+		Down: func(tx *gorm.DB) error {
+			var anonymous int64
+			if err := tx.Table("comments").Where("user_id IS NULL").Count(&anonymous).Error; err != nil {
+				return err
+			}
+			if anonymous > 0 {
+				return fmt.Errorf("%d comments have no author", anonymous)
+			}
+			return tx.Migrator().AlterColumn(&notNullAuthorComment{}, "UserID")
+		},
+	}
+}
@@ -0,0 +1,25 @@
+// Package migrations 博客的全部数据库迁移
+// 新增迁移时在本目录添加 NNNN_name.go 并追加到 All，已发布的迁移不要再修改
+package migrations
+
+import "github.com/zhanglegen/go_task/go_gin/migrate"
+
+// All 按版本号排列的全部迁移
+func All() []migrate.Migration {
+	return []migrate.Migration{
+		baseline(),
+		backfillCommentPaths(),
+		tagsCategories(),
+		postStatus(),
+		postRevisions(),
+		postVersion(),
+		accountTokens(),
+		postEngagement(),
+		counterColumns(),
+		contentHTML(),
+		userTokenEmail(),
+		postsFulltext(),
+		restoreSQLiteIndexes(),
+		commentAuthorNullable(),
+	}
+}
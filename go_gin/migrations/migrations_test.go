@@ -0,0 +1,146 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"gorm.io/gorm"
+)
+
+func newTestMigrator(t *testing.T) (*gorm.DB, *migrate.Migrator) {
+	t.Helper()
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { model.Close(db) })
+
+	m, err := migrate.New(db, All())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	return db, m
+}
+
+// checkIndexes sqliteRebuiltIndexes 中存在的表上的索引都没有丢失
+func checkIndexes(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, idx := range sqliteRebuiltIndexes {
+		if db.Migrator().HasTable(idx.model) && !db.Migrator().HasIndex(idx.model, idx.index) {
+			t.Errorf("index %T.%s missing", idx.model, idx.index)
+		}
+	}
+}
+
+// TestRollbackKeepsIndexes SQLite 上回滚删除列的迁移不会丢失表上的其他索引
+func TestRollbackKeepsIndexes(t *testing.T) {
+	db, m := newTestMigrator(t)
+	ctx := context.Background()
+
+	checkIndexes(t, db)
+	// 回滚到 0004 之后，0006-0011 的删除列都已执行
+	if _, err := m.To(ctx, 4); err != nil {
+		t.Fatalf("To(4): %v", err)
+	}
+	checkIndexes(t, db)
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	checkIndexes(t, db)
+}
+
+// TestRestoreSQLiteIndexes 之前回滚时丢失的索引由 restore_sqlite_indexes 补建
+func TestRestoreSQLiteIndexes(t *testing.T) {
+	db, m := newTestMigrator(t)
+	ctx := context.Background()
+
+	if _, err := m.To(ctx, 12); err != nil {
+		t.Fatalf("To(12): %v", err)
+	}
+	for _, idx := range sqliteRebuiltIndexes {
+		if err := db.Migrator().DropIndex(idx.model, idx.index); err != nil {
+			t.Fatalf("drop index %T.%s: %v", idx.model, idx.index, err)
+		}
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	checkIndexes(t, db)
+}
+
+// TestAllUpDownUp 全部迁移可以在 SQLite 上执行、完整回滚并再次执行
+func TestAllUpDownUp(t *testing.T) {
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer model.Close(db)
+
+	m, err := migrate.New(db, All())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	for _, table := range []string{"users", "posts", "comments", "user_tokens", "tags", "post_revisions"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s missing after Up", table)
+		}
+	}
+
+	done, err := m.To(ctx, 0)
+	if err != nil {
+		t.Fatalf("To(0): %v", err)
+	}
+	if len(done) != len(All()) {
+		t.Errorf("rolled back %d migrations, want %d", len(done), len(All()))
+	}
+	for _, table := range []string{"users", "posts", "comments"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s still exists after rolling back everything", table)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up after rollback: %v", err)
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("%d migrations pending after Up", len(pending))
+	}
+}
+
+// TestCommentAuthorNullable 存在匿名评论时拒绝回滚 comment_author_nullable
+func TestCommentAuthorNullable(t *testing.T) {
+	db, m := newTestMigrator(t)
+	ctx := context.Background()
+
+	if err := db.Exec("INSERT INTO comments (content, post_id, user_id) VALUES ('c', 1, NULL)").Error; err != nil {
+		t.Fatalf("insert anonymous comment: %v", err)
+	}
+	if _, err := m.To(ctx, 13); err == nil {
+		t.Fatal("To(13) succeeded with anonymous comments")
+	}
+
+	if err := db.Exec("DELETE FROM comments").Error; err != nil {
+		t.Fatalf("delete comments: %v", err)
+	}
+	if _, err := m.To(ctx, 13); err != nil {
+		t.Fatalf("To(13): %v", err)
+	}
+	if err := db.Exec("INSERT INTO comments (content, post_id, user_id) VALUES ('c', 1, NULL)").Error; err == nil {
+		t.Error("user_id accepts NULL after rollback")
+	}
+	checkIndexes(t, db)
+}
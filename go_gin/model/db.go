@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// InitDb 按配置打开数据库并设置连接池，表结构由 migrations 包中的迁移维护
+// MySQL DSN 格式: username:password@tcp(host:port)/dbname?charset=utf8mb4&parseTime=True&loc=Local
+// sqlite 驱动下 dsn 可以是文件路径，也可以是 ":memory:"（内存数据库，适合测试）
+// logLevel 为 log.level，SQL日志写入 slog；只有 debug 时输出全部 SQL 和参数，其余只记录慢查询和错误且不带参数
+func InitDb(cfg config.DatabaseConfig, logLevel string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case DriverMySQL:
+		dialector = mysql.Open(cfg.DSN)
+	case DriverSQLite:
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = ":memory:"
+		}
+		dialector = openSQLite(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+
+	// 连接数据库
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: newSQLLogger(logLevel == "debug"),
+		// 把唯一键、外键等驱动错误转换为 gorm.ErrDuplicatedKey 等通用错误
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+	if cfg.Driver == DriverSQLite {
+		// 内存数据库每个连接都是独立的库，限制为单连接保证数据共享
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	slog.Info("Database connected", "driver", cfg.Driver)
+	return db, nil
+}
+
+// Close 关闭数据库连接池
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold 超过该耗时的SQL按慢查询记录
+const slowQueryThreshold = 200 * time.Millisecond
+
+// sqlLogger 把GORM日志写入 slog，使用请求上下文中的logger，SQL日志和访问日志带有相同的请求ID
+// 只有 level 为 logger.Info 时记录每条SQL和参数，其余级别记录的SQL不带参数，避免密码哈希等写入日志
+type sqlLogger struct {
+	level logger.LogLevel
+	slow  time.Duration
+}
+
+// newSQLLogger 创建GORM日志，debug 为 true 时以 Debug 级别记录全部SQL
+func newSQLLogger(debug bool) *sqlLogger {
+	level := logger.Warn
+	if debug {
+		level = logger.Info
+	}
+	return &sqlLogger{level: level, slow: slowQueryThreshold}
+}
+
+func (l *sqlLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *sqlLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		utils.Logger(ctx).InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *sqlLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		utils.Logger(ctx).WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *sqlLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		utils.Logger(ctx).ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace 记录一条SQL：出错记为 Error，慢查询记为 Warn，其余只在 debug 时记为 Debug
+// 记录不存在由调用方转换为 404，不视为错误
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+
+	var level slog.Level
+	msg := "SQL executed"
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "SQL failed"
+	case l.slow > 0 && elapsed > l.slow && l.level >= logger.Warn:
+		level, msg = slog.LevelWarn, "Slow SQL"
+	case l.level >= logger.Info:
+		level = slog.LevelDebug
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []any{"sql", sql, "duration_ms", utils.LogDuration(elapsed)}
+	if rows >= 0 {
+		attrs = append(attrs, "rows", rows)
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, "error", err)
+	}
+	utils.Logger(ctx).Log(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter 非 debug 时丢弃SQL参数，日志中的SQL保留 ? 占位符
+func (l *sqlLogger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.level >= logger.Info {
+		return sql, params
+	}
+	return sql, nil
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/zhanglegen/go_task/go_gin/utils"
+	"gorm.io/gorm"
+)
+
+type logUser struct {
+	ID       uint
+	Password string
+}
+
+// captureLogs 把 slog 默认logger替换为写入内存的 JSON logger，返回每条日志
+func captureLogs(t *testing.T) func() []map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	return func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var r map[string]any
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("decode log line %q: %v", line, err)
+			}
+			records = append(records, r)
+		}
+		buf.Reset()
+		return records
+	}
+}
+
+func TestSQLLogger(t *testing.T) {
+	tests := []struct {
+		name  string
+		debug bool
+		slow  time.Duration
+		run   func(db *gorm.DB) error
+		level string // 为空表示不应记录
+		msg   string
+		sql   string
+	}{
+		{
+			name: "successful query not logged",
+			run:  func(db *gorm.DB) error { return db.Create(&logUser{Password: "hash-secret"}).Error },
+		},
+		{
+			name: "record not found not logged",
+			run: func(db *gorm.DB) error {
+				err := db.First(&logUser{}, 42).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			},
+		},
+		{
+			name: "error logged without parameters",
+			run: func(db *gorm.DB) error {
+				db.Exec("INSERT INTO missing (password) VALUES (?)", "hash-secret")
+				return nil
+			},
+			level: "ERROR", msg: "SQL failed", sql: "INSERT INTO missing (password) VALUES (?)",
+		},
+		{
+			name:  "slow query logged without parameters",
+			slow:  time.Nanosecond,
+			run:   func(db *gorm.DB) error { return db.Create(&logUser{Password: "hash-secret"}).Error },
+			level: "WARN", msg: "Slow SQL", sql: "INSERT INTO `log_users` (`password`) VALUES (?)",
+		},
+		{
+			name:  "debug logs every query with parameters",
+			debug: true,
+			run:   func(db *gorm.DB) error { return db.Create(&logUser{Password: "hash-secret"}).Error },
+			level: "DEBUG", msg: "SQL executed", sql: "INSERT INTO `log_users` (`password`) VALUES (\"hash-secret\")",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			l := newSQLLogger(tt.debug)
+			db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: l})
+			if err != nil {
+				t.Fatalf("open db: %v", err)
+			}
+			if err := db.AutoMigrate(&logUser{}); err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			logs()
+
+			l.slow = tt.slow
+			if err := tt.run(db); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			records := logs()
+			if tt.level == "" {
+				if len(records) != 0 {
+					t.Errorf("logged %v, want nothing", records)
+				}
+				return
+			}
+			// debug 时 RETURNING 等附加语句也会记录，只检查最后一条
+			if len(records) == 0 {
+				t.Fatal("nothing logged")
+			}
+			r := records[len(records)-1]
+			if r["level"] != tt.level || r["msg"] != tt.msg {
+				t.Errorf("record = %v, want level %s msg %q", r, tt.level, tt.msg)
+			}
+			if sql, _ := r["sql"].(string); !strings.HasPrefix(sql, tt.sql) {
+				t.Errorf("sql = %q, want prefix %q", sql, tt.sql)
+			}
+			if _, ok := r["duration_ms"].(float64); !ok {
+				t.Errorf("record has no duration_ms: %v", r)
+			}
+			if !tt.debug {
+				for _, rec := range records {
+					if strings.Contains(rec["sql"].(string), "hash-secret") {
+						t.Errorf("parameter leaked into log: %v", rec)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestSQLLoggerUsesContextLogger SQL日志使用请求上下文中的logger，带上请求ID等字段
+func TestSQLLoggerUsesContextLogger(t *testing.T) {
+	logs := captureLogs(t)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: newSQLLogger(true)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	logs()
+
+	ctx := utils.WithLogger(context.Background(), slog.Default().With("request_id", "req-1"))
+	db.WithContext(ctx).Exec("SELECT 1")
+
+	records := logs()
+	if len(records) != 1 || records[0]["request_id"] != "req-1" {
+		t.Errorf("records = %v, want one record with request_id", records)
+	}
+}
@@ -1,79 +1,181 @@
 package model
 
 import (
-	"log"
+	"fmt"
 	"time"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
 // User 模型表示系统中的用户
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Username  string         `gorm:"size:50;not null;unique" json:"username"` // 用户名，唯一
-	Password  string         `gorm:"size:100;not null" json:"password"`      // 密码
-	Email     string         `gorm:"size:100;not null;unique" json:"email"`   // 邮箱，唯一
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // 软删除字段
-	Posts     []Post         `gorm:"foreignKey:UserID" json:"posts,omitempty"` // 用户的文章
-	Comments  []Comment      `gorm:"foreignKey:UserID" json:"comments,omitempty"` // 用户的评论
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Username        string         `gorm:"size:50;not null;unique" json:"username"`     // 用户名，唯一
+	Password        string         `gorm:"size:100;not null" json:"-"`                  // 密码的bcrypt哈希，任何响应中都不输出
+	Email           string         `gorm:"size:100;not null;unique" json:"email"`       // 邮箱，唯一
+	Role            string         `gorm:"size:20;not null;default:author" json:"role"` // 角色：admin、moderator、author、reader
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`                           // 邮箱验证时间，未验证或修改邮箱后为空
+	PostCount       int64          `gorm:"not null;default:0" json:"post_count"`        // 已发布的文章数，发布、撤回和删除文章时在同一事务中更新
+	CommentCount    int64          `gorm:"not null;default:0" json:"comment_count"`     // 评论数，发表和删除评论时在同一事务中更新
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`                              // 软删除字段
+	Posts           []Post         `gorm:"foreignKey:UserID" json:"posts,omitempty"`    // 用户的文章
+	Comments        []Comment      `gorm:"foreignKey:UserID" json:"comments,omitempty"` // 用户的评论
 }
 
 // Post 模型表示博客文章
 type Post struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Title     string         `gorm:"size:200;not null" json:"title"`    // 文章标题
-	Content   string         `gorm:"type:text;not null" json:"content"` // 文章内容
-	UserID    uint           `gorm:"not null" json:"user_id"`           // 关联的用户ID
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // 软删除字段
-	User      User           `gorm:"foreignKey:UserID" json:"user,omitempty"` // 文章作者
-	Comments  []Comment      `gorm:"foreignKey:PostID" json:"comments,omitempty"` // 文章的评论
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Title         string         `gorm:"size:200;not null" json:"title"`                   // 文章标题
+	Content       string         `gorm:"type:text;not null" json:"content"`                // 文章内容
+	ContentHTML   string         `gorm:"type:mediumtext" json:"content_html"`              // 由 Content 渲染并过滤后的 HTML，随 Content 一起写入
+	UserID        uint           `gorm:"not null" json:"user_id"`                          // 关联的用户ID
+	Status        string         `gorm:"size:20;not null;default:published" json:"status"` // 文章状态，只有 published 对所有人可见
+	PublishedAt   *time.Time     `json:"published_at"`                                     // 发布时间，定时发布时为计划时间，草稿为空
+	Version       int            `gorm:"not null;default:1" json:"version"`                // 乐观锁版本号，每次修改加1
+	LikeCount     int64          `gorm:"not null;default:0" json:"like_count"`             // 点赞数，点赞和取消时在同一事务中更新
+	BookmarkCount int64          `gorm:"not null;default:0" json:"bookmark_count"`         // 收藏数，收藏和取消时在同一事务中更新
+	ViewCount     int64          `gorm:"not null;default:0" json:"view_count"`             // 浏览数，在内存中累计后定期批量写入
+	CommentCount  int64          `gorm:"not null;default:0" json:"comment_count"`          // 未删除的评论数，发表和删除评论时在同一事务中更新
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`                                        // 软删除字段
+	User          User           `gorm:"foreignKey:UserID" json:"user,omitempty"`               // 文章作者
+	Comments      []Comment      `gorm:"foreignKey:PostID" json:"comments,omitempty"`           // 文章的评论
+	Tags          []Tag          `gorm:"many2many:post_tags" json:"tags,omitempty"`             // 文章标签
+	Categories    []Category     `gorm:"many2many:post_categories" json:"categories,omitempty"` // 文章分类
+}
+
+// 文章状态
+const (
+	PostStatusDraft     = "draft"     // 草稿，只有作者、版主和管理员可见
+	PostStatusScheduled = "scheduled" // 定时发布，到 PublishedAt 时由调度器发布
+	PostStatusPublished = "published" // 已发布，所有人可见
+	PostStatusArchived  = "archived"  // 已归档，不再公开
+)
+
+// Published 文章是否已发布
+func (p *Post) Published() bool {
+	return p.Status == PostStatusPublished
+}
+
+// PostRevision 文章修订记录，每次创建和更新文章时保存标题和内容的完整快照
+type PostRevision struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	PostID       uint      `gorm:"not null" json:"post_id"`           // 关联的文章ID
+	Number       int       `gorm:"not null" json:"number"`            // 文章内从1开始递增的修订号
+	Title        string    `gorm:"size:200;not null" json:"title"`    // 文章标题快照
+	Content      string    `gorm:"type:text;not null" json:"content"` // 文章内容快照
+	EditorID     uint      `gorm:"not null" json:"editor_id"`         // 本次修改的用户ID
+	RestoredFrom *int      `json:"restored_from"`                     // 从哪个修订号恢复而来，普通修改为空
+	CreatedAt    time.Time `json:"created_at"`
+	Editor       User      `gorm:"foreignKey:EditorID" json:"editor,omitempty"` // 修改人
+}
+
+// PostLike 用户对文章的点赞，每个用户对每篇文章只能点赞一次
+type PostLike struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	PostID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Bookmark 用户收藏的文章，每个用户对每篇文章只能收藏一次
+type Bookmark struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	PostID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Post      Post      `gorm:"foreignKey:PostID" json:"post,omitempty"` // 收藏的文章
 }
 
 // Comment 模型表示文章评论
 type Comment struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Content   string         `gorm:"size:500;not null" json:"content"` // 评论内容
-	UserID    uint           `gorm:"not null" json:"user_id"`          // 关联的用户ID
-	PostID    uint           `gorm:"not null" json:"post_id"`          // 关联的文章ID
-	CreatedAt time.Time      `json:"created_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // 软删除字段
-	User      User           `gorm:"foreignKey:UserID" json:"user,omitempty"` // 评论作者
-	Post      Post           `gorm:"foreignKey:PostID" json:"post,omitempty"` // 评论的文章
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Content     string         `gorm:"size:500;not null" json:"content"` // 评论内容
+	ContentHTML string         `gorm:"type:text" json:"content_html"`    // 由 Content 渲染并过滤后的 HTML
+	UserID      *uint          `json:"user_id"`                          // 关联的用户ID，作者注销后为空
+	PostID      uint           `gorm:"not null" json:"post_id"`          // 关联的文章ID
+	ParentID    *uint          `gorm:"index" json:"parent_id"`           // 回复的评论ID，顶层评论为空
+	Depth       int            `gorm:"not null;default:0" json:"depth"`  // 嵌套层级，顶层评论为0
+	Path        string         `gorm:"size:255;index" json:"-"`          // 祖先ID路径，用于按楼层顺序排序和查询子树
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`                          // 软删除字段
+	User        User           `gorm:"foreignKey:UserID" json:"user,omitempty"` // 评论作者
+	Post        Post           `gorm:"foreignKey:PostID" json:"post,omitempty"` // 评论的文章
 }
 
-func InitDb() error {
-	// 数据库连接配置
-	// 格式: username:password@tcp(host:port)/dbname?charset=utf8mb4&parseTime=True&loc=Local
-	dsn := "root:123321as@tcp(127.0.0.1:3306)/gorm?charset=utf8mb4&parseTime=True&loc=Local"
-
-	// 连接数据库
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info), // 显示SQL日志
-	})
-	if err != nil {
-		return err
+// AuthorID 评论作者的用户ID，作者已注销时为0
+func (c *Comment) AuthorID() uint {
+	if c.UserID == nil {
+		return 0
 	}
+	return *c.UserID
+}
 
-	// 自动迁移创建表
-	// AutoMigrate会根据模型结构创建表，已存在的表会被修改但不会删除数据
-	err = DB.AutoMigrate(
-		&User{},
-		&Post{},
-		&Comment{},
-	)
-	if err != nil {
-		return err
-	}
+// MaxCommentDepth 评论最大嵌套层级，受 Path 字段长度限制
+const MaxCommentDepth = 20
+
+// CommentPathSegment 把评论ID格式化为定长的路径片段，保证路径按字符串排序即为楼层顺序
+func CommentPathSegment(id uint) string {
+	return fmt.Sprintf("%010d", id)
+}
+
+// Tag 文章标签，作者发表文章时按名称自动创建，Slug 由名称规范化生成且全局唯一
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:50;not null" json:"name"`             // 首次使用时的名称
+	Slug      string    `gorm:"size:50;not null;uniqueIndex" json:"slug"` // 用于 ?tag= 过滤和去重
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// PostCount 使用该标签的文章数，标签云查询时填充，不对应表字段
+	PostCount int64 `gorm:"->;-:migration" json:"post_count"`
+}
+
+// Category 文章分类，由管理员和版主维护
+type Category struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:50;not null" json:"name"`
+	Slug        string    `gorm:"size:50;not null;uniqueIndex" json:"slug"`
+	Description string    `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// PostCount 该分类下的文章数，列表查询时填充，不对应表字段
+	PostCount int64 `gorm:"->;-:migration" json:"post_count"`
+}
+
+// RefreshToken 服务端保存的刷新令牌，同一次登录轮换出的令牌属于同一个 FamilyID
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	FamilyID  string     `gorm:"size:64;not null;index"`       // 会话ID，对应访问令牌的 sid
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"` // 令牌的SHA-256，不保存明文
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已轮换出新令牌的时间，再次使用即视为重放
+	RevokedAt *time.Time // 被注销或强制下线的时间
+	CreatedAt time.Time
+}
+
+// UserToken 邮箱验证和重置密码使用的一次性令牌，只保存令牌的SHA-256
+type UserToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	Purpose   string     `gorm:"size:20;not null"`             // 令牌用途：verify_email 或 reset_password
+	Email     string     `gorm:"size:100;not null;default:''"` // 发出令牌时的收件邮箱，用户邮箱变更后令牌失效
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"` // 令牌的SHA-256，不保存明文
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已使用或被新令牌作废的时间
+	CreatedAt time.Time
+}
+
+// 一次性令牌的用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
 
-	log.Println("数据库表创建/迁移成功")
-	return nil
+// RevokedToken 访问令牌吊销列表，ID 为令牌的 jti 或会话的 sid
+type RevokedToken struct {
+	ID        string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"not null;index"` // 超过该时间对应的访问令牌已自然过期，可以清理
 }
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxSlugLength 标签和分类 Slug 的字符数上限，与表字段 size:50 一致
+const MaxSlugLength = 50
+
+// Slugify 把名称规范化为 Slug：字母转小写，保留各语言的字母和数字，
+// 其余字符视为分隔符并合并为单个 "-"，例如 "Go  语言/Web" 得到 "go-语言-web"
+// 名称中没有字母和数字时返回空字符串
+func Slugify(name string) string {
+	var b strings.Builder
+	count := 0
+	pendingDash := false
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingDash = count > 0
+			continue
+		}
+		if pendingDash {
+			if count+1 >= MaxSlugLength {
+				break
+			}
+			b.WriteByte('-')
+			count++
+			pendingDash = false
+		}
+		if count >= MaxSlugLength {
+			break
+		}
+		b.WriteRune(unicode.ToLower(r))
+		count++
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Go", "go"},
+		{"Hello World", "hello-world"},
+		{"Go  语言/Web", "go-语言-web"},
+		{"  leading and trailing  ", "leading-and-trailing"},
+		{"C++ & Rust!", "c-rust"},
+		{"Ünïcödé Straße", "ünïcödé-straße"},
+		{"Ελληνικά Κείμενα", "ελληνικά-κείμενα"},
+		{"日本語のタグ", "日本語のタグ"},
+		{"web3.0", "web3-0"},
+		{"٣ أرقام", "٣-أرقام"},
+		{"emoji 🚀 rocket", "emoji-rocket"},
+		// 没有字母和数字时为空
+		{"", ""},
+		{"   ", ""},
+		{"---", ""},
+		{"!@#$%", ""},
+		{"🚀🚀", ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSlugifyCollisions 大小写、空白和标点不同的名称得到相同的 Slug，由唯一索引拒绝重复
+func TestSlugifyCollisions(t *testing.T) {
+	groups := [][]string{
+		{"Go Lang", "go-lang", "GO_LANG", "  go   lang ", "Go/Lang!"},
+		{"Web 开发", "web-开发", "WEB·开发"},
+	}
+	for _, names := range groups {
+		want := Slugify(names[0])
+		for _, name := range names[1:] {
+			if got := Slugify(name); got != want {
+				t.Errorf("Slugify(%q) = %q, want %q like %q", name, got, want, names[0])
+			}
+		}
+	}
+}
+
+// TestSlugifyMaxLength 按字符数截断，不会截断在多字节字符中间，也不会以 "-" 结尾
+func TestSlugifyMaxLength(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{strings.Repeat("a", MaxSlugLength+10), strings.Repeat("a", MaxSlugLength)},
+		{strings.Repeat("语", MaxSlugLength+10), strings.Repeat("语", MaxSlugLength)},
+		{strings.Repeat("a", MaxSlugLength-1) + " b", strings.Repeat("a", MaxSlugLength-1)},
+		{strings.Repeat("a", MaxSlugLength-2) + " b", strings.Repeat("a", MaxSlugLength-2) + "-b"},
+	}
+	for _, tt := range tests {
+		got := Slugify(tt.name)
+		if got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if n := utf8.RuneCountInString(got); n > MaxSlugLength || !utf8.ValidString(got) {
+			t.Errorf("Slugify(%q) has %d characters or invalid UTF-8", tt.name, n)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"slices"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqliteDialector SQLite 驱动删除列、修改列时会新建表、复制数据后删除旧表，旧表上 CREATE INDEX 建立的索引随之丢失
+// 这里在重建表后补建其余的索引，迁移前后表上的索引保持一致
+type sqliteDialector struct {
+	sqlite.Dialector
+}
+
+func openSQLite(dsn string) gorm.Dialector {
+	return sqliteDialector{sqlite.Dialector{DSN: dsn}}
+}
+
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{Migrator: d.Dialector.Migrator(db), db: db}
+}
+
+type sqliteMigrator struct {
+	gorm.Migrator
+	db *gorm.DB
+}
+
+// sqliteIndex sqlite_master 中保存的索引定义
+type sqliteIndex struct {
+	Name string
+	SQL  string
+}
+
+// DropColumn 删除列，并重建表上不包含该列的索引
+func (m sqliteMigrator) DropColumn(value interface{}, name string) error {
+	return m.keepIndexes(value, name, func() error {
+		return m.Migrator.DropColumn(value, name)
+	})
+}
+
+// AlterColumn 修改列，并重建表上的索引
+func (m sqliteMigrator) AlterColumn(value interface{}, name string) error {
+	return m.keepIndexes(value, "", func() error {
+		return m.Migrator.AlterColumn(value, name)
+	})
+}
+
+// keepIndexes 执行 rebuild 重建表，之后补建丢失的索引；包含 dropped 列的索引不再重建
+func (m sqliteMigrator) keepIndexes(value interface{}, dropped string, rebuild func() error) error {
+	stmt := &gorm.Statement{DB: m.db}
+	if err := stmt.Parse(value); err != nil {
+		return err
+	}
+	if field := stmt.Schema.LookUpField(dropped); field != nil {
+		dropped = field.DBName
+	}
+
+	// 自动创建的主键、唯一约束索引 sql 为空，随建表语句保留，不需要重建
+	var indexes []sqliteIndex
+	if err := m.db.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL",
+		stmt.Table).Scan(&indexes).Error; err != nil {
+		return err
+	}
+	keep := indexes[:0]
+	for _, idx := range indexes {
+		var columns []string
+		if err := m.db.Raw("SELECT name FROM pragma_index_info(?)", idx.Name).Scan(&columns).Error; err != nil {
+			return err
+		}
+		if dropped == "" || !slices.Contains(columns, dropped) {
+			keep = append(keep, idx)
+		}
+	}
+
+	if err := rebuild(); err != nil {
+		return err
+	}
+	for _, idx := range keep {
+		if m.Migrator.HasIndex(stmt.Table, idx.Name) {
+			continue
+		}
+		if err := m.db.Exec(idx.SQL).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
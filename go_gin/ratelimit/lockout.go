@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LockoutPolicy 登录失败锁定策略
+// 连续失败 MaxFailures 次后锁定 BaseDelay，之后每多失败一次锁定时间翻倍，最长 MaxDelay；
+// 失败计数在最后一次失败 Window 之后清零，登录成功立即清零
+type LockoutPolicy struct {
+	MaxFailures int
+	Window      time.Duration
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Lockout 按账号记录登录失败次数并锁定
+type Lockout struct {
+	store  Store
+	policy LockoutPolicy
+	now    func() time.Time
+}
+
+// NewLockout 创建登录失败锁定器，policy.MaxFailures 为0时不锁定
+func NewLockout(store Store, policy LockoutPolicy) *Lockout {
+	return &Lockout{store: store, policy: policy, now: time.Now}
+}
+
+// LockedUntil 账号当前的锁定截止时间，未锁定时返回零值
+func (l *Lockout) LockedUntil(ctx context.Context, account string) (time.Time, error) {
+	if l.policy.MaxFailures <= 0 {
+		return time.Time{}, nil
+	}
+	return l.store.LockedUntil(ctx, lockKey(account))
+}
+
+// Fail 记录一次登录失败，达到阈值时锁定账号，返回锁定截止时间（未锁定时为零值）
+func (l *Lockout) Fail(ctx context.Context, account string) (time.Time, error) {
+	if l.policy.MaxFailures <= 0 {
+		return time.Time{}, nil
+	}
+	n, err := l.store.Incr(ctx, failKey(account), l.policy.Window)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if n < int64(l.policy.MaxFailures) {
+		return time.Time{}, nil
+	}
+
+	delay := l.policy.BaseDelay
+	for i := int64(l.policy.MaxFailures); i < n && delay < l.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, l.policy.MaxDelay)
+
+	until := l.now().Add(delay)
+	if err := l.store.Lock(ctx, lockKey(account), until); err != nil {
+		return time.Time{}, err
+	}
+	return until, nil
+}
+
+// Reset 登录成功后清除失败记录
+func (l *Lockout) Reset(ctx context.Context, account string) error {
+	if l.policy.MaxFailures <= 0 {
+		return nil
+	}
+	return l.store.Delete(ctx, failKey(account), lockKey(account))
+}
+
+// 用户名不区分大小写，避免通过大小写变化绕过锁定
+func failKey(account string) string {
+	return "lockout:fail:" + strings.ToLower(account)
+}
+
+func lockKey(account string) string {
+	return "lockout:until:" + strings.ToLower(account)
+}
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock 测试中手动推进的时钟
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLockout(policy LockoutPolicy) (*Lockout, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	l := NewLockout(store, policy)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestLockoutBackoff(t *testing.T) {
+	policy := LockoutPolicy{MaxFailures: 3, Window: time.Hour, BaseDelay: time.Minute, MaxDelay: 8 * time.Minute}
+	// 第 n 次连续失败后的锁定时长，0 表示未锁定
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 8 * time.Minute, 8 * time.Minute}
+
+	l, clock := newTestLockout(policy)
+	ctx := context.Background()
+	for i, delay := range want {
+		until, err := l.Fail(ctx, "alice")
+		if err != nil {
+			t.Fatalf("failure %d: %v", i+1, err)
+		}
+		var got time.Duration
+		if !until.IsZero() {
+			got = until.Sub(clock.now())
+		}
+		if got != delay {
+			t.Errorf("failure %d: locked for %v, want %v", i+1, got, delay)
+		}
+
+		locked, err := l.LockedUntil(ctx, "alice")
+		if err != nil {
+			t.Fatalf("LockedUntil: %v", err)
+		}
+		if !locked.Equal(until) {
+			t.Errorf("failure %d: LockedUntil = %v, want %v", i+1, locked, until)
+		}
+	}
+}
+
+func TestLockout(t *testing.T) {
+	policy := LockoutPolicy{MaxFailures: 2, Window: 10 * time.Minute, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		policy LockoutPolicy
+		// run 执行操作后返回 bob 是否处于锁定状态
+		run        func(l *Lockout, clock *fakeClock) bool
+		wantLocked bool
+	}{
+		{
+			name:   "lock expires after delay",
+			policy: policy,
+			run: func(l *Lockout, clock *fakeClock) bool {
+				l.Fail(ctx, "bob")
+				l.Fail(ctx, "bob")
+				clock.advance(time.Minute)
+				return isLocked(t, l, "bob")
+			},
+			wantLocked: false,
+		},
+		{
+			name:   "username is case insensitive",
+			policy: policy,
+			run: func(l *Lockout, clock *fakeClock) bool {
+				l.Fail(ctx, "Bob")
+				l.Fail(ctx, "BOB")
+				return isLocked(t, l, "bob")
+			},
+			wantLocked: true,
+		},
+		{
+			name:   "reset clears failures and lock",
+			policy: policy,
+			run: func(l *Lockout, clock *fakeClock) bool {
+				l.Fail(ctx, "bob")
+				l.Fail(ctx, "bob")
+				if err := l.Reset(ctx, "bob"); err != nil {
+					t.Fatalf("Reset: %v", err)
+				}
+				if isLocked(t, l, "bob") {
+					return true
+				}
+				// 计数已清零，再失败一次不会锁定
+				l.Fail(ctx, "bob")
+				return isLocked(t, l, "bob")
+			},
+			wantLocked: false,
+		},
+		{
+			name:   "failures outside window are forgotten",
+			policy: policy,
+			run: func(l *Lockout, clock *fakeClock) bool {
+				l.Fail(ctx, "bob")
+				clock.advance(11 * time.Minute)
+				l.Fail(ctx, "bob")
+				return isLocked(t, l, "bob")
+			},
+			wantLocked: false,
+		},
+		{
+			name:   "other accounts are not affected",
+			policy: policy,
+			run: func(l *Lockout, clock *fakeClock) bool {
+				l.Fail(ctx, "carol")
+				l.Fail(ctx, "carol")
+				return isLocked(t, l, "bob")
+			},
+			wantLocked: false,
+		},
+		{
+			name:   "zero max failures disables lockout",
+			policy: LockoutPolicy{},
+			run: func(l *Lockout, clock *fakeClock) bool {
+				for range 10 {
+					if until, _ := l.Fail(ctx, "bob"); !until.IsZero() {
+						return true
+					}
+				}
+				return isLocked(t, l, "bob")
+			},
+			wantLocked: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLockout(tt.policy)
+			if got := tt.run(l, clock); got != tt.wantLocked {
+				t.Errorf("locked = %v, want %v", got, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func isLocked(t *testing.T, l *Lockout, account string) bool {
+	t.Helper()
+	until, err := l.LockedUntil(context.Background(), account)
+	if err != nil {
+		t.Fatalf("LockedUntil: %v", err)
+	}
+	return !until.IsZero()
+}
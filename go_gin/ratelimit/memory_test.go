@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	return store, clock
+}
+
+func TestEvery(t *testing.T) {
+	tests := []struct {
+		requests int
+		per      time.Duration
+		burst    int
+		want     Limit
+	}{
+		{60, time.Minute, 10, Limit{Rate: 1, Burst: 10}},
+		{30, time.Minute, 0, Limit{Rate: 0.5, Burst: 30}},
+		{0, time.Minute, 10, Limit{}},
+		{10, 0, 10, Limit{}},
+	}
+	for _, tt := range tests {
+		got := Every(tt.requests, tt.per, tt.burst)
+		if got != tt.want {
+			t.Errorf("Every(%d, %v, %d) = %+v, want %+v", tt.requests, tt.per, tt.burst, got, tt.want)
+		}
+		if got.Enabled() != (tt.want.Rate > 0) {
+			t.Errorf("Every(%d, %v, %d).Enabled() = %v", tt.requests, tt.per, tt.burst, got.Enabled())
+		}
+	}
+}
+
+// TestMemoryStoreTake 每秒补充一个令牌、容量为3的令牌桶
+func TestMemoryStoreTake(t *testing.T) {
+	store, clock := newTestStore()
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	steps := []struct {
+		advance time.Duration
+		want    Result
+	}{
+		{0, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+		{0, Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second}},
+		{0, Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+		{0, Result{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: time.Second, ResetAfter: 3 * time.Second}},
+		// 半秒后只补充了半个令牌
+		{500 * time.Millisecond, Result{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: 500 * time.Millisecond, ResetAfter: 2500 * time.Millisecond}},
+		{500 * time.Millisecond, Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+		// 补满后不会超过容量
+		{time.Minute, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+	}
+	for i, step := range steps {
+		clock.advance(step.advance)
+		got, err := store.Take(ctx, "k", limit)
+		if err != nil {
+			t.Fatalf("step %d: Take: %v", i, err)
+		}
+		if got != step.want {
+			t.Errorf("step %d: Take = %+v, want %+v", i, got, step.want)
+		}
+	}
+}
+
+func TestMemoryStoreTakeKeysIndependent(t *testing.T) {
+	store, _ := newTestStore()
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if res, _ := store.Take(ctx, "a", limit); !res.Allowed {
+		t.Fatal("first take on a rejected")
+	}
+	if res, _ := store.Take(ctx, "a", limit); res.Allowed {
+		t.Error("second take on a allowed, want rejected")
+	}
+	if res, _ := store.Take(ctx, "b", limit); !res.Allowed {
+		t.Error("take on b rejected, want its own bucket")
+	}
+
+	// 删除后令牌桶重新从满开始
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := store.Take(ctx, "a", limit); !res.Allowed {
+		t.Error("take after Delete rejected")
+	}
+}
+
+func TestMemoryStoreIncr(t *testing.T) {
+	store, clock := newTestStore()
+	ctx := context.Background()
+
+	for i, advance := range []time.Duration{0, 30 * time.Second, 59 * time.Second} {
+		clock.advance(advance)
+		n, err := store.Incr(ctx, "c", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 每次加一都会重置过期时间
+		if n != int64(i+1) {
+			t.Errorf("Incr #%d = %d, want %d", i+1, n, i+1)
+		}
+	}
+	clock.advance(time.Minute)
+	if n, _ := store.Incr(ctx, "c", time.Minute); n != 1 {
+		t.Errorf("Incr after expiry = %d, want 1", n)
+	}
+}
+
+func TestMemoryStoreLock(t *testing.T) {
+	store, clock := newTestStore()
+	ctx := context.Background()
+
+	until := clock.now().Add(time.Minute)
+	if err := store.Lock(ctx, "l", until); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.LockedUntil(ctx, "l"); !got.Equal(until) {
+		t.Errorf("LockedUntil = %v, want %v", got, until)
+	}
+	clock.advance(time.Minute)
+	if got, _ := store.LockedUntil(ctx, "l"); !got.IsZero() {
+		t.Errorf("LockedUntil after expiry = %v, want zero", got)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store, clock := newTestStore()
+	ctx := context.Background()
+
+	store.Take(ctx, "bucket", Limit{Rate: 1, Burst: 2})
+	store.Incr(ctx, "counter", time.Second)
+	store.Lock(ctx, "lock", clock.now().Add(time.Second))
+	clock.advance(time.Minute)
+
+	store.ops = sweepEvery - 1
+	store.Incr(ctx, "fresh", time.Hour)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.buckets) != 0 || len(store.locks) != 0 || len(store.counters) != 1 {
+		t.Errorf("after sweep buckets=%d counters=%d locks=%d, want only the fresh counter",
+			len(store.buckets), len(store.counters), len(store.locks))
+	}
+}
@@ -0,0 +1,70 @@
+// Package ratelimit 提供令牌桶限流和登录失败锁定，状态保存在可替换的 Store 中
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit 令牌桶参数：每秒补充 Rate 个令牌，桶容量为 Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Every 每 per 时间允许 requests 次请求，突发最多 burst 次，burst 为0时等于 requests
+func Every(requests int, per time.Duration, burst int) Limit {
+	if requests <= 0 || per <= 0 {
+		return Limit{}
+	}
+	if burst <= 0 {
+		burst = requests
+	}
+	return Limit{Rate: float64(requests) / per.Seconds(), Burst: burst}
+}
+
+// Enabled 是否需要限流
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result 一次取令牌的结果，用于设置 X-RateLimit-* 响应头
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	RetryAfter time.Duration // 被拒绝时距离下一个令牌的时间
+	ResetAfter time.Duration // 距离令牌桶补满的时间
+}
+
+// Store 限流状态存储，操作都是原子的，便于用 Redis 等共享存储实现多实例限流
+type Store interface {
+	// Take 从 key 对应的令牌桶取一个令牌
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Incr 计数器加一并把过期时间重置为 ttl，返回加一后的值（INCR + PEXPIRE）
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Lock 把 key 锁定到 until（SET key until PXAT until）
+	Lock(ctx context.Context, key string, until time.Time) error
+	// LockedUntil 返回 key 的锁定截止时间，未锁定时返回零值
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// Delete 删除计数器、锁和令牌桶
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// refill 按经过的时间补充令牌，返回补充后的令牌数
+func refill(tokens float64, last, now time.Time, limit Limit) float64 {
+	elapsed := now.Sub(last).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+}
+
+// durationFor 补充 n 个令牌需要的时间
+func durationFor(n float64, limit Limit) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(n / limit.Rate * float64(time.Second))
+}
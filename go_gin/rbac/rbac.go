@@ -0,0 +1,73 @@
+package rbac
+
+// Role 用户角色
+type Role string
+
+// 系统内置角色
+const (
+	RoleAdmin     Role = "admin"     // 管理员，拥有全部权限
+	RoleModerator Role = "moderator" // 版主，可以查看、编辑和删除任何文章、评论
+	RoleAuthor    Role = "author"    // 作者，可以发表文章，只能管理自己的内容
+	RoleReader    Role = "reader"    // 读者，只能发表评论
+)
+
+// DefaultRole 新注册用户的角色
+const DefaultRole = RoleAuthor
+
+// Permission 权限
+type Permission string
+
+// 权限列表，*_any 表示可以操作他人的资源
+const (
+	PermPostCreate       Permission = "post:create"
+	PermPostEditAny      Permission = "post:edit_any"
+	PermPostDeleteAny    Permission = "post:delete_any"
+	PermPostViewAny      Permission = "post:view_any" // 查看他人的草稿、定时和归档文章
+	PermCommentCreate    Permission = "comment:create"
+	PermCommentEditAny   Permission = "comment:edit_any"
+	PermCommentDeleteAny Permission = "comment:delete_any"
+	PermUserManageRoles  Permission = "user:manage_roles"
+	PermTaxonomyManage   Permission = "taxonomy:manage" // 维护标签和分类
+)
+
+// rolePermissions 角色拥有的权限
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermPostCreate, PermPostEditAny, PermPostDeleteAny, PermPostViewAny,
+		PermCommentCreate, PermCommentEditAny, PermCommentDeleteAny,
+		PermUserManageRoles, PermTaxonomyManage,
+	},
+	RoleModerator: {
+		PermPostCreate, PermPostEditAny, PermPostDeleteAny, PermPostViewAny,
+		PermCommentCreate, PermCommentEditAny, PermCommentDeleteAny,
+		PermTaxonomyManage,
+	},
+	RoleAuthor: {
+		PermPostCreate,
+		PermCommentCreate,
+	},
+	RoleReader: {
+		PermCommentCreate,
+	},
+}
+
+// Valid 是否为内置角色
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can 角色是否拥有权限
+func (r Role) Can(p Permission) bool {
+	for _, perm := range rolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CanModify 资源作者本人或拥有 anyPerm 权限的角色可以修改资源
+func CanModify(role Role, userID, ownerID uint, anyPerm Permission) bool {
+	return userID == ownerID || role.Can(anyPerm)
+}
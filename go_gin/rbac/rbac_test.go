@@ -0,0 +1,12 @@
+package rbac
+
+import "testing"
+
+// TestEditAnyImpliesViewAny 能编辑他人文章的角色也必须能查看他人未发布的文章，否则编辑后看不到草稿
+func TestEditAnyImpliesViewAny(t *testing.T) {
+	for role := range rolePermissions {
+		if role.Can(PermPostEditAny) && !role.Can(PermPostViewAny) {
+			t.Errorf("role %s has %s without %s", role, PermPostEditAny, PermPostViewAny)
+		}
+	}
+}
@@ -0,0 +1,907 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewGormStore 基于GORM创建仓储，MySQL和SQLite共用同一套实现
+func NewGormStore(db *gorm.DB) *Store {
+	return &Store{
+		Users:      &gormUserRepository{db: db},
+		Posts:      &gormPostRepository{db: db},
+		Comments:   &gormCommentRepository{db: db},
+		Tags:       &gormTagRepository{db: db},
+		Categories: &gormCategoryRepository{db: db},
+		Revisions:  &gormRevisionRepository{db: db},
+		Tokens:     &gormTokenRepository{db: db},
+		Engagement: &gormEngagementRepository{db: db},
+		Counters:   &gormCounterRepository{db: db},
+	}
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormUserRepository) Create(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormUserRepository) FindByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("username = ? OR email = ?", username, email).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormUserRepository) Update(ctx context.Context, user *model.User, fields ...string) error {
+	return r.db.WithContext(ctx).Model(user).Select(fields).Updates(user).Error
+}
+
+func (r *gormUserRepository) Delete(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		placeholder := fmt.Sprintf("deleted_%d", user.ID)
+		err := tx.Model(user).Select("Username", "Email", "Password", "CommentCount").Updates(&model.User{
+			Username:     placeholder,
+			Email:        placeholder + "@deleted.invalid",
+			Password:     "",
+			CommentCount: 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		// 评论与用户解除关联，包括已软删除的评论
+		err = tx.Unscoped().Model(&model.Comment{}).Where("user_id = ?", user.ID).
+			UpdateColumn("user_id", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+}
+
+func (r *gormUserRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+type gormPostRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormPostRepository) Create(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		if post.Published() {
+			if err := addCount(tx, &model.User{}, post.UserID, "post_count", 1); err != nil {
+				return err
+			}
+		}
+		return createRevision(tx, post, &model.PostRevision{EditorID: post.UserID})
+	})
+}
+
+func (r *gormPostRepository) FindByID(ctx context.Context, id uint) (*model.Post, error) {
+	var post model.Post
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("Categories").First(&post, id).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) FindDetail(ctx context.Context, id uint) (*model.Post, error) {
+	var post model.Post
+	// 预加载用户、评论、标签和分类
+	err := r.db.WithContext(ctx).Preload("User").Preload("Comments.User").
+		Preload("Tags").Preload("Categories").First(&post, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.Post, error) {
+	var posts []model.Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	err := r.db.WithContext(ctx).Preload("User").Preload("Tags").Preload("Categories").
+		Where("id IN ?", ids).Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// publishedIDsBatch PublishedIDs 每条查询的最大ID数，避免 IN 列表超过数据库的参数个数限制
+const publishedIDsBatch = 1000
+
+func (r *gormPostRepository) PublishedIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	found := make([]uint, 0, len(ids))
+	for start := 0; start < len(ids); start += publishedIDsBatch {
+		var batch []uint
+		err := r.db.WithContext(ctx).Model(&model.Post{}).
+			Where("id IN ? AND status = ?", ids[start:min(start+publishedIDsBatch, len(ids))], model.PostStatusPublished).
+			Pluck("id", &batch).Error
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, batch...)
+	}
+	return found, nil
+}
+
+func (r *gormPostRepository) EachBatch(ctx context.Context, batchSize int, fn func(posts []model.Post) error) error {
+	var posts []model.Post
+	return r.db.WithContext(ctx).FindInBatches(&posts, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(posts)
+	}).Error
+}
+
+// 热度的计算权重：一次点赞、收藏或评论分别相当于若干次浏览
+const (
+	popularityLikeWeight     = 10
+	popularityBookmarkWeight = 10
+	popularityCommentWeight  = 5
+)
+
+// postPopularityExpr 文章热度，浏览数加上按权重折算的点赞、收藏和评论数
+var postPopularityExpr = fmt.Sprintf("(posts.view_count + %d * posts.like_count + %d * posts.bookmark_count + %d * posts.comment_count)",
+	popularityLikeWeight, popularityBookmarkWeight, popularityCommentWeight)
+
+// postPopularity 与 postPopularityExpr 相同的计算，用于生成游标
+func postPopularity(p *model.Post) int64 {
+	return p.ViewCount + popularityLikeWeight*p.LikeCount + popularityBookmarkWeight*p.BookmarkCount +
+		popularityCommentWeight*p.CommentCount
+}
+
+var postPageSpec = pageSpec[model.Post]{
+	idColumn: "posts.id",
+	fields: map[string]sortField[model.Post]{
+		"created_at":    {column: "posts.created_at", kind: sortTime, value: func(p *model.Post) any { return p.CreatedAt }},
+		"updated_at":    {column: "posts.updated_at", kind: sortTime, value: func(p *model.Post) any { return p.UpdatedAt }},
+		"comment_count": {column: "posts.comment_count", kind: sortInt, value: func(p *model.Post) any { return p.CommentCount }},
+		"like_count":    {column: "posts.like_count", kind: sortInt, value: func(p *model.Post) any { return p.LikeCount }},
+		"view_count":    {column: "posts.view_count", kind: sortInt, value: func(p *model.Post) any { return p.ViewCount }},
+		"popularity":    {column: postPopularityExpr, kind: sortInt, value: func(p *model.Post) any { return postPopularity(p) }},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "desc",
+	id:           func(p *model.Post) uint { return p.ID },
+}
+
+func (r *gormPostRepository) List(ctx context.Context, filter PostFilter, page PageRequest) (*Page[model.Post], error) {
+	query := visiblePosts(r.db.WithContext(ctx).Model(&model.Post{}), filter.Visibility)
+	if filter.Status != "" {
+		query = query.Where("posts.status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("posts.user_id = ?", filter.UserID)
+	}
+	if filter.TagSlug != "" {
+		query = query.Where("posts.id IN (?)", r.db.Table("post_tags").Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").Where("tags.slug = ?", filter.TagSlug))
+	}
+	if filter.CategorySlug != "" {
+		query = query.Where("posts.id IN (?)", r.db.Table("post_categories").Select("post_categories.post_id").
+			Joins("JOIN categories ON categories.id = post_categories.category_id").Where("categories.slug = ?", filter.CategorySlug))
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("posts.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("posts.created_at <= ?", *filter.CreatedTo)
+	}
+
+	return paginate(query, func(q *gorm.DB) *gorm.DB {
+		// 预加载用户、标签和分类
+		return q.Preload("User").Preload("Tags").Preload("Categories")
+	}, postPageSpec, page)
+}
+
+// visiblePosts 按可见范围过滤文章，query 中需要包含 posts 表
+func visiblePosts(query *gorm.DB, visibility Visibility) *gorm.DB {
+	switch {
+	case visibility.All:
+		return query
+	case visibility.UserID != 0:
+		return query.Where("(posts.status = ? OR posts.user_id = ?)", model.PostStatusPublished, visibility.UserID)
+	default:
+		return query.Where("posts.status = ?", model.PostStatusPublished)
+	}
+}
+
+func (r *gormPostRepository) Update(ctx context.Context, post *model.Post, revision *model.PostRevision, fields ...string) error {
+	// 关联单独替换，其余字段和版本号一起写入
+	columns := []string{"*"}
+	replaceTags, replaceCategories := post.Tags != nil, post.Categories != nil
+	if len(fields) > 0 {
+		columns = []string{"Version"}
+		replaceTags, replaceCategories = false, false
+		for _, f := range fields {
+			switch f {
+			case "Tags":
+				replaceTags = true
+			case "Categories":
+				replaceCategories = true
+			default:
+				columns = append(columns, f)
+			}
+		}
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 修改前的状态决定作者的已发布文章数是否变化
+		expected := post.Version
+		var previous []string
+		if err := tx.Model(&model.Post{}).Where("id = ? AND version = ?", post.ID, expected).
+			Pluck("status", &previous).Error; err != nil {
+			return err
+		}
+		if len(previous) == 0 {
+			return ErrVersionConflict
+		}
+
+		// 条件更新代替 Save：版本号不匹配时 Save 会退化为插入
+		post.Version = expected + 1
+		// 计数由点赞、收藏、浏览和评论单独维护，不随文章内容覆盖
+		result := tx.Model(post).Omit(clause.Associations, "LikeCount", "BookmarkCount", "ViewCount", "CommentCount").
+			Select(columns).Where("version = ?", expected).Updates(post)
+		if result.Error == nil && result.RowsAffected == 0 {
+			result.Error = ErrVersionConflict
+		}
+		if result.Error != nil {
+			post.Version = expected
+			return result.Error
+		}
+		if delta := publishedDelta(previous[0], post.Status); delta != 0 {
+			if err := addCount(tx, &model.User{}, post.UserID, "post_count", delta); err != nil {
+				return err
+			}
+		}
+		if err := createRevision(tx, post, revision); err != nil {
+			return err
+		}
+		if replaceTags {
+			if err := tx.Model(post).Association("Tags").Replace(post.Tags); err != nil {
+				return err
+			}
+		}
+		if replaceCategories {
+			if err := tx.Model(post).Association("Categories").Replace(post.Categories); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// createRevision 以文章当前的标题和内容保存一个新修订，修订号为该文章已有的最大修订号加1
+// 并发更新同一篇文章时 (post_id, number) 唯一索引冲突，后提交的事务失败
+func createRevision(tx *gorm.DB, post *model.Post, revision *model.PostRevision) error {
+	var last int
+	err := tx.Model(&model.PostRevision{}).Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(number), 0)").Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	revision.PostID = post.ID
+	revision.Number = last + 1
+	revision.Title = post.Title
+	revision.Content = post.Content
+	return tx.Omit(clause.Associations).Create(revision).Error
+}
+
+func (r *gormPostRepository) PublishDue(ctx context.Context, now time.Time, limit int) ([]model.Post, error) {
+	db := r.db.WithContext(ctx)
+	var due []model.Post
+	err := db.Where("status = ? AND published_at <= ?", model.PostStatusScheduled, now).
+		Order("published_at").Limit(limit).Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	published := due[:0]
+	for _, post := range due {
+		// 带上状态条件更新，其他实例已经发布或作者已改回草稿的文章会被跳过
+		ok := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&model.Post{}).
+				Where("id = ? AND status = ?", post.ID, model.PostStatusScheduled).
+				Updates(map[string]any{"status": model.PostStatusPublished, "version": gorm.Expr("version + 1")})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			ok = true
+			return addCount(tx, &model.User{}, post.UserID, "post_count", 1)
+		})
+		if err != nil {
+			return published, err
+		}
+		if ok {
+			post.Status = model.PostStatusPublished
+			post.Version++
+			published = append(published, post)
+		}
+	}
+	return published, nil
+}
+
+func (r *gormPostRepository) Delete(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("version = ?", post.Version).Delete(post)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		// 版本号一致说明状态与读取时相同
+		if post.Published() {
+			return addCount(tx, &model.User{}, post.UserID, "post_count", -1)
+		}
+		return nil
+	})
+}
+
+// publishedDelta 文章状态从 from 变为 to 时作者已发布文章数的变化
+func publishedDelta(from, to string) int {
+	wasPublished, isPublished := from == model.PostStatusPublished, to == model.PostStatusPublished
+	switch {
+	case !wasPublished && isPublished:
+		return 1
+	case wasPublished && !isPublished:
+		return -1
+	}
+	return 0
+}
+
+// addCount 在事务中原子地调整计数列，不修改更新时间和版本号
+// 已软删除的记录同样调整，与 Reconcile 重新统计的结果保持一致
+func addCount(tx *gorm.DB, table any, id uint, column string, delta int) error {
+	return tx.Unscoped().Model(table).Where("id = ?", id).
+		UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
+}
+
+type gormRevisionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRevisionRepository) ListByPost(ctx context.Context, postID uint) ([]model.PostRevision, error) {
+	var revisions []model.PostRevision
+	err := r.db.WithContext(ctx).Omit("content").Preload("Editor").
+		Where("post_id = ?", postID).Order("number DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *gormRevisionRepository) FindByNumber(ctx context.Context, postID uint, number int) (*model.PostRevision, error) {
+	var revision model.PostRevision
+	err := r.db.WithContext(ctx).Preload("Editor").
+		Where("post_id = ? AND number = ?", postID, number).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+type gormCommentRepository struct {
+	db *gorm.DB
+}
+
+// Create 创建评论并根据父评论生成楼层路径
+func (r *gormCommentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+
+		path := model.CommentPathSegment(comment.ID)
+		if comment.ParentID != nil {
+			var parent model.Comment
+			// 父评论可能已被删除，仍然沿用它的路径
+			if err := tx.Unscoped().Select("path").First(&parent, *comment.ParentID).Error; err != nil {
+				return err
+			}
+			path = parent.Path + "/" + path
+		}
+		comment.Path = path
+		if err := tx.Model(comment).UpdateColumn("path", path).Error; err != nil {
+			return err
+		}
+		if err := addCount(tx, &model.Post{}, comment.PostID, "comment_count", 1); err != nil {
+			return err
+		}
+		return addCount(tx, &model.User{}, comment.AuthorID(), "comment_count", 1)
+	})
+}
+
+func (r *gormCommentRepository) FindByID(ctx context.Context, id uint) (*model.Comment, error) {
+	var comment model.Comment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *gormCommentRepository) Update(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Save(comment).Error
+}
+
+func (r *gormCommentRepository) Delete(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 并发删除同一条评论时只有一个请求减少计数
+		result := tx.Delete(comment)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := addCount(tx, &model.Post{}, comment.PostID, "comment_count", -1); err != nil {
+			return err
+		}
+		// 作者已注销的评论不再计入任何用户
+		if comment.UserID == nil {
+			return nil
+		}
+		return addCount(tx, &model.User{}, *comment.UserID, "comment_count", -1)
+	})
+}
+
+var commentPageSpec = pageSpec[model.Comment]{
+	idColumn: "comments.id",
+	fields: map[string]sortField[model.Comment]{
+		"created_at": {column: "comments.created_at", kind: sortTime, value: func(c *model.Comment) any { return c.CreatedAt }},
+		// thread 按楼层顺序排列，回复紧跟在被回复的评论之后
+		"thread": {column: "comments.path", kind: sortString, value: func(c *model.Comment) any { return c.Path }},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "asc",
+	id:           func(c *model.Comment) uint { return c.ID },
+}
+
+func (r *gormCommentRepository) ListByPost(ctx context.Context, postID uint, filter CommentFilter, page PageRequest) (*Page[model.Comment], error) {
+	query := r.db.WithContext(ctx).Model(&model.Comment{}).Where("comments.post_id = ?", postID)
+	if filter.RootsOnly {
+		// 已删除的顶层评论下仍有回复时作为占位返回，否则回复在树形展示中没有可挂的祖先；
+		// 顶层评论的路径就是它自己的路径片段，子树内的评论路径都以它开头
+		query = query.Unscoped().Where("comments.parent_id IS NULL").
+			Where("comments.deleted_at IS NULL OR EXISTS (?)", r.db.Table("comments AS replies").
+				Select("1").
+				Where("replies.post_id = comments.post_id AND replies.depth > 0 AND replies.deleted_at IS NULL").
+				Where("SUBSTR(replies.path, 1, ?) = comments.path", len(model.CommentPathSegment(0))))
+	}
+	if filter.UserID != 0 {
+		query = query.Where("comments.user_id = ?", filter.UserID)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("comments.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("comments.created_at <= ?", *filter.CreatedTo)
+	}
+
+	return paginate(query, func(q *gorm.DB) *gorm.DB {
+		// 预加载用户信息
+		return q.Preload("User")
+	}, commentPageSpec, page)
+}
+
+func (r *gormCommentRepository) ListReplies(ctx context.Context, postID uint, rootPaths []string) ([]model.Comment, error) {
+	var comments []model.Comment
+	if len(rootPaths) == 0 {
+		return comments, nil
+	}
+
+	query := r.db.WithContext(ctx).Where("post_id = ?", postID)
+	cond := r.db.Where("path LIKE ?", rootPaths[0]+"/%")
+	for _, p := range rootPaths[1:] {
+		cond = cond.Or("path LIKE ?", p+"/%")
+	}
+	err := query.Where(cond).Preload("User").Order("path ASC").Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+type gormTagRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormTagRepository) FindByID(ctx context.Context, id uint) (*model.Tag, error) {
+	var tag model.Tag
+	if err := r.db.WithContext(ctx).First(&tag, id).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *gormTagRepository) FindOrCreate(ctx context.Context, tags []model.Tag) ([]model.Tag, error) {
+	found := make([]model.Tag, 0, len(tags))
+	if len(tags) == 0 {
+		return found, nil
+	}
+
+	slugs := make([]string, len(tags))
+	for i, t := range tags {
+		slugs[i] = t.Slug
+	}
+	db := r.db.WithContext(ctx)
+	// 已存在的 Slug 跳过插入，随后统一按 Slug 查询，拿到的都是库中实际的行
+	err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "slug"}}, DoNothing: true}).
+		Create(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Where("slug IN ?", slugs).Order("slug").Find(&found).Error; err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (r *gormTagRepository) Cloud(ctx context.Context, limit int) ([]model.Tag, error) {
+	var tags []model.Tag
+	query := r.db.WithContext(ctx).Model(&model.Tag{}).
+		Select("tags.*, COUNT(posts.id) AS post_count").
+		Joins("LEFT JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("LEFT JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL AND posts.status = ?", model.PostStatusPublished).
+		Group("tags.id").
+		Order("post_count DESC, tags.slug ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *gormTagRepository) Create(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).Create(tag).Error
+}
+
+func (r *gormTagRepository) Update(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).Save(tag).Error
+}
+
+func (r *gormTagRepository) Delete(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+}
+
+type gormCategoryRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormCategoryRepository) FindByID(ctx context.Context, id uint) (*model.Category, error) {
+	var category model.Category
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *gormCategoryRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.Category, error) {
+	categories := make([]model.Category, 0, len(ids))
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *gormCategoryRepository) List(ctx context.Context) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Model(&model.Category{}).
+		Select("categories.*, COUNT(posts.id) AS post_count").
+		Joins("LEFT JOIN post_categories ON post_categories.category_id = categories.id").
+		Joins("LEFT JOIN posts ON posts.id = post_categories.post_id AND posts.deleted_at IS NULL AND posts.status = ?", model.PostStatusPublished).
+		Group("categories.id").
+		Order("categories.name ASC").
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *gormCategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *gormCategoryRepository) Update(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+func (r *gormCategoryRepository) Delete(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_categories WHERE category_id = ?", category.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	})
+}
+
+type gormTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormTokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *gormTokenRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *gormTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id uint, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", usedAt)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (r *gormTokenRepository) RevokeUserFamilies(ctx context.Context, userID uint, revokedAt time.Time) ([]string, error) {
+	var families []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Distinct().Pluck("family_id", &families).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", revokedAt).Error
+	})
+	return families, err
+}
+
+func (r *gormTokenRepository) AddRevoked(ctx context.Context, ids []string, expiresAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&model.RevokedToken{}).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			// 同一ID重复吊销时延长过期时间
+			if err := tx.Save(&model.RevokedToken{ID: id, ExpiresAt: expiresAt}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *gormTokenRepository) CreateUserToken(ctx context.Context, token *model.UserToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *gormTokenRepository) FindUserTokenByHash(ctx context.Context, hash string) (*model.UserToken, error) {
+	var token model.UserToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *gormTokenRepository) MarkUserTokenUsed(ctx context.Context, id uint, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormTokenRepository) DeleteUserTokens(ctx context.Context, userID uint, purpose string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Delete(&model.UserToken{}).Error
+}
+
+func (r *gormTokenRepository) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.RevokedToken{}).
+		Where("id IN ? AND expires_at > ?", ids, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+type gormEngagementRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormEngagementRepository) Like(ctx context.Context, userID, postID uint) (bool, error) {
+	return r.add(ctx, &model.PostLike{UserID: userID, PostID: postID}, postID, "like_count")
+}
+
+func (r *gormEngagementRepository) Unlike(ctx context.Context, userID, postID uint) (bool, error) {
+	return r.remove(ctx, &model.PostLike{}, userID, postID, "like_count")
+}
+
+func (r *gormEngagementRepository) Bookmark(ctx context.Context, userID, postID uint) (bool, error) {
+	return r.add(ctx, &model.Bookmark{UserID: userID, PostID: postID}, postID, "bookmark_count")
+}
+
+func (r *gormEngagementRepository) Unbookmark(ctx context.Context, userID, postID uint) (bool, error) {
+	return r.remove(ctx, &model.Bookmark{}, userID, postID, "bookmark_count")
+}
+
+// add 插入点赞或收藏记录，记录已存在时忽略，只有真正插入时才增加文章上的计数
+// UpdateColumn 不修改文章的更新时间和版本号
+func (r *gormEngagementRepository) add(ctx context.Context, record any, postID uint, counter string) (bool, error) {
+	added := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		added = true
+		return tx.Model(&model.Post{}).Where("id = ?", postID).
+			UpdateColumn(counter, gorm.Expr(counter+" + 1")).Error
+	})
+	return added, err
+}
+
+// remove 删除点赞或收藏记录，只有真正删除时才减少文章上的计数
+func (r *gormEngagementRepository) remove(ctx context.Context, record any, userID, postID uint, counter string) (bool, error) {
+	removed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(record)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+		return tx.Model(&model.Post{}).Where("id = ? AND "+counter+" > 0", postID).
+			UpdateColumn(counter, gorm.Expr(counter+" - 1")).Error
+	})
+	return removed, err
+}
+
+func (r *gormEngagementRepository) State(ctx context.Context, userID, postID uint) (*EngagementState, error) {
+	db := r.db.WithContext(ctx)
+	var likes, bookmarks int64
+	if err := db.Model(&model.PostLike{}).Where("user_id = ? AND post_id = ?", userID, postID).Count(&likes).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&model.Bookmark{}).Where("user_id = ? AND post_id = ?", userID, postID).Count(&bookmarks).Error; err != nil {
+		return nil, err
+	}
+	return &EngagementState{Liked: likes > 0, Bookmarked: bookmarks > 0}, nil
+}
+
+var bookmarkPageSpec = pageSpec[model.Bookmark]{
+	idColumn: "bookmarks.post_id",
+	fields: map[string]sortField[model.Bookmark]{
+		"created_at": {column: "bookmarks.created_at", kind: sortTime, value: func(b *model.Bookmark) any { return b.CreatedAt }},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: "desc",
+	id:           func(b *model.Bookmark) uint { return b.PostID },
+}
+
+func (r *gormEngagementRepository) ListBookmarks(ctx context.Context, userID uint, visibility Visibility, page PageRequest) (*Page[model.Bookmark], error) {
+	query := r.db.WithContext(ctx).Model(&model.Bookmark{}).
+		Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL").
+		Where("bookmarks.user_id = ?", userID)
+	query = visiblePosts(query, visibility)
+
+	return paginate(query, func(q *gorm.DB) *gorm.DB {
+		return q.Select("bookmarks.*").Preload("Post").Preload("Post.User").Preload("Post.Tags").Preload("Post.Categories")
+	}, bookmarkPageSpec, page)
+}
+
+func (r *gormEngagementRepository) AddViews(ctx context.Context, views map[uint]int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for postID, n := range views {
+			if err := tx.Model(&model.Post{}).Where("id = ?", postID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type gormCounterRepository struct {
+	db *gorm.DB
+}
+
+// counterSources 每个冗余计数列及其按明细统计的子查询
+var counterSources = []struct {
+	table, column, source string
+}{
+	{"posts", "comment_count",
+		"SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL"},
+	{"posts", "like_count", "SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id"},
+	{"posts", "bookmark_count", "SELECT COUNT(*) FROM bookmarks WHERE bookmarks.post_id = posts.id"},
+	{"users", "post_count", "SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.status = '" +
+		model.PostStatusPublished + "' AND posts.deleted_at IS NULL"},
+	{"users", "comment_count",
+		"SELECT COUNT(*) FROM comments WHERE comments.user_id = users.id AND comments.deleted_at IS NULL"},
+}
+
+func (r *gormCounterRepository) Reconcile(ctx context.Context, dryRun bool) ([]CounterDrift, error) {
+	drifts := make([]CounterDrift, 0, len(counterSources))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, c := range counterSources {
+			drift := CounterDrift{Counter: c.table + "." + c.column}
+			// 包含软删除的行，恢复后计数仍然正确
+			where := fmt.Sprintf("%s <> (%s)", c.column, c.source)
+			if dryRun {
+				err := tx.Table(c.table).Where(where).Count(&drift.Rows).Error
+				if err != nil {
+					return err
+				}
+			} else {
+				result := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = (%s) WHERE %s", c.table, c.column, c.source, where))
+				if result.Error != nil {
+					return result.Error
+				}
+				drift.Rows = result.RowsAffected
+			}
+			drifts = append(drifts, drift)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+func TestUserDeleteAnonymizesComments(t *testing.T) {
+	db := newTestDB(t)
+	store := NewGormStore(db)
+	ctx := context.Background()
+	alice := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: "author"}
+	bob := &model.User{Username: "bob", Email: "bob@example.com", Password: "x", Role: "reader"}
+	for _, u := range []*model.User{alice, bob} {
+		if err := store.Users.Create(ctx, u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	post := &model.Post{Title: "p", Content: "c", UserID: bob.ID, Status: model.PostStatusPublished}
+	if err := store.Posts.Create(ctx, post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	var comments []*model.Comment
+	for _, u := range []*model.User{alice, alice, bob} {
+		c := &model.Comment{Content: u.Username, PostID: post.ID, UserID: &u.ID}
+		if err := store.Comments.Create(ctx, c); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := store.Comments.Delete(ctx, comments[1]); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+
+	if err := store.Users.Delete(ctx, alice); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	// 包括已软删除的评论在内，alice 的评论都不再关联到她
+	var got []model.Comment
+	if err := db.Unscoped().Order("id").Find(&got).Error; err != nil {
+		t.Fatalf("load comments: %v", err)
+	}
+	for i, c := range got {
+		if i < 2 && c.UserID != nil {
+			t.Errorf("comment %d user_id = %d, want NULL", c.ID, *c.UserID)
+		}
+		if i == 2 && (c.UserID == nil || *c.UserID != bob.ID) {
+			t.Errorf("comment %d user_id = %v, want %d", c.ID, c.UserID, bob.ID)
+		}
+	}
+
+	var deleted model.User
+	if err := db.Unscoped().First(&deleted, alice.ID).Error; err != nil {
+		t.Fatalf("load deleted user: %v", err)
+	}
+	if !deleted.DeletedAt.Valid || deleted.Username != "deleted_1" || deleted.CommentCount != 0 {
+		t.Errorf("deleted user = %+v, want anonymized with comment_count 0", deleted)
+	}
+
+	drifts, err := store.Counters.Reconcile(ctx, true)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	for _, d := range drifts {
+		if d.Rows != 0 {
+			t.Errorf("%s drifted in %d rows", d.Counter, d.Rows)
+		}
+	}
+}
+
+// TestReconcile 手工改乱的计数按明细恢复，dryRun 只报告不修改，浏览数不参与统计
+func TestReconcile(t *testing.T) {
+	db := newTestDB(t)
+	store := NewGormStore(db)
+	ctx := context.Background()
+	alice := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: "author"}
+	bob := &model.User{Username: "bob", Email: "bob@example.com", Password: "x", Role: "reader"}
+	for _, u := range []*model.User{alice, bob} {
+		if err := store.Users.Create(ctx, u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	post := &model.Post{Title: "p", Content: "c", UserID: alice.ID, Status: model.PostStatusPublished}
+	draft := &model.Post{Title: "d", Content: "c", UserID: alice.ID, Status: model.PostStatusDraft}
+	for _, p := range []*model.Post{post, draft} {
+		if err := store.Posts.Create(ctx, p); err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+	}
+	for _, u := range []*model.User{alice, bob, bob} {
+		c := &model.Comment{Content: "c", PostID: post.ID, UserID: &u.ID}
+		if err := store.Comments.Create(ctx, c); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+		if u == alice {
+			if err := store.Comments.Delete(ctx, c); err != nil {
+				t.Fatalf("delete comment: %v", err)
+			}
+		}
+	}
+	if _, err := store.Engagement.Like(ctx, bob.ID, post.ID); err != nil {
+		t.Fatalf("like: %v", err)
+	}
+	if _, err := store.Engagement.Bookmark(ctx, bob.ID, post.ID); err != nil {
+		t.Fatalf("bookmark: %v", err)
+	}
+
+	for _, sql := range []string{
+		"UPDATE posts SET comment_count = 9, like_count = 0, bookmark_count = 3, view_count = 7",
+		"UPDATE users SET post_count = 5, comment_count = -1 WHERE username = 'alice'",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatalf("corrupt counters: %v", err)
+		}
+	}
+	want := map[string]int64{
+		"posts.comment_count":  2,
+		"posts.like_count":     1,
+		"posts.bookmark_count": 2,
+		"users.post_count":     1,
+		"users.comment_count":  1,
+	}
+	check := func(drifts []CounterDrift, want map[string]int64) {
+		t.Helper()
+		if len(drifts) != len(want) {
+			t.Fatalf("got %d counters, want %d", len(drifts), len(want))
+		}
+		for _, d := range drifts {
+			if d.Rows != want[d.Counter] {
+				t.Errorf("%s: %d rows drifted, want %d", d.Counter, d.Rows, want[d.Counter])
+			}
+		}
+	}
+
+	drifts, err := store.Counters.Reconcile(ctx, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	check(drifts, want)
+	// 只检查时不修改计数
+	if drifts, err = store.Counters.Reconcile(ctx, true); err != nil {
+		t.Fatalf("second dry run: %v", err)
+	}
+	check(drifts, want)
+
+	if drifts, err = store.Counters.Reconcile(ctx, false); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	check(drifts, want)
+
+	var posts []model.Post
+	if err := db.Order("id").Find(&posts).Error; err != nil {
+		t.Fatalf("load posts: %v", err)
+	}
+	type postCounts struct{ comments, likes, bookmarks, views int64 }
+	for i, wantCounts := range []postCounts{{2, 1, 1, 7}, {0, 0, 0, 7}} {
+		p := posts[i]
+		if got := (postCounts{p.CommentCount, p.LikeCount, p.BookmarkCount, p.ViewCount}); got != wantCounts {
+			t.Errorf("post %d counts = %+v, want %+v", p.ID, got, wantCounts)
+		}
+	}
+	var users []model.User
+	if err := db.Order("id").Find(&users).Error; err != nil {
+		t.Fatalf("load users: %v", err)
+	}
+	type userCounts struct{ posts, comments int64 }
+	for i, wantCounts := range []userCounts{{1, 0}, {0, 2}} {
+		u := users[i]
+		if got := (userCounts{u.PostCount, u.CommentCount}); got != wantCounts {
+			t.Errorf("user %s counts = %+v, want %+v", u.Username, got, wantCounts)
+		}
+	}
+
+	if drifts, err = store.Counters.Reconcile(ctx, true); err != nil {
+		t.Fatalf("dry run after reconcile: %v", err)
+	}
+	check(drifts, map[string]int64{
+		"posts.comment_count": 0, "posts.like_count": 0, "posts.bookmark_count": 0,
+		"users.post_count": 0, "users.comment_count": 0,
+	})
+}
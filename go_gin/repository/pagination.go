@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 分页默认值
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidPage 分页、排序或游标参数不合法
+var ErrInvalidPage = errors.New("invalid pagination parameters")
+
+// PageRequest 列表分页参数，Cursor 不为空时使用游标分页，否则按 Page 偏移分页
+type PageRequest struct {
+	Limit  int
+	Page   int    // 页码，从1开始
+	Cursor string // 上一页返回的 next_cursor，包含排序字段和方向
+	SortBy string // 排序字段，为空时使用各列表的默认字段
+	Order  string // asc 或 desc，为空时使用各列表的默认方向
+}
+
+// Page 一页查询结果
+type Page[T any] struct {
+	Items      []T
+	Total      int64 // 满足过滤条件的总数，与分页无关
+	Page       int   // 偏移分页时的页码，游标分页时为0
+	Limit      int
+	NextCursor string // 没有下一页时为空
+}
+
+// pageCursor 游标内容，编码为base64的JSON
+type pageCursor struct {
+	SortBy string `json:"s"`
+	Order  string `json:"o"`
+	Value  string `json:"v"`
+	ID     uint   `json:"id"`
+}
+
+// sortKind 排序值的类型，决定游标中的值如何解析
+type sortKind int
+
+const (
+	sortTime sortKind = iota
+	sortInt
+	sortString
+)
+
+// sortField 可排序字段
+type sortField[T any] struct {
+	column string       // 用于ORDER BY和WHERE的SQL表达式
+	kind   sortKind     // 排序值类型
+	value  func(*T) any // 从记录中取出排序值，返回time.Time、int64或string
+}
+
+// pageSpec 描述一个列表如何排序和分页
+type pageSpec[T any] struct {
+	idColumn     string
+	fields       map[string]sortField[T]
+	defaultSort  string
+	defaultOrder string
+	id           func(*T) uint
+}
+
+// paginate 统计总数并按分页参数查询一页数据
+// base 只包含过滤条件，load 用于追加Select、Preload等只影响查询结果的选项
+func paginate[T any](base *gorm.DB, load func(*gorm.DB) *gorm.DB, spec pageSpec[T], req PageRequest) (*Page[T], error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	sortBy, order := req.SortBy, req.Order
+	var cur *pageCursor
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// 游标中记录了排序方式，保证翻页过程中顺序不变
+		cur = c
+		sortBy, order = c.SortBy, c.Order
+	}
+	if sortBy == "" {
+		sortBy = spec.defaultSort
+	}
+	if order == "" {
+		order = spec.defaultOrder
+	}
+	field, ok := spec.fields[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidPage, sortBy)
+	}
+	if order != "asc" && order != "desc" {
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidPage)
+	}
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	query := load(base.Session(&gorm.Session{})).
+		Order(fmt.Sprintf("%s %s, %s %s", field.column, order, spec.idColumn, order))
+
+	page := &Page[T]{Total: total, Limit: limit}
+	if cur != nil {
+		value, err := cursorValue(field, cur.Value)
+		if err != nil {
+			return nil, err
+		}
+		op := ">"
+		if order == "desc" {
+			op = "<"
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", field.column, op, field.column, spec.idColumn, op),
+			value, value, cur.ID,
+		)
+	} else {
+		page.Page = req.Page
+		if page.Page <= 0 {
+			page.Page = 1
+		}
+		query = query.Offset((page.Page - 1) * limit)
+	}
+
+	// 多取一条用于判断是否还有下一页
+	var items []T
+	if err := query.Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) > limit {
+		items = items[:limit]
+		last := &items[len(items)-1]
+		page.NextCursor = encodeCursor(pageCursor{
+			SortBy: sortBy,
+			Order:  order,
+			Value:  formatCursorValue(field.value(last)),
+			ID:     spec.id(last),
+		})
+	}
+	page.Items = items
+	return page, nil
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+	}
+	return &c, nil
+}
+
+func formatCursorValue(v any) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func cursorValue[T any](field sortField[T], s string) (any, error) {
+	switch field.kind {
+	case sortTime:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+		// 数据库中的时间按本地时区存储，SQLite按字符串比较时需要保持一致
+		return t.Local(), nil
+	case sortString:
+		return s, nil
+	}
+	var n int64
+	if _, err := fmt.Sscan(s, &n); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+	}
+	return n, nil
+}
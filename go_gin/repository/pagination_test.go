@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/migrations"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"gorm.io/gorm"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []pageCursor{
+		{SortBy: "created_at", Order: "desc", Value: "2024-01-02T03:04:05.123456789Z", ID: 42},
+		{SortBy: "thread", Order: "asc", Value: "0000000001/0000000007", ID: 7},
+		{SortBy: "like_count", Order: "desc", Value: "-1", ID: 1},
+		{SortBy: "title", Order: "asc", Value: `中文 "quoted" / + =`, ID: 1<<32 - 1},
+	}
+	for _, want := range tests {
+		encoded := encodeCursor(want)
+		got, err := decodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("decodeCursor(%q): %v", encoded, err)
+		}
+		if *got != want {
+			t.Errorf("round trip = %+v, want %+v", *got, want)
+		}
+	}
+}
+
+func TestDecodeCursorMalformed(t *testing.T) {
+	tests := map[string]string{
+		"not base64":       "!!!",
+		"padded base64":    "eyJzIjoiYSJ9==",
+		"not json":         "bm90IGpzb24",
+		"json array":       "WzEsMl0",
+		"wrong value type": "eyJpZCI6ImEifQ", // {"id":"a"}
+	}
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeCursor(cursor); !errors.Is(err, ErrInvalidPage) {
+				t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidPage", cursor, err)
+			}
+		})
+	}
+}
+
+func TestCursorValue(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		name    string
+		kind    sortKind
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{name: "time keeps nanoseconds", kind: sortTime, value: ts, want: ts},
+		{name: "int", kind: sortInt, value: int64(-15), want: int64(-15)},
+		{name: "string", kind: sortString, value: "0000000001/0000000002", want: "0000000001/0000000002"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := sortField[model.Post]{kind: tt.kind}
+			got, err := cursorValue(field, formatCursorValue(tt.value))
+			if err != nil {
+				t.Fatalf("cursorValue: %v", err)
+			}
+			if want, ok := tt.want.(time.Time); ok {
+				if !got.(time.Time).Equal(want) {
+					t.Errorf("cursorValue = %v, want %v", got, want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("cursorValue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		kind sortKind
+		raw  string
+	}{{sortTime, "yesterday"}, {sortInt, "ten"}} {
+		if _, err := cursorValue(sortField[model.Post]{kind: tt.kind}, tt.raw); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("cursorValue(%q) error = %v, want ErrInvalidPage", tt.raw, err)
+		}
+	}
+}
+
+// TestCursorPagination 按游标逐页读取，结果与一次读取全部的顺序一致，且不重复、不遗漏
+func TestCursorPagination(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: "author"}
+	if err := store.Users.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post := &model.Post{Title: "p", Content: "c", UserID: user.ID, Status: model.PostStatusPublished}
+	if err := store.Posts.Create(ctx, post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	// 一部分评论的创建时间相同，需要按ID区分先后
+	base := time.Now().Add(-time.Hour)
+	for i := range 7 {
+		c := &model.Comment{Content: fmt.Sprint(i), PostID: post.ID, UserID: &user.ID, CreatedAt: base.Add(time.Duration(i/2) * time.Second)}
+		if err := store.Comments.Create(ctx, c); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+	}
+
+	for _, order := range []string{"asc", "desc"} {
+		t.Run(order, func(t *testing.T) {
+			all, err := store.Comments.ListByPost(ctx, post.ID, CommentFilter{}, PageRequest{Limit: 100, Order: order})
+			if err != nil {
+				t.Fatalf("list all: %v", err)
+			}
+
+			var got []uint
+			req := PageRequest{Limit: 3, Order: order}
+			for pages := 0; ; pages++ {
+				if pages > 10 {
+					t.Fatal("cursor pagination did not terminate")
+				}
+				page, err := store.Comments.ListByPost(ctx, post.ID, CommentFilter{}, req)
+				if err != nil {
+					t.Fatalf("list page: %v", err)
+				}
+				if page.Total != 7 {
+					t.Errorf("total = %d, want 7", page.Total)
+				}
+				for _, c := range page.Items {
+					got = append(got, c.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				// 游标中带有排序方向，后续请求不需要再传
+				req = PageRequest{Limit: 3, Cursor: page.NextCursor}
+			}
+
+			if len(got) != len(all.Items) {
+				t.Fatalf("got %d comments, want %d", len(got), len(all.Items))
+			}
+			for i := range got {
+				if got[i] != all.Items[i].ID {
+					t.Fatalf("paged order %v differs from full list at %d", got, i)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginateInvalidRequest(t *testing.T) {
+	store := newTestStore(t)
+	tests := map[string]PageRequest{
+		"unknown sort field":       {SortBy: "password"},
+		"bad order":                {Order: "sideways"},
+		"malformed cursor":         {Cursor: "???"},
+		"cursor with unknown sort": {Cursor: encodeCursor(pageCursor{SortBy: "password", Order: "asc", Value: "x", ID: 1})},
+		"cursor with bad value":    {Cursor: encodeCursor(pageCursor{SortBy: "created_at", Order: "asc", Value: "x", ID: 1})},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Comments.ListByPost(context.Background(), 1, CommentFilter{}, req)
+			if !errors.Is(err, ErrInvalidPage) {
+				t.Errorf("error = %v, want ErrInvalidPage", err)
+			}
+		})
+	}
+}
+
+// newTestStore 基于内存 SQLite 创建仓储并执行全部迁移
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return NewGormStore(newTestDB(t))
+}
+
+// newTestDB 打开内存 SQLite 并执行全部迁移
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { model.Close(db) })
+
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	return db
+}
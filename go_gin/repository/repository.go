@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// ErrVersionConflict 按版本号更新或删除时记录已被其他请求修改
+var ErrVersionConflict = errors.New("version conflict")
+
+// UserRepository 用户数据访问接口
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	FindByID(ctx context.Context, id uint) (*model.User, error)
+	FindByUsername(ctx context.Context, username string) (*model.User, error)
+	// FindByEmail 按邮箱查找用户，邮箱统一保存为小写，调用方需要先转换
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	// ExistsByUsernameOrEmail 检查用户名或邮箱是否已被占用
+	ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error)
+	UpdateRole(ctx context.Context, id uint, role string) error
+	// Update 只写入 fields 中的模型字段，如 "Email"
+	Update(ctx context.Context, user *model.User, fields ...string) error
+	// Delete 注销用户：用户名、邮箱改为占位值并清空密码后软删除，评论的作者置空，
+	// 文章和评论保留，但不再关联到可识别的用户，原用户名和邮箱可以重新注册
+	Delete(ctx context.Context, user *model.User) error
+}
+
+// PostRepository 文章数据访问接口
+type PostRepository interface {
+	// Create 创建文章并以作者为修改人保存第1个修订
+	Create(ctx context.Context, post *model.Post) error
+	// FindByID 查询文章，包含标签和分类，不加载作者和评论
+	FindByID(ctx context.Context, id uint) (*model.Post, error)
+	// FindDetail 查询文章详情，包含作者、评论、标签和分类
+	FindDetail(ctx context.Context, id uint) (*model.Post, error)
+	// FindByIDs 按ID批量查询文章，包含作者、标签和分类，结果顺序不保证
+	FindByIDs(ctx context.Context, ids []uint) ([]model.Post, error)
+	// PublishedIDs 返回 ids 中已发布且未删除的文章ID，不存在的ID被忽略
+	PublishedIDs(ctx context.Context, ids []uint) ([]uint, error)
+	// EachBatch 按ID顺序分批遍历全部文章
+	EachBatch(ctx context.Context, batchSize int, fn func(posts []model.Post) error) error
+	// List 分页查询文章列表，包含作者、标签、分类和评论数
+	List(ctx context.Context, filter PostFilter, page PageRequest) (*Page[model.Post], error)
+	// Update 保存文章并追加一个修订，revision 只需填写修改人和 RestoredFrom，修订号和快照内容由仓储填写
+	// fields 为要写入的模型字段名，如 "Title"、"Tags"；为空时写入全部字段，Tags、Categories 不为 nil 时整体替换对应的关联。
+	// 只有数据库中的版本号仍等于 post.Version 时才会保存，成功后版本号加1，否则返回 ErrVersionConflict
+	Update(ctx context.Context, post *model.Post, revision *model.PostRevision, fields ...string) error
+	// PublishDue 把发布时间不晚于 now 的定时文章改为已发布，返回本次发布的文章
+	// 多个实例同时执行时每篇文章只会被其中一个实例发布
+	PublishDue(ctx context.Context, now time.Time, limit int) ([]model.Post, error)
+	// Delete 删除文章，数据库中的版本号与 post.Version 不一致时返回 ErrVersionConflict
+	Delete(ctx context.Context, post *model.Post) error
+}
+
+// RevisionRepository 文章修订数据访问接口，修订只由 PostRepository 在创建和更新文章时写入
+type RevisionRepository interface {
+	// ListByPost 按修订号从新到旧返回文章的全部修订，包含修改人，不加载内容
+	ListByPost(ctx context.Context, postID uint) ([]model.PostRevision, error)
+	// FindByNumber 查询文章的指定修订，包含修改人和内容
+	FindByNumber(ctx context.Context, postID uint, number int) (*model.PostRevision, error)
+}
+
+// CommentRepository 评论数据访问接口
+type CommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	FindByID(ctx context.Context, id uint) (*model.Comment, error)
+	Update(ctx context.Context, comment *model.Comment) error
+	Delete(ctx context.Context, comment *model.Comment) error
+	// ListByPost 分页查询文章下的评论，包含评论作者
+	ListByPost(ctx context.Context, postID uint, filter CommentFilter, page PageRequest) (*Page[model.Comment], error)
+	// ListReplies 查询若干顶层评论下的全部回复，按楼层顺序排列
+	ListReplies(ctx context.Context, postID uint, rootPaths []string) ([]model.Comment, error)
+}
+
+// TagRepository 标签数据访问接口
+type TagRepository interface {
+	FindByID(ctx context.Context, id uint) (*model.Tag, error)
+	// FindOrCreate 按 Slug 查找标签，不存在的按给定名称创建，并发创建同一个 Slug 时只会保留一个
+	FindOrCreate(ctx context.Context, tags []model.Tag) ([]model.Tag, error)
+	// Cloud 按已发布文章数从多到少返回标签及其文章数，limit 为0时不限制数量
+	Cloud(ctx context.Context, limit int) ([]model.Tag, error)
+	Create(ctx context.Context, tag *model.Tag) error
+	Update(ctx context.Context, tag *model.Tag) error
+	// Delete 删除标签及其与文章的关联
+	Delete(ctx context.Context, tag *model.Tag) error
+}
+
+// CategoryRepository 分类数据访问接口
+type CategoryRepository interface {
+	FindByID(ctx context.Context, id uint) (*model.Category, error)
+	// FindByIDs 按ID批量查询分类，不存在的ID被忽略
+	FindByIDs(ctx context.Context, ids []uint) ([]model.Category, error)
+	// List 按名称返回全部分类及其已发布文章数
+	List(ctx context.Context) ([]model.Category, error)
+	Create(ctx context.Context, category *model.Category) error
+	Update(ctx context.Context, category *model.Category) error
+	// Delete 删除分类及其与文章的关联，文章本身不受影响
+	Delete(ctx context.Context, category *model.Category) error
+}
+
+// TokenRepository 刷新令牌和访问令牌吊销列表的数据访问接口
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	// MarkRefreshTokenUsed 将未使用且未吊销的令牌标记为已使用，返回是否标记成功
+	// 并发轮换同一个令牌时只有一个请求会成功
+	MarkRefreshTokenUsed(ctx context.Context, id uint, usedAt time.Time) (bool, error)
+	// RevokeFamily 吊销一个会话下的全部刷新令牌
+	RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error
+	// RevokeUserFamilies 吊销用户全部会话的刷新令牌，返回受影响的会话ID
+	RevokeUserFamilies(ctx context.Context, userID uint, revokedAt time.Time) ([]string, error)
+	// AddRevoked 把 jti 或 sid 加入访问令牌吊销列表，同时清理已过期的记录
+	AddRevoked(ctx context.Context, ids []string, expiresAt time.Time) error
+	// IsRevoked 检查任一ID是否在吊销列表中
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
+
+	// CreateUserToken 保存一次性令牌，同时作废该用户同一用途下未使用的令牌，只有最新发出的链接有效
+	CreateUserToken(ctx context.Context, token *model.UserToken) error
+	FindUserTokenByHash(ctx context.Context, hash string) (*model.UserToken, error)
+	// MarkUserTokenUsed 将未使用的令牌标记为已使用，返回是否标记成功，同一个令牌只能成功使用一次
+	MarkUserTokenUsed(ctx context.Context, id uint, usedAt time.Time) (bool, error)
+	// DeleteUserTokens 删除用户某一用途下未使用的令牌
+	DeleteUserTokens(ctx context.Context, userID uint, purpose string) error
+}
+
+// EngagementRepository 点赞、收藏和浏览计数的数据访问接口
+// 点赞和收藏记录与文章上的计数在同一事务中修改，重复点赞或取消不会改变计数
+type EngagementRepository interface {
+	// Like 点赞文章，返回是否新增了点赞
+	Like(ctx context.Context, userID, postID uint) (bool, error)
+	// Unlike 取消点赞，返回是否删除了点赞
+	Unlike(ctx context.Context, userID, postID uint) (bool, error)
+	// Bookmark 收藏文章，返回是否新增了收藏
+	Bookmark(ctx context.Context, userID, postID uint) (bool, error)
+	// Unbookmark 取消收藏，返回是否删除了收藏
+	Unbookmark(ctx context.Context, userID, postID uint) (bool, error)
+	// State 查询用户是否点赞、收藏了文章
+	State(ctx context.Context, userID, postID uint) (*EngagementState, error)
+	// ListBookmarks 分页查询用户收藏的文章，包含文章的作者、标签和分类，已删除和不在可见范围内的文章不返回
+	ListBookmarks(ctx context.Context, userID uint, visibility Visibility, page PageRequest) (*Page[model.Bookmark], error)
+	// AddViews 按文章ID批量累加浏览数，不修改文章的版本号和更新时间
+	AddViews(ctx context.Context, views map[uint]int64) error
+}
+
+// EngagementState 当前用户与一篇文章的互动状态
+type EngagementState struct {
+	Liked      bool
+	Bookmarked bool
+}
+
+// CounterRepository 冗余计数的校对接口
+// 评论数、已发布文章数、点赞数和收藏数在写入明细的同一事务中维护，手工修改数据后可能与明细不一致
+type CounterRepository interface {
+	// Reconcile 按明细重新统计全部冗余计数，返回每个计数列不一致的行数，dryRun 为 true 时只检查不修改
+	Reconcile(ctx context.Context, dryRun bool) ([]CounterDrift, error)
+}
+
+// CounterDrift 一个计数列与明细不一致的行数
+type CounterDrift struct {
+	Counter string // 表名.列名，如 posts.comment_count
+	Rows    int64
+}
+
+// PostFilter 文章列表过滤条件，零值表示不过滤
+type PostFilter struct {
+	Visibility   Visibility // 可见范围，零值只包含已发布的文章
+	Status       string     // 文章状态，在可见范围内进一步过滤
+	UserID       uint       // 作者
+	TagSlug      string     // 标签
+	CategorySlug string     // 分类
+	CreatedFrom  *time.Time // 创建时间下限（含）
+	CreatedTo    *time.Time // 创建时间上限（含）
+}
+
+// Visibility 文章列表对当前访问者的可见范围
+type Visibility struct {
+	All    bool // 全部文章，用于管理员
+	UserID uint // 已发布的文章加上该用户自己的全部文章，用于登录用户
+}
+
+// CommentFilter 评论列表过滤条件，零值表示不过滤
+type CommentFilter struct {
+	RootsOnly   bool // 只查询顶层评论，包含已删除但仍有回复的顶层评论
+	UserID      uint
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// Store 汇总所有仓储，便于一次性注入到路由和处理函数
+type Store struct {
+	Users      UserRepository
+	Posts      PostRepository
+	Comments   CommentRepository
+	Tags       TagRepository
+	Categories CategoryRepository
+	Revisions  RevisionRepository
+	Tokens     TokenRepository
+	Engagement EngagementRepository
+	Counters   CounterRepository
+}
@@ -2,40 +2,178 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/apperr"
+	"github.com/zhanglegen/go_task/go_gin/auth"
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/dto"
 	"github.com/zhanglegen/go_task/go_gin/handlers"
 	"github.com/zhanglegen/go_task/go_gin/login"
 	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/ratelimit"
+	"github.com/zhanglegen/go_task/go_gin/rbac"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+	"github.com/zhanglegen/go_task/go_gin/search"
+	"github.com/zhanglegen/go_task/go_gin/telemetry"
 )
 
-// SetupRouter 设置路由
-func SetupRouter() *gin.Engine {
-	router := gin.Default()
+// Dependencies 路由处理函数依赖的服务
+type Dependencies struct {
+	Store    *repository.Store
+	Searcher search.Searcher
+	Views    handlers.ViewRecorder
+	Sessions *auth.Service
+	Accounts *auth.Accounts
+	Health   *handlers.HealthHandler
+	// CommentMaxDepth 评论树形展示的默认最大层级
+	CommentMaxDepth int
+	// RateLimitStore 限流和登录失败锁定的状态存储
+	RateLimitStore ratelimit.Store
+	RateLimits     config.RateLimitConfig
+	// TrustedProxies 可信的反向代理，决定 ClientIP 是否采用 X-Forwarded-For
+	TrustedProxies []string
+	// Metrics 为 nil 时不记录请求指标，也不注册指标接口
+	Metrics     *telemetry.Metrics
+	MetricsPath string
+}
 
-	// 公共路由
-	public := router.Group("/api")
+// SetupRouter 设置路由，所有处理函数通过deps访问数据
+func SetupRouter(deps Dependencies) (*gin.Engine, error) {
+	// 访问日志使用结构化日志中间件，不使用 gin.Default 自带的文本日志；
+	// Tracing 放在最外层，日志和数据库操作都能关联到请求的span；
+	// ErrorHandler 负责把处理函数记录的错误和 panic 渲染为统一的错误响应
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.Use(middleware.Tracing())
+	if deps.Metrics != nil {
+		router.Use(middleware.Metrics(deps.Metrics))
+	}
+	router.Use(middleware.RequestLogger(), middleware.ErrorHandler())
+	router.NoRoute(middleware.NotFoundHandler)
+	router.NoMethod(middleware.MethodNotAllowedHandler)
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		return nil, err
+	}
+	apperr.UseJSONFieldNames()
+	if err := dto.RegisterValidators(); err != nil {
+		return nil, err
+	}
+	store := deps.Store
+
+	// 每个路由分组使用独立的令牌桶，limits.Enabled 为 false 时全部不限流
+	limits := deps.RateLimits
+	rateLimit := func(name string, rule config.RateLimitRule, by middleware.RateLimitKey) gin.HandlerFunc {
+		if !limits.Enabled {
+			rule = config.RateLimitRule{}
+		}
+		return middleware.RateLimit(deps.RateLimitStore, name,
+			ratelimit.Every(rule.Requests, rule.Per.Duration, rule.Burst), by)
+	}
+	var lockoutPolicy ratelimit.LockoutPolicy
+	if limits.Enabled {
+		lockoutPolicy = ratelimit.LockoutPolicy{
+			MaxFailures: limits.Lockout.MaxFailures,
+			Window:      limits.Lockout.Window.Duration,
+			BaseDelay:   limits.Lockout.BaseDelay.Duration,
+			MaxDelay:    limits.Lockout.MaxDelay.Duration,
+		}
+	}
+
+	authHandler := login.NewHandler(store.Users, deps.Sessions, deps.Accounts, ratelimit.NewLockout(deps.RateLimitStore, lockoutPolicy))
+	postHandler := handlers.NewPostHandler(store.Posts, store.Revisions, store.Tags, store.Categories,
+		store.Engagement, deps.Views, deps.Searcher)
+	taxonomyHandler := handlers.NewTaxonomyHandler(store.Tags, store.Categories)
+	commentHandler := handlers.NewCommentHandler(store.Posts, store.Comments, deps.CommentMaxDepth)
+	userHandler := handlers.NewUserHandler(store.Users, deps.Sessions, deps.Accounts)
+
+	// 存活、就绪检查和指标，供编排系统和监控探测，不限流
+	router.GET("/healthz", deps.Health.Healthz)
+	router.GET("/readyz", deps.Health.Readyz)
+	if deps.Metrics != nil {
+		router.GET(deps.MetricsPath, gin.WrapH(deps.Metrics.Handler()))
+	}
+
+	// 用户认证，按IP限流防止暴力破解
+	authRoutes := router.Group("/api", rateLimit("auth", limits.Auth, middleware.ByIP))
 	{
-		// 用户认证
-		public.POST("/register", login.Register)
-		public.POST("/login", login.Login)
+		authRoutes.POST("/register", authHandler.Register)
+		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/token/refresh", authHandler.Refresh)
 
+		// 邮箱验证和找回密码，同样按IP限流防止批量发信和猜测令牌
+		authRoutes.POST("/email/verify", authHandler.VerifyEmail)
+		authRoutes.POST("/email/verify/resend", authHandler.ResendVerification)
+		authRoutes.POST("/password/forgot", authHandler.ForgotPassword)
+		authRoutes.POST("/password/reset", authHandler.ResetPassword)
+	}
+
+	// 公共路由，携带令牌时识别当前用户，作者、版主和管理员可以看到未发布的文章
+	public := router.Group("/api", middleware.OptionalAuth(deps.Sessions), rateLimit("read", limits.Read, middleware.ByIP))
+	{
 		// 文章相关（无需认证）
-		public.GET("/posts", handlers.GetPosts)
-		public.GET("/posts/:id", handlers.GetPost)
-		public.GET("/posts/:id/comments", handlers.GetComments)
+		public.GET("/posts", postHandler.GetPosts)
+		public.GET("/posts/search", postHandler.SearchPosts)
+		public.GET("/posts/:id", postHandler.GetPost)
+		public.GET("/posts/:id/comments", commentHandler.GetComments)
+
+		// 用户公开资料
+		public.GET("/users/:id", userHandler.GetUser)
+
+		// 标签云和分类
+		public.GET("/tags", taxonomyHandler.GetTags)
+		public.GET("/categories", taxonomyHandler.GetCategories)
 	}
 
-	// 需要认证的路由
-	protected := router.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
+	// 需要认证的路由，写操作使用 write 分组的额度，查询使用 read 分组的额度，都按用户计数
+	protected := router.Group("/api", middleware.AuthMiddleware(deps.Sessions))
+	read := rateLimit("read", limits.Read, middleware.ByUser)
+	write := rateLimit("write", limits.Write, middleware.ByUser)
 	{
-		// 文章管理
-		protected.POST("/posts", handlers.CreatePost)
-		protected.PUT("/posts/:id", handlers.UpdatePost)
-		protected.DELETE("/posts/:id", handlers.DeletePost)
+		// 会话管理
+		protected.POST("/logout", write, authHandler.Logout)
+
+		// 文章和评论的编辑、删除在处理函数中按作者或角色判断
+		protected.POST("/posts", write, middleware.RequirePermission(rbac.PermPostCreate), postHandler.CreatePost)
+		protected.PUT("/posts/:id", write, postHandler.UpdatePost)
+		protected.PATCH("/posts/:id", write, postHandler.PatchPost)
+		protected.DELETE("/posts/:id", write, postHandler.DeletePost)
+
+		// 文章修订历史，作者本人或版主、管理员可以查看和恢复
+		protected.GET("/posts/:id/revisions", read, postHandler.ListRevisions)
+		protected.GET("/posts/:id/revisions/diff", read, postHandler.DiffRevisions)
+		protected.GET("/posts/:id/revisions/:rev", read, postHandler.GetRevision)
+		protected.POST("/posts/:id/revisions/:rev/restore", write, postHandler.RestoreRevision)
+
+		// 点赞和收藏，重复操作是幂等的
+		protected.POST("/posts/:id/like", write, postHandler.LikePost)
+		protected.DELETE("/posts/:id/like", write, postHandler.UnlikePost)
+		protected.POST("/posts/:id/bookmark", write, postHandler.BookmarkPost)
+		protected.DELETE("/posts/:id/bookmark", write, postHandler.UnbookmarkPost)
 
 		// 评论管理
-		protected.POST("/posts/:id/comments", handlers.CreateComment)
+		protected.POST("/posts/:id/comments", write, middleware.RequirePermission(rbac.PermCommentCreate), commentHandler.CreateComment)
+		protected.PUT("/comments/:id", write, commentHandler.UpdateComment)
+		protected.DELETE("/comments/:id", write, commentHandler.DeleteComment)
+
+		// 标签和分类维护
+		manageTaxonomy := middleware.RequirePermission(rbac.PermTaxonomyManage)
+		protected.POST("/tags", write, manageTaxonomy, taxonomyHandler.CreateTag)
+		protected.PUT("/tags/:id", write, manageTaxonomy, taxonomyHandler.UpdateTag)
+		protected.DELETE("/tags/:id", write, manageTaxonomy, taxonomyHandler.DeleteTag)
+		protected.POST("/categories", write, manageTaxonomy, taxonomyHandler.CreateCategory)
+		protected.PUT("/categories/:id", write, manageTaxonomy, taxonomyHandler.UpdateCategory)
+		protected.DELETE("/categories/:id", write, manageTaxonomy, taxonomyHandler.DeleteCategory)
+
+		// 个人资料和账号
+		protected.GET("/users/me", read, userHandler.GetMe)
+		protected.PUT("/users/me", write, userHandler.UpdateMe)
+		protected.PATCH("/users/me", write, userHandler.PatchMe)
+		protected.POST("/users/me/password", write, userHandler.ChangePassword)
+		protected.DELETE("/users/me", write, userHandler.DeleteMe)
+		protected.GET("/users/me/bookmarks", read, postHandler.ListBookmarks)
+
+		// 用户管理
+		protected.PUT("/users/:id/role", write, middleware.RequirePermission(rbac.PermUserManageRoles), userHandler.UpdateRole)
 	}
 
-	return router
-}
\ No newline at end of file
+	return router, nil
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhanglegen/go_task/go_gin/auth"
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/handlers"
+	"github.com/zhanglegen/go_task/go_gin/middleware"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/migrations"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/ratelimit"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	key, err := middleware.NewHMACKey("test", []byte("test-secret"))
+	if err != nil {
+		panic(err)
+	}
+	keys, err := middleware.NewKeySet("test", key)
+	if err != nil {
+		panic(err)
+	}
+	middleware.InitJWT(keys, 15*time.Minute)
+}
+
+// newTestRouter 基于内存 SQLite 创建完整的路由，返回路由和一个作者的访问令牌
+func newTestRouter(t *testing.T, limits config.RateLimitConfig) (*gin.Engine, string) {
+	t.Helper()
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { model.Close(db) })
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	store := repository.NewGormStore(db)
+
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: "author"}
+	if err := store.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	sessions := auth.NewService(store.Users, store.Tokens, time.Hour)
+	tokens, err := sessions.IssueTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("issue tokens: %v", err)
+	}
+
+	router, err := SetupRouter(Dependencies{
+		Store:          store,
+		Sessions:       sessions,
+		Health:         handlers.NewHealthHandler(),
+		RateLimitStore: ratelimit.NewMemoryStore(),
+		RateLimits:     limits,
+	})
+	if err != nil {
+		t.Fatalf("SetupRouter: %v", err)
+	}
+	return router, tokens.AccessToken
+}
+
+// TestWriteLimitOnlyForWrites 需要认证的查询接口不消耗写操作的额度
+func TestWriteLimitOnlyForWrites(t *testing.T) {
+	limits := config.Default().RateLimit
+	limits.Read = config.RateLimitRule{Requests: 100, Per: config.Duration{Duration: time.Minute}, Burst: 100}
+	limits.Write = config.RateLimitRule{Requests: 1, Per: config.Duration{Duration: time.Minute}, Burst: 2}
+	router, token := newTestRouter(t, limits)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 5; i++ {
+		w := do(http.MethodGet, "/api/users/me", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /users/me #%d: status = %d, body %s", i+1, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "100" {
+			t.Errorf("GET /users/me: X-RateLimit-Limit = %q, want the read limit 100", got)
+		}
+	}
+
+	// 写操作的额度没有被查询消耗，校验失败的请求也计入额度
+	for i, remaining := range []string{"1", "0"} {
+		w := do(http.MethodPost, "/api/posts", `{}`)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("POST /posts #%d: status = %d, want 400", i+1, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("POST /posts: X-RateLimit-Limit = %q, want the write limit 2", got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != remaining {
+			t.Errorf("POST /posts #%d: X-RateLimit-Remaining = %q, want %s", i+1, got, remaining)
+		}
+	}
+	if w := do(http.MethodPatch, "/api/users/me", `{}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("PATCH /users/me: status = %d, want 429", w.Code)
+	}
+
+	// 写操作用完额度后查询不受影响
+	if w := do(http.MethodGet, "/api/users/me", ""); w.Code != http.StatusOK {
+		t.Errorf("GET /users/me after write limit: status = %d, want 200", w.Code)
+	}
+}
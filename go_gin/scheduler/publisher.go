@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// publishBatch 每次最多发布的文章数，积压较多时在同一轮中分批处理
+const publishBatch = 100
+
+// PostPublisher 定时把到期的定时文章改为已发布
+// 状态保存在数据库中，服务重启后启动时的第一次检查会补发停机期间到期的文章
+type PostPublisher struct {
+	posts     repository.PostRepository
+	interval  time.Duration
+	onPublish func(ctx context.Context, post *model.Post)
+}
+
+// NewPostPublisher 创建定时发布器，onPublish 在每篇文章发布后调用，用于同步搜索索引，可以为 nil
+func NewPostPublisher(posts repository.PostRepository, interval time.Duration,
+	onPublish func(ctx context.Context, post *model.Post)) *PostPublisher {
+	return &PostPublisher{posts: posts, interval: interval, onPublish: onPublish}
+}
+
+// Run 立即检查一次，之后每隔 interval 检查，直到 ctx 被取消
+func (p *PostPublisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.PublishDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue 发布所有已到期的定时文章，返回发布的数量
+func (p *PostPublisher) PublishDue(ctx context.Context) int {
+	total := 0
+	for ctx.Err() == nil {
+		posts, err := p.posts.PublishDue(ctx, time.Now().UTC(), publishBatch)
+		for i := range posts {
+			slog.InfoContext(ctx, "Scheduled post published", "post_id", posts[i].ID, "published_at", posts[i].PublishedAt)
+			if p.onPublish != nil {
+				p.onPublish(ctx, &posts[i])
+			}
+		}
+		total += len(posts)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to publish scheduled posts", "error", err)
+			}
+			return total
+		}
+		if len(posts) < publishBatch {
+			return total
+		}
+	}
+	return total
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/config"
+	"github.com/zhanglegen/go_task/go_gin/migrate"
+	"github.com/zhanglegen/go_task/go_gin/migrations"
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// newTestStore 基于内存 SQLite 创建仓储并执行全部迁移
+func newTestStore(t *testing.T) *repository.Store {
+	t.Helper()
+	db, err := model.InitDb(config.DatabaseConfig{Driver: model.DriverSQLite, MaxOpenConns: 1, MaxIdleConns: 1}, "warn")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { model.Close(db) })
+
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	return repository.NewGormStore(db)
+}
+
+func createUser(t *testing.T, store *repository.Store) *model.User {
+	t.Helper()
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", Role: "author"}
+	if err := store.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func createPost(t *testing.T, store *repository.Store, userID uint, title, status string, publishedAt time.Time) *model.Post {
+	t.Helper()
+	post := &model.Post{Title: title, Content: "content", UserID: userID, Status: status, PublishedAt: &publishedAt}
+	if err := store.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	return post
+}
+
+func TestPublishDue(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user := createUser(t, store)
+
+	now := time.Now().UTC()
+	due := createPost(t, store, user.ID, "due", model.PostStatusScheduled, now.Add(-time.Minute))
+	overdue := createPost(t, store, user.ID, "overdue", model.PostStatusScheduled, now.Add(-24*time.Hour))
+	future := createPost(t, store, user.ID, "future", model.PostStatusScheduled, now.Add(time.Hour))
+	draft := createPost(t, store, user.ID, "draft", model.PostStatusDraft, now.Add(-time.Hour))
+
+	var published []uint
+	p := NewPostPublisher(store.Posts, time.Minute, func(_ context.Context, post *model.Post) {
+		published = append(published, post.ID)
+	})
+	if n := p.PublishDue(ctx); n != 2 {
+		t.Errorf("PublishDue = %d, want 2", n)
+	}
+	// 按发布时间先后发布
+	if len(published) != 2 || published[0] != overdue.ID || published[1] != due.ID {
+		t.Errorf("onPublish called for %v, want [%d %d]", published, overdue.ID, due.ID)
+	}
+
+	wantStatus := map[*model.Post]string{
+		due:     model.PostStatusPublished,
+		overdue: model.PostStatusPublished,
+		future:  model.PostStatusScheduled,
+		draft:   model.PostStatusDraft,
+	}
+	for post, status := range wantStatus {
+		got, err := store.Posts.FindByID(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("find post %d: %v", post.ID, err)
+		}
+		if got.Status != status {
+			t.Errorf("post %q status = %s, want %s", post.Title, got.Status, status)
+		}
+		// 定时文章保留预定的发布时间
+		if !got.PublishedAt.Equal(*post.PublishedAt) {
+			t.Errorf("post %q published_at = %v, want %v", post.Title, got.PublishedAt, post.PublishedAt)
+		}
+	}
+
+	author, err := store.Users.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if author.PostCount != 2 {
+		t.Errorf("post_count = %d, want 2", author.PostCount)
+	}
+
+	// 已发布的文章不会重复发布
+	published = nil
+	if n := p.PublishDue(ctx); n != 0 || len(published) != 0 {
+		t.Errorf("second PublishDue = %d (%v), want nothing", n, published)
+	}
+}
+
+// TestPublisherRun 启动时立即检查一次，补发停机期间到期的文章，ctx 取消后退出
+func TestPublisherRun(t *testing.T) {
+	store := newTestStore(t)
+	post := createPost(t, store, createUser(t, store).ID, "due", model.PostStatusScheduled, time.Now().UTC().Add(-time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	published := make(chan uint, 1)
+	p := NewPostPublisher(store.Posts, time.Hour, func(_ context.Context, post *model.Post) {
+		published <- post.ID
+		cancel()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	select {
+	case id := <-published:
+		if id != post.ID {
+			t.Errorf("published post %d, want %d", id, post.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("post was not published on start")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
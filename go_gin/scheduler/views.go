@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// ViewCounter 在内存中累计文章浏览数，定期批量写入数据库，避免每次查看文章都产生一次写操作
+// 进程异常退出时会丢失最后一个周期内的浏览数，正常退出时 Run 在返回前写入剩余的计数
+type ViewCounter struct {
+	engagement repository.EngagementRepository
+	interval   time.Duration
+
+	mu      sync.Mutex
+	pending map[uint]int64
+}
+
+// NewViewCounter 创建浏览计数器，每隔 interval 写入一次
+func NewViewCounter(engagement repository.EngagementRepository, interval time.Duration) *ViewCounter {
+	return &ViewCounter{engagement: engagement, interval: interval, pending: make(map[uint]int64)}
+}
+
+// Add 记录一次浏览
+func (v *ViewCounter) Add(postID uint) {
+	v.mu.Lock()
+	v.pending[postID]++
+	v.mu.Unlock()
+}
+
+// Run 每隔 interval 写入一次，ctx 被取消后写入剩余的计数再返回
+func (v *ViewCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// 服务退出时 ctx 已取消，最后一次写入使用独立的超时
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			v.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			v.Flush(ctx)
+		}
+	}
+}
+
+// Flush 把累计的浏览数写入数据库，写入失败时计数放回缓冲区，下次再写，返回写入的文章数
+func (v *ViewCounter) Flush(ctx context.Context) int {
+	v.mu.Lock()
+	views := v.pending
+	v.pending = make(map[uint]int64)
+	v.mu.Unlock()
+	if len(views) == 0 {
+		return 0
+	}
+
+	if err := v.engagement.AddViews(ctx, views); err != nil {
+		slog.ErrorContext(ctx, "Failed to flush post views", "posts", len(views), "error", err)
+		v.mu.Lock()
+		for id, n := range views {
+			v.pending[id] += n
+		}
+		v.mu.Unlock()
+		return 0
+	}
+	return len(views)
+}
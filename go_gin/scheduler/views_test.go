@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"github.com/zhanglegen/go_task/go_gin/repository"
+)
+
+// flakyEngagement AddViews 在 fail 为 true 时返回错误，其余方法使用内嵌的仓储
+type flakyEngagement struct {
+	repository.EngagementRepository
+	fail  bool
+	calls int
+}
+
+func (f *flakyEngagement) AddViews(ctx context.Context, views map[uint]int64) error {
+	f.calls++
+	if f.fail {
+		return errors.New("database is down")
+	}
+	return f.EngagementRepository.AddViews(ctx, views)
+}
+
+func viewCount(t *testing.T, store *repository.Store, postID uint) int64 {
+	t.Helper()
+	post, err := store.Posts.FindByID(context.Background(), postID)
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+	return post.ViewCount
+}
+
+func TestViewCounterFlush(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user := createUser(t, store)
+	now := time.Now()
+	a := createPost(t, store, user.ID, "a", model.PostStatusPublished, now)
+	b := createPost(t, store, user.ID, "b", model.PostStatusPublished, now)
+	before, err := store.Posts.FindByID(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+
+	engagement := &flakyEngagement{EngagementRepository: store.Engagement}
+	v := NewViewCounter(engagement, time.Hour)
+	for range 3 {
+		v.Add(a.ID)
+	}
+	v.Add(b.ID)
+	// 写入之前浏览数只在内存中累计
+	if got := viewCount(t, store, a.ID); got != 0 {
+		t.Errorf("view_count before flush = %d, want 0", got)
+	}
+
+	if n := v.Flush(ctx); n != 2 {
+		t.Errorf("Flush = %d, want 2", n)
+	}
+	if got := viewCount(t, store, a.ID); got != 3 {
+		t.Errorf("post a view_count = %d, want 3", got)
+	}
+	if got := viewCount(t, store, b.ID); got != 1 {
+		t.Errorf("post b view_count = %d, want 1", got)
+	}
+	// 累加浏览数不修改版本号和更新时间
+	after, err := store.Posts.FindByID(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("find post: %v", err)
+	}
+	if after.Version != before.Version || !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("version %d updated_at %v after flush, want %d %v", after.Version, after.UpdatedAt, before.Version, before.UpdatedAt)
+	}
+
+	// 缓冲区为空时不访问数据库
+	calls := engagement.calls
+	if n := v.Flush(ctx); n != 0 || engagement.calls != calls {
+		t.Errorf("empty Flush = %d with %d writes, want 0 without writes", n, engagement.calls-calls)
+	}
+
+	// 写入失败时计数放回缓冲区，与之后的浏览一起写入
+	engagement.fail = true
+	v.Add(a.ID)
+	if n := v.Flush(ctx); n != 0 {
+		t.Errorf("failed Flush = %d, want 0", n)
+	}
+	engagement.fail = false
+	v.Add(a.ID)
+	if n := v.Flush(ctx); n != 1 {
+		t.Errorf("Flush after failure = %d, want 1", n)
+	}
+	if got := viewCount(t, store, a.ID); got != 5 {
+		t.Errorf("post a view_count = %d, want 5", got)
+	}
+}
+
+// TestViewCounterRun 定期写入，ctx 取消后写入剩余的计数再返回
+func TestViewCounterRun(t *testing.T) {
+	store := newTestStore(t)
+	user := createUser(t, store)
+	post := createPost(t, store, user.ID, "a", model.PostStatusPublished, time.Now())
+
+	v := NewViewCounter(store.Engagement, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		v.Run(ctx)
+		close(done)
+	}()
+
+	v.Add(post.ID)
+	deadline := time.Now().Add(time.Second)
+	for viewCount(t, store, post.ID) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("views not flushed by the ticker")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	// 周期未到时取消，剩余的计数在退出前写入
+	v = NewViewCounter(store.Engagement, time.Hour)
+	ctx, cancel = context.WithCancel(context.Background())
+	done = make(chan struct{})
+	go func() {
+		v.Run(ctx)
+		close(done)
+	}()
+	v.Add(post.ID)
+	v.Add(post.ID)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if got := viewCount(t, store, post.ID); got != 3 {
+		t.Errorf("view_count after shutdown = %d, want 3", got)
+	}
+}
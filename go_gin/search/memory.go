@@ -0,0 +1,170 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// titleWeight 标题中的词按出现多次计算，使标题命中排名更高
+	titleWeight = 3
+)
+
+// MemoryIndex 进程内倒排索引，用于SQLite和测试环境
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[uint]int // 词 -> 文章ID -> 词频
+	docLen   map[uint]int            // 文章ID -> 词数
+	docTerms map[uint][]string       // 文章ID -> 包含的词，用于删除
+	totalLen int
+	visible  VisibleFilter // 为 nil 时信任索引内容，不再查询数据库
+}
+
+// NewMemoryIndex 创建空的进程内索引
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		postings: make(map[string]map[uint]int),
+		docLen:   make(map[uint]int),
+		docTerms: make(map[uint][]string),
+	}
+}
+
+// Index 新增或更新文章索引，未发布的文章从索引中移除
+func (m *MemoryIndex) Index(_ context.Context, post *model.Post) error {
+	if !post.Published() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.remove(post.ID)
+		return nil
+	}
+	m.add(post)
+	return nil
+}
+
+// Remove 删除文章索引
+func (m *MemoryIndex) Remove(_ context.Context, postID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(postID)
+	return nil
+}
+
+func (m *MemoryIndex) add(post *model.Post) {
+	freq := make(map[string]int)
+	length := 0
+	for _, t := range Tokenize(post.Title) {
+		freq[t] += titleWeight
+		length += titleWeight
+	}
+	for _, t := range Tokenize(post.Content) {
+		freq[t]++
+		length++
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(post.ID)
+
+	terms := make([]string, 0, len(freq))
+	for t, n := range freq {
+		docs := m.postings[t]
+		if docs == nil {
+			docs = make(map[uint]int)
+			m.postings[t] = docs
+		}
+		docs[post.ID] = n
+		terms = append(terms, t)
+	}
+	m.docTerms[post.ID] = terms
+	m.docLen[post.ID] = length
+	m.totalLen += length
+}
+
+// remove 调用方需持有写锁
+func (m *MemoryIndex) remove(postID uint) {
+	for _, t := range m.docTerms[postID] {
+		docs := m.postings[t]
+		delete(docs, postID)
+		if len(docs) == 0 {
+			delete(m.postings, t)
+		}
+	}
+	m.totalLen -= m.docLen[postID]
+	delete(m.docTerms, postID)
+	delete(m.docLen, postID)
+}
+
+// Search 使用BM25对包含任一检索词的文章打分
+// 先剔除已撤回发布或已删除的文章再分页，命中总数只包含可见的文章
+func (m *MemoryIndex) Search(ctx context.Context, query string, limit, offset int) ([]Hit, int64, error) {
+	terms := uniqueTokens(query)
+
+	m.mu.RLock()
+	n := float64(len(m.docLen))
+	avgLen := 1.0
+	if n > 0 {
+		avgLen = float64(m.totalLen) / n
+	}
+
+	scores := make(map[uint]float64)
+	for _, t := range terms {
+		docs := m.postings[t]
+		if len(docs) == 0 {
+			continue
+		}
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range docs {
+			f := float64(tf)
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(m.docLen[id])/avgLen)
+			scores[id] += idf * f * (bm25K1 + 1) / (f + norm)
+		}
+	}
+	m.mu.RUnlock()
+
+	if m.visible != nil && len(scores) > 0 {
+		ids := make([]uint, 0, len(scores))
+		for id := range scores {
+			ids = append(ids, id)
+		}
+		visible, err := m.visible(ctx, ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		keep := make(map[uint]float64, len(visible))
+		for _, id := range visible {
+			if s, ok := scores[id]; ok {
+				keep[id] = s
+			}
+		}
+		scores = keep
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, Hit{PostID: id, Score: s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].PostID > hits[j].PostID
+	})
+
+	total := int64(len(hits))
+	if offset >= len(hits) {
+		return nil, total, nil
+	}
+	hits = hits[offset:]
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, total, nil
+}
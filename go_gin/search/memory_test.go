@@ -0,0 +1,208 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+)
+
+func indexPosts(t *testing.T, idx *MemoryIndex, posts ...*model.Post) {
+	t.Helper()
+	for _, p := range posts {
+		if p.Status == "" {
+			p.Status = model.PostStatusPublished
+		}
+		if err := idx.Index(context.Background(), p); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+}
+
+func hitIDs(hits []Hit) []uint {
+	ids := make([]uint, len(hits))
+	for i, h := range hits {
+		ids[i] = h.PostID
+	}
+	return ids
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	idx := NewMemoryIndex()
+	indexPosts(t, idx,
+		&model.Post{ID: 1, Title: "Cooking", Content: "go go go to the market"},
+		&model.Post{ID: 2, Title: "Go concurrency", Content: "channels and goroutines"},
+		&model.Post{ID: 3, Title: "Rust", Content: "ownership and borrowing"},
+		&model.Post{ID: 4, Title: "数据库索引", Content: "MySQL 全文索引"},
+		&model.Post{ID: 5, Title: "Draft about go", Content: "go", Status: model.PostStatusDraft},
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []uint
+	}{
+		{"title match ranks first", "go", []uint{2, 1}},
+		{"case insensitive", "RUST", []uint{3}},
+		{"any term matches", "rust channels", []uint{3, 2}},
+		{"chinese bigrams", "索引", []uint{4}},
+		{"no match", "python", []uint{}},
+		{"empty query", "  ", []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, total, err := idx.Search(context.Background(), tt.query, 10, 0)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hits = %v, want %v", got, tt.want)
+			}
+			if total != int64(len(tt.want)) {
+				t.Errorf("total = %d, want %d", total, len(tt.want))
+			}
+			for i := 1; i < len(hits); i++ {
+				if hits[i].Score > hits[i-1].Score {
+					t.Errorf("hits not sorted by score: %+v", hits)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryIndexUpdateAndRemove(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+	post := &model.Post{ID: 1, Title: "Gin", Content: "web framework"}
+	indexPosts(t, idx, post, &model.Post{ID: 2, Title: "Echo", Content: "web framework"})
+
+	search := func(query string) []uint {
+		hits, _, err := idx.Search(ctx, query, 10, 0)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		return hitIDs(hits)
+	}
+
+	// 重新索引时旧内容中的词被移除
+	post.Title, post.Content = "Fiber", "fast router"
+	indexPosts(t, idx, post)
+	if got := search("gin"); len(got) != 0 {
+		t.Errorf("old title still matches: %v", got)
+	}
+	if got := search("fiber"); !reflect.DeepEqual(got, []uint{1}) {
+		t.Errorf("new title hits = %v, want [1]", got)
+	}
+
+	// 撤回发布等同于移除
+	post.Status = model.PostStatusDraft
+	indexPosts(t, idx, post)
+	if got := search("fiber"); len(got) != 0 {
+		t.Errorf("draft still matches: %v", got)
+	}
+
+	if err := idx.Remove(ctx, 2); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if got := search("web"); len(got) != 0 {
+		t.Errorf("removed post still matches: %v", got)
+	}
+	if idx.totalLen != 0 || len(idx.postings) != 0 || len(idx.docLen) != 0 {
+		t.Errorf("index not empty after removing every post: len=%d postings=%d docs=%d", idx.totalLen, len(idx.postings), len(idx.docLen))
+	}
+}
+
+func TestMemoryIndexPagination(t *testing.T) {
+	idx := NewMemoryIndex()
+	for id := uint(1); id <= 5; id++ {
+		indexPosts(t, idx, &model.Post{ID: id, Title: "same", Content: "same"})
+	}
+
+	tests := []struct {
+		limit, offset int
+		want          []uint
+	}{
+		{2, 0, []uint{5, 4}},
+		{2, 2, []uint{3, 2}},
+		{2, 4, []uint{1}},
+		{2, 6, []uint{}},
+		{0, 3, []uint{2, 1}},
+	}
+	for _, tt := range tests {
+		hits, total, err := idx.Search(context.Background(), "same", tt.limit, tt.offset)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("limit %d offset %d: hits = %v, want %v", tt.limit, tt.offset, got, tt.want)
+		}
+		if total != 5 {
+			t.Errorf("limit %d offset %d: total = %d, want 5", tt.limit, tt.offset, total)
+		}
+	}
+}
+
+// TestMemoryIndexVisibleFilter 索引中过期的条目在分页前被剔除，不计入总数
+func TestMemoryIndexVisibleFilter(t *testing.T) {
+	idx := NewMemoryIndex()
+	for id := uint(1); id <= 5; id++ {
+		indexPosts(t, idx, &model.Post{ID: id, Title: "same", Content: "same"})
+	}
+	hidden := map[uint]bool{2: true, 5: true}
+	idx.visible = func(_ context.Context, ids []uint) ([]uint, error) {
+		var out []uint
+		for _, id := range ids {
+			if !hidden[id] {
+				out = append(out, id)
+			}
+		}
+		return out, nil
+	}
+
+	hits, total, err := idx.Search(context.Background(), "same", 2, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []uint{4, 3}) {
+		t.Errorf("first page = %v, want [4 3]", got)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	hits, _, _ = idx.Search(context.Background(), "same", 2, 2)
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []uint{1}) {
+		t.Errorf("second page = %v, want [1]", got)
+	}
+
+	errDB := errors.New("db down")
+	idx.visible = func(context.Context, []uint) ([]uint, error) { return nil, errDB }
+	if _, _, err := idx.Search(context.Background(), "same", 2, 0); !errors.Is(err, errDB) {
+		t.Errorf("Search error = %v, want %v", err, errDB)
+	}
+}
+
+func TestNewMemoryBuildsIndex(t *testing.T) {
+	posts := []model.Post{
+		{ID: 1, Title: "Published", Content: "gin", Status: model.PostStatusPublished},
+		{ID: 2, Title: "Draft", Content: "gin", Status: model.PostStatusDraft},
+	}
+	load := func(_ context.Context, fn func([]model.Post) error) error { return fn(posts) }
+
+	s, err := New(context.Background(), EngineAuto, model.DriverSQLite, nil, load, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	hits, _, err := s.Search(context.Background(), "gin", 10, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []uint{1}) {
+		t.Errorf("hits = %v, want [1]", got)
+	}
+
+	if _, err := New(context.Background(), "elastic", model.DriverSQLite, nil, load, nil); err == nil {
+		t.Error("New accepted an unknown engine")
+	}
+}
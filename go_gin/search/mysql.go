@@ -0,0 +1,70 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"gorm.io/gorm"
+)
+
+// fulltextIndex posts表上的全文索引名
+const fulltextIndex = "idx_posts_fulltext"
+
+// MySQLSearcher 基于 MySQL FULLTEXT 索引的搜索，索引由数据库自动维护
+type MySQLSearcher struct {
+	db *gorm.DB
+}
+
+// NewMySQLSearcher 创建MySQL搜索，ngram 全文索引由迁移 posts_fulltext 建立，这里只检查索引是否存在
+func NewMySQLSearcher(db *gorm.DB) (*MySQLSearcher, error) {
+	if !db.Migrator().HasIndex(&model.Post{}, fulltextIndex) {
+		return nil, fmt.Errorf("fulltext index %s not found on posts, run migrate up first", fulltextIndex)
+	}
+	return &MySQLSearcher{db: db}, nil
+}
+
+// Index 索引随数据写入自动更新，无需处理
+func (s *MySQLSearcher) Index(context.Context, *model.Post) error { return nil }
+
+// Remove 索引随数据删除自动更新，无需处理
+func (s *MySQLSearcher) Remove(context.Context, uint) error { return nil }
+
+// fulltextMatch 自然语言模式的全文匹配条件
+const fulltextMatch = "MATCH(title, content) AGAINST(? IN NATURAL LANGUAGE MODE)"
+
+// matching 命中检索词的已发布文章，Model 带上软删除条件，已删除的文章不计入总数
+func (s *MySQLSearcher) matching(ctx context.Context, query string) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&model.Post{}).
+		Where("status = ?", model.PostStatusPublished).Where(fulltextMatch, query)
+}
+
+// page 按相关度排序的一页命中
+func (s *MySQLSearcher) page(ctx context.Context, query string, limit, offset int) *gorm.DB {
+	return s.matching(ctx, query).
+		Select("id, "+fulltextMatch+" AS score", query).
+		Order("score DESC, id DESC").
+		Limit(limit).Offset(offset)
+}
+
+// Search 使用自然语言模式检索已发布的文章，按相关度排序
+func (s *MySQLSearcher) Search(ctx context.Context, query string, limit, offset int) ([]Hit, int64, error) {
+	var total int64
+	if err := s.matching(ctx, query).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		ID    uint
+		Score float64
+	}
+	if err := s.page(ctx, query, limit, offset).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]Hit, len(rows))
+	for i, r := range rows {
+		hits[i] = Hit{PostID: r.ID, Score: r.Score}
+	}
+	return hits, total, nil
+}
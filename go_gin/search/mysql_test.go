@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB 只生成SQL不连接数据库的 MySQL 连接
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:password@tcp(127.0.0.1:3306)/blog",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry run db: %v", err)
+	}
+	return db
+}
+
+func TestMySQLSearcherQueries(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := context.Background()
+
+	var total int64
+	var rows []struct {
+		ID    uint
+		Score float64
+	}
+	tests := []struct {
+		name  string
+		query func(tx *gorm.DB) *gorm.DB
+		want  []string
+	}{
+		{
+			name: "count",
+			query: func(tx *gorm.DB) *gorm.DB {
+				return (&MySQLSearcher{db: tx}).matching(ctx, "go 并发").Count(&total)
+			},
+			want: []string{
+				"SELECT count(*) FROM `posts`",
+				"status = 'published'",
+				"MATCH(title, content) AGAINST('go 并发' IN NATURAL LANGUAGE MODE)",
+				"`posts`.`deleted_at` IS NULL",
+			},
+		},
+		{
+			name: "page",
+			query: func(tx *gorm.DB) *gorm.DB {
+				return (&MySQLSearcher{db: tx}).page(ctx, "go", 10, 20).Scan(&rows)
+			},
+			want: []string{
+				"SELECT id, MATCH(title, content) AGAINST('go' IN NATURAL LANGUAGE MODE) AS score FROM `posts`",
+				"status = 'published'",
+				"`posts`.`deleted_at` IS NULL",
+				"ORDER BY score DESC, id DESC LIMIT 10 OFFSET 20",
+			},
+		},
+		{
+			name: "query is a bound parameter",
+			query: func(tx *gorm.DB) *gorm.DB {
+				return (&MySQLSearcher{db: tx}).matching(ctx, "') OR 1=1 -- ").Count(&total)
+			},
+			want: []string{`AGAINST(''') OR 1=1 -- ' IN NATURAL LANGUAGE MODE)`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql := db.ToSQL(tt.query)
+			for _, want := range tt.want {
+				if !strings.Contains(sql, want) {
+					t.Errorf("SQL %q does not contain %q", sql, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zhanglegen/go_task/go_gin/model"
+	"gorm.io/gorm"
+)
+
+// 搜索引擎类型
+const (
+	EngineAuto   = "auto"   // 根据数据库驱动自动选择
+	EngineMySQL  = "mysql"  // MySQL FULLTEXT 索引
+	EngineMemory = "memory" // 进程内倒排索引
+)
+
+// Hit 一条搜索命中，按 Score 从高到低排列
+type Hit struct {
+	PostID uint
+	Score  float64
+}
+
+// Searcher 文章全文搜索接口
+type Searcher interface {
+	// Index 新增或更新文章索引，只有已发布的文章会被搜索到
+	Index(ctx context.Context, post *model.Post) error
+	// Remove 删除文章索引
+	Remove(ctx context.Context, postID uint) error
+	// Search 返回一页命中结果以及命中总数
+	Search(ctx context.Context, query string, limit, offset int) ([]Hit, int64, error)
+}
+
+// PostLoader 分批读取全部文章，用于重建进程内索引
+type PostLoader func(ctx context.Context, fn func(posts []model.Post) error) error
+
+// VisibleFilter 返回 ids 中仍然已发布且未删除的文章ID
+// 进程内索引可能落后于数据库（如其他实例修改了文章），搜索时用它剔除过期的条目
+type VisibleFilter func(ctx context.Context, ids []uint) ([]uint, error)
+
+// New 按配置创建搜索引擎，auto 模式下 MySQL 使用 FULLTEXT，其他数据库使用进程内索引
+func New(ctx context.Context, engine, driver string, db *gorm.DB, load PostLoader, visible VisibleFilter) (Searcher, error) {
+	if engine == "" || engine == EngineAuto {
+		engine = EngineMemory
+		if driver == model.DriverMySQL {
+			engine = EngineMySQL
+		}
+	}
+
+	switch engine {
+	case EngineMySQL:
+		return NewMySQLSearcher(db)
+	case EngineMemory:
+		idx := NewMemoryIndex()
+		idx.visible = visible
+		if err := load(ctx, func(posts []model.Post) error {
+			for i := range posts {
+				if posts[i].Published() {
+					idx.add(&posts[i])
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("build search index: %w", err)
+		}
+		return idx, nil
+	default:
+		return nil, fmt.Errorf("unsupported search engine: %s", engine)
+	}
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// snippetRunes 摘要的最大字符数
+const snippetRunes = 120
+
+// Tokenize 把文本切分为检索词
+// 英文和数字按单词切分并转小写，中文按相邻两个字切分（单字成词时保留单字）
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			tokens = append(tokens, string(han))
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				tokens = append(tokens, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
+
+// uniqueTokens 去重后的检索词
+func uniqueTokens(text string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range Tokenize(text) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// matchRanges 找出 text 中所有检索词出现的位置（按字符下标），已合并重叠区间
+func matchRanges(runes []rune, terms []string) [][2]int {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var ranges [][2]int
+	for _, term := range terms {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) == term {
+				ranges = append(ranges, [2]int{i, i + len(t)})
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := [][2]int{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1] {
+			if r[1] > last[1] {
+				last[1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// markRanges 对 runes[start:end] 做HTML转义，并用 <mark> 包裹命中部分
+func markRanges(runes []rune, ranges [][2]int, start, end int) string {
+	var b strings.Builder
+	pos := start
+	for _, r := range ranges {
+		if r[1] <= start || r[0] >= end {
+			continue
+		}
+		from, to := max(r[0], start), min(r[1], end)
+		b.WriteString(html.EscapeString(string(runes[pos:from])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[from:to])))
+		b.WriteString("</mark>")
+		pos = to
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	return b.String()
+}
+
+// Highlight 返回HTML转义后的完整文本，命中的检索词用 <mark> 标记
+func Highlight(text, query string) string {
+	runes := []rune(text)
+	return markRanges(runes, matchRanges(runes, uniqueTokens(query)), 0, len(runes))
+}
+
+// Snippet 截取第一个命中位置附近的摘要并高亮，没有命中时返回开头部分
+func Snippet(text, query string) string {
+	runes := []rune(text)
+	ranges := matchRanges(runes, uniqueTokens(query))
+
+	start := 0
+	if len(ranges) > 0 {
+		start = max(ranges[0][0]-snippetRunes/4, 0)
+	}
+	end := min(start+snippetRunes, len(runes))
+	// 靠近结尾时向前补足长度
+	start = max(end-snippetRunes, 0)
+
+	s := markRanges(runes, ranges, start, end)
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(runes) {
+		s += "…"
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Go1.24 released", []string{"go1", "24", "released"}},
+		{"数据库", []string{"数据", "据库"}},
+		{"学 Go 语言", []string{"学", "go", "语言"}},
+		{"GORM和MySQL全文索引", []string{"gorm", "和", "mysql", "全文", "文索", "索引"}},
+		{"Ünïcödé café", []string{"ünïcödé", "café"}},
+		{"  ---  ", nil},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueTokens(t *testing.T) {
+	got := uniqueTokens("go Go GO gin go")
+	if want := []string{"go", "gin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueTokens = %q, want %q", got, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text, query, want string
+	}{
+		{"Learning Go with Gin", "go gin", "Learning <mark>Go</mark> with <mark>Gin</mark>"},
+		{"gopher", "go", "<mark>go</mark>pher"},
+		{"使用数据库索引", "数据库", "使用<mark>数据库</mark>索引"},
+		{"<b>Go</b> & more", "go", "&lt;b&gt;<mark>Go</mark>&lt;/b&gt; &amp; more"},
+		{"nothing here", "rust", "nothing here"},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text, tt.query); got != tt.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := make([]rune, 300)
+	for i := range long {
+		long[i] = 'a'
+	}
+	text := string(long[:200]) + " target " + string(long[:100])
+
+	tests := []struct {
+		name, text, query, want string
+	}{
+		{"short text", "Go is fun", "fun", "Go is <mark>fun</mark>"},
+		{"no match keeps beginning", string(long), "zzz", string(long[:snippetRunes]) + "…"},
+		{"match in the middle", text, "target", "…" + string(long[:snippetRunes/4-1]) + " <mark>target</mark> " + string(long[:snippetRunes-snippetRunes/4-len(" target ")+1]) + "…"},
+		{"match near the end", string(long[:200]) + " end", "end", "…" + string(long[:snippetRunes-len(" end")]) + " <mark>end</mark>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Snippet(tt.text, tt.query); got != tt.want {
+				t.Errorf("Snippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
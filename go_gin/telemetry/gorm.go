@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gorm 实例上保存埋点状态的键
+const (
+	startKey     = "telemetry:start"
+	parentCtxKey = "telemetry:parent_ctx"
+)
+
+// GormPlugin 为每次数据库操作记录耗时、错误指标并创建子span
+// span 的父节点取自 db.WithContext 传入的上下文，处理函数传入请求上下文即可串起整条链路
+type GormPlugin struct {
+	metrics *Metrics // 为 nil 时只记录span
+}
+
+// NewGormPlugin 创建 GORM 埋点插件，通过 db.Use 注册
+func NewGormPlugin(metrics *Metrics) *GormPlugin {
+	return &GormPlugin{metrics: metrics}
+}
+
+// Name 插件名称
+func (p *GormPlugin) Name() string {
+	return "telemetry"
+}
+
+// Initialize 在各类操作的 GORM 内置回调前后注册埋点
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	system := db.Dialector.Name()
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("telemetry:before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register("telemetry:after_create", p.after(system, "create")),
+		cb.Query().Before("gorm:query").Register("telemetry:before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register("telemetry:after_query", p.after(system, "query")),
+		cb.Update().Before("gorm:update").Register("telemetry:before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("telemetry:after_update", p.after(system, "update")),
+		cb.Delete().Before("gorm:delete").Register("telemetry:before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("telemetry:after_delete", p.after(system, "delete")),
+		cb.Row().Before("gorm:row").Register("telemetry:before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register("telemetry:after_row", p.after(system, "row")),
+		cb.Raw().Before("gorm:raw").Register("telemetry:before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("telemetry:after_raw", p.after(system, "raw")),
+	)
+}
+
+func (p *GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, _ := Tracer().Start(parent, "gorm."+operation, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(parentCtxKey, parent)
+		db.InstanceSet(startKey, time.Now())
+	}
+}
+
+func (p *GormPlugin) after(system, operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		start, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		span := trace.SpanFromContext(db.Statement.Context)
+		// 恢复调用方的上下文，同一个 Statement 上的后续操作不会挂到已结束的span下
+		if parent, ok := db.InstanceGet(parentCtxKey); ok {
+			db.Statement.Context = parent.(context.Context)
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		if p.metrics != nil {
+			p.metrics.ObserveQuery(table, operation, time.Since(start.(time.Time)), err)
+		}
+
+		if span.IsRecording() {
+			span.SetAttributes(
+				semconv.DBSystemKey.String(system),
+				semconv.DBSQLTable(table),
+				semconv.DBOperation(operation),
+				semconv.DBStatement(db.Statement.SQL.String()),
+				attribute.Int64("db.rows_affected", db.RowsAffected),
+			)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}
+		span.End()
+	}
+}
@@ -1,66 +1,112 @@
 package utils
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
-)
 
-var (
-	InfoLogger  *log.Logger
-	ErrorLogger *log.Logger
+	"github.com/zhanglegen/go_task/go_gin/config"
 )
 
-func InitLogger() error {
-	// 创建logs目录
-	logDir := "logs"
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return err
-	}
+// logWriter 当前的日志文件，服务退出时需要关闭
+var logWriter *RotatingWriter
 
-	// 获取当前日期用于日志文件名
-	currentDate := time.Now().Format("2006-01-02")
+// loggerKey 请求上下文中保存logger的键
+type loggerKey struct{}
 
-	// 创建info日志文件
-	infoLogPath := filepath.Join(logDir, "info_"+currentDate+".log")
-	infoFile, err := os.OpenFile(infoLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+// InitLogger 按配置初始化结构化日志，并设置为 slog 的默认logger
+// 日志写入 <dir>/app_<日期>.log，按天和大小切分，可选同时输出到标准输出
+func InitLogger(cfg config.LogConfig) error {
+	level, err := parseLevel(cfg.Level)
 	if err != nil {
 		return err
 	}
 
-	// 创建error日志文件
-	errorLogPath := filepath.Join(logDir, "error_"+currentDate+".log")
-	errorFile, err := os.OpenFile(errorLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	w, err := NewRotatingWriter(cfg.Dir, "app", int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxAge.Duration, cfg.Compress)
 	if err != nil {
-		infoFile.Close()
 		return err
 	}
 
-	// 初始化logger
-	InfoLogger = log.New(infoFile, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	ErrorLogger = log.New(errorFile, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+	var out io.Writer = w
+	if cfg.Console {
+		out = io.MultiWriter(w, os.Stdout)
+	}
+
+	opts := &slog.HandlerOptions{Level: level, AddSource: level == slog.LevelDebug}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "logfmt":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		handler = slog.NewJSONHandler(out, opts)
+	}
 
+	if logWriter != nil {
+		logWriter.Close()
+	}
+	logWriter = w
+	slog.SetDefault(slog.New(handler))
 	return nil
 }
 
+// CloseLogger 关闭日志文件，之后的日志输出到标准错误
+func CloseLogger() error {
+	if logWriter == nil {
+		return nil
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	err := logWriter.Close()
+	logWriter = nil
+	return err
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level: %s", s)
+}
+
+// WithLogger 把logger放入上下文，用于携带请求ID等字段
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Logger 取出上下文中的logger，没有时返回默认logger
+func Logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
 // LogInfo 记录信息日志
 func LogInfo(message string) {
-	if InfoLogger != nil {
-		InfoLogger.Println(message)
-	}
+	slog.Info(message)
 }
 
 // LogError 记录错误日志
 func LogError(message string) {
-	if ErrorLogger != nil {
-		ErrorLogger.Println(message)
-	}
+	slog.Error(message)
 }
 
 // LogErrorWithDetails 记录带详细信息的错误日志
 func LogErrorWithDetails(message string, err error) {
-	if ErrorLogger != nil {
-		ErrorLogger.Printf("%s: %v", message, err)
-	}
-}
\ No newline at end of file
+	slog.Error(message, "error", err)
+}
+
+// LogDuration 把耗时转换为毫秒，便于日志检索和统计
+func LogDuration(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
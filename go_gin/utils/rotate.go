@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingWriter 按天和按大小切分的日志文件
+// 当前文件为 <prefix>_<日期>.log，同一天超过大小上限时依次改名为 <prefix>_<日期>.<序号>.log，
+// 切分后的旧文件可以压缩为 .gz，超过保留天数的文件会被删除
+type RotatingWriter struct {
+	mu       sync.Mutex
+	cleanMu  sync.Mutex // 串行执行后台清理，避免重复压缩同一文件
+	dir      string
+	prefix   string
+	maxSize  int64         // 单个文件的字节上限，0表示不限
+	maxAge   time.Duration // 旧文件保留时长，0表示不清理
+	compress bool
+
+	file *os.File
+	date string
+	size int64
+	now  func() time.Time
+}
+
+// NewRotatingWriter 在dir目录下创建日志文件
+func NewRotatingWriter(dir, prefix string, maxSize int64, maxAge time.Duration, compress bool) (*RotatingWriter, error) {
+	return newRotatingWriter(dir, prefix, maxSize, maxAge, compress, time.Now)
+}
+
+// newRotatingWriter 可以指定时钟的 NewRotatingWriter，用于测试跨天切分和过期清理
+func newRotatingWriter(dir, prefix string, maxSize int64, maxAge time.Duration, compress bool, now func() time.Time) (*RotatingWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	w := &RotatingWriter{
+		dir:      dir,
+		prefix:   prefix,
+		maxSize:  maxSize,
+		maxAge:   maxAge,
+		compress: compress,
+		now:      now,
+	}
+	if err := w.openCurrent(); err != nil {
+		return nil, err
+	}
+	go w.cleanup()
+	return w, nil
+}
+
+// Write 写入一条日志，日期变化或超过大小上限时先切分文件
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	rotated := false
+	if w.now().Format("2006-01-02") != w.date {
+		// 跨天：直接切换到新日期的文件
+		if err := w.file.Close(); err != nil {
+			return 0, err
+		}
+		if err := w.openCurrent(); err != nil {
+			return 0, err
+		}
+		rotated = true
+	} else if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotateBySize(); err != nil {
+			return 0, err
+		}
+		rotated = true
+	}
+	if rotated {
+		go w.cleanup()
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close 关闭当前日志文件
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingWriter) currentPath() string {
+	return filepath.Join(w.dir, fmt.Sprintf("%s_%s.log", w.prefix, w.date))
+}
+
+// openCurrent 打开当天的日志文件，调用方需持有锁
+func (w *RotatingWriter) openCurrent() error {
+	w.date = w.now().Format("2006-01-02")
+	f, err := os.OpenFile(w.currentPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+// rotateBySize 把当前文件改名为下一个序号，调用方需持有锁
+func (w *RotatingWriter) rotateBySize() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	for i := 1; ; i++ {
+		name := filepath.Join(w.dir, fmt.Sprintf("%s_%s.%d.log", w.prefix, w.date, i))
+		if _, err := os.Stat(name); err == nil {
+			continue
+		}
+		if _, err := os.Stat(name + ".gz"); err == nil {
+			continue
+		}
+		if err := os.Rename(w.currentPath(), name); err != nil {
+			return err
+		}
+		break
+	}
+	return w.openCurrent()
+}
+
+// cleanup 压缩已切分的文件并删除过期文件，在后台执行
+// 失败时记录到 slog；cleanup 不持有 w.mu，日志写回本文件不会死锁
+func (w *RotatingWriter) cleanup() {
+	w.cleanMu.Lock()
+	defer w.cleanMu.Unlock()
+
+	w.mu.Lock()
+	current := w.currentPath()
+	w.mu.Unlock()
+
+	matches, err := filepath.Glob(filepath.Join(w.dir, w.prefix+"_*"))
+	if err != nil {
+		slog.Error("Failed to list log files", "dir", w.dir, "error", err)
+		return
+	}
+	sort.Strings(matches)
+	for _, name := range matches {
+		if name == current {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		if w.maxAge > 0 && w.now().Sub(info.ModTime()) > w.maxAge {
+			if err := os.Remove(name); err != nil {
+				slog.Error("Failed to remove expired log file", "file", name, "error", err)
+			}
+			continue
+		}
+		if w.compress && strings.HasSuffix(name, ".log") {
+			if err := gzipFile(name); err != nil {
+				slog.Error("Failed to compress log file", "file", name, "error", err)
+			}
+		}
+	}
+}
+
+// gzipFile 把文件压缩为 .gz 后删除原文件
+func gzipFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
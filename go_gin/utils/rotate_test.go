@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock 可以手动推进的时钟，后台清理和写入会并发读取
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestWriter(t *testing.T, maxSize int64, maxAge time.Duration, compress bool) (*RotatingWriter, *testClock, string) {
+	t.Helper()
+	dir := t.TempDir()
+	clock := &testClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)}
+	w, err := newRotatingWriter(dir, "app", maxSize, maxAge, compress, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotatingWriter: %v", err)
+	}
+	// 等待后台清理结束，避免删除临时目录时还有文件在写入
+	waitCleanup := func() {
+		w.cleanMu.Lock()
+		w.cleanMu.Unlock()
+	}
+	t.Cleanup(func() {
+		w.Close()
+		waitCleanup()
+	})
+	waitCleanup()
+	return w, clock, dir
+}
+
+// listFiles 目录中的文件名，已排序
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("open gzip %s: %v", path, err)
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			t.Fatalf("read gzip %s: %v", path, err)
+		}
+	}
+	return string(data)
+}
+
+func write(t *testing.T, w *RotatingWriter, s string) {
+	t.Helper()
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+func TestRotatingWriterSizeRotation(t *testing.T) {
+	w, _, dir := newTestWriter(t, 10, 0, false)
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cc\n", "dddddd\n"} {
+		write(t, w, line)
+	}
+
+	want := []string{"app_2024-05-01.1.log", "app_2024-05-01.2.log", "app_2024-05-01.log"}
+	if got := listFiles(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	contents := map[string]string{
+		"app_2024-05-01.1.log": "aaaaaa\n",
+		"app_2024-05-01.2.log": "bbbbbb\ncc\n",
+		"app_2024-05-01.log":   "dddddd\n",
+	}
+	for name, want := range contents {
+		if got := readFile(t, filepath.Join(dir, name)); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestRotatingWriterOversizedWrite 单条超过上限的日志不会切出空文件
+func TestRotatingWriterOversizedWrite(t *testing.T) {
+	w, _, dir := newTestWriter(t, 4, 0, false)
+	write(t, w, "0123456789\n")
+	if got := listFiles(t, dir); len(got) != 1 {
+		t.Errorf("files = %v, want only the current file", got)
+	}
+}
+
+func TestRotatingWriterReopensExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)}
+	if err := os.WriteFile(filepath.Join(dir, "app_2024-05-01.log"), []byte("12345678"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := newRotatingWriter(dir, "app", 10, 0, false, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotatingWriter: %v", err)
+	}
+	defer w.Close()
+
+	// 重启后沿用已有文件的大小继续计算
+	write(t, w, "abc\n")
+	if got := readFile(t, filepath.Join(dir, "app_2024-05-01.1.log")); got != "12345678" {
+		t.Errorf("rotated file = %q, want previous content", got)
+	}
+}
+
+func TestRotatingWriterDailyRotation(t *testing.T) {
+	w, clock, dir := newTestWriter(t, 0, 0, false)
+	write(t, w, "day one\n")
+	clock.Advance(24 * time.Hour)
+	write(t, w, "day two\n")
+
+	if got := readFile(t, filepath.Join(dir, "app_2024-05-01.log")); got != "day one\n" {
+		t.Errorf("first day = %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "app_2024-05-02.log")); got != "day two\n" {
+		t.Errorf("second day = %q", got)
+	}
+}
+
+func TestRotatingWriterCompress(t *testing.T) {
+	w, _, dir := newTestWriter(t, 10, 0, true)
+	write(t, w, "aaaaaa\n")
+	write(t, w, "bbbbbb\n")
+	w.cleanup()
+
+	want := []string{"app_2024-05-01.1.log.gz", "app_2024-05-01.log"}
+	if got := listFiles(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "app_2024-05-01.1.log.gz")); got != "aaaaaa\n" {
+		t.Errorf("compressed content = %q", got)
+	}
+
+	// 已压缩的序号不会被重复使用
+	write(t, w, "cccccc\n")
+	w.cleanup()
+	want = []string{"app_2024-05-01.1.log.gz", "app_2024-05-01.2.log.gz", "app_2024-05-01.log"}
+	if got := listFiles(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestRotatingWriterRetention(t *testing.T) {
+	w, clock, dir := newTestWriter(t, 0, 48*time.Hour, false)
+	write(t, w, "current\n")
+
+	now := clock.Now()
+	files := map[string]time.Time{
+		"app_2024-04-20.log":    now.Add(-11 * 24 * time.Hour),
+		"app_2024-04-29.1.log":  now.Add(-49 * time.Hour),
+		"app_2024-04-30.log.gz": now.Add(-24 * time.Hour),
+		"other.log":             now.Add(-30 * 24 * time.Hour),
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 当前文件本身也很旧，但仍在写入，不能删除
+	current := filepath.Join(dir, "app_2024-05-01.log")
+	old := now.Add(-72 * time.Hour)
+	if err := os.Chtimes(current, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	w.cleanup()
+	want := []string{"app_2024-04-30.log.gz", "app_2024-05-01.log", "other.log"}
+	if got := listFiles(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+// TestRotatingWriterCompressFailure 压缩失败时保留原文件并记录到 slog
+func TestRotatingWriterCompressFailure(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	w, _, dir := newTestWriter(t, 0, 0, true)
+	old := filepath.Join(dir, "app_2024-04-30.log")
+	if err := os.WriteFile(old, []byte("yesterday\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 目标 .gz 路径被目录占用，无法创建
+	if err := os.Mkdir(old+".gz", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	w.cleanup()
+	if out := buf.String(); !strings.Contains(out, "Failed to compress log file") || !strings.Contains(out, old) {
+		t.Errorf("log output = %q, want compress failure", out)
+	}
+	if got := readFile(t, old); got != "yesterday\n" {
+		t.Errorf("original file = %q, want it kept", got)
+	}
+}
+
+func TestRotatingWriterClose(t *testing.T) {
+	w, _, _ := newTestWriter(t, 0, 0, false)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("Write after Close = %v, want os.ErrClosed", err)
+	}
+}